@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 
 	"app/internal/app/adapter"
@@ -12,12 +13,23 @@ func main() {
 	initializr, cfg := initializer.InitApplication()
 	server := initializr.Server
 
+	switch command := flag.Arg(0); command {
+	case "":
+	case "purge":
+		if err := purge(initializr, cfg); err != nil {
+			initializr.Logger.Fatal(fmt.Sprintf("Purge error: %s", err.Error()))
+		}
+		return
+	default:
+		initializr.Logger.Fatal(fmt.Sprintf("Unknown command: %s", command))
+	}
+
 	adapters := []adapter.Adapter{
 		user.NewUserAdapter(),
 	}
 
 	for _, a := range adapters {
-		err := a.Initialize(initializr.Logger, *cfg, initializr.Server, initializr.DB)
+		err := a.Initialize(initializr.Logger, *cfg, initializr.Server, initializr.DB, initializr.Cache, initializr.Bus, initializr.Metrics)
 		if err != nil {
 			initializr.Logger.Fatal(fmt.Sprintf("Usecase %s load error: %s", a.Name(), err.Error()))
 		} else {
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	memoryAttempt "app/internal/app/repository/memory/attempt"
+	auditRepository "app/internal/app/repository/postgres/audit"
+	outboxRepository "app/internal/app/repository/postgres/outbox"
+	userRepository "app/internal/app/repository/postgres/user"
+	lockoutService "app/internal/app/usecase/lockout"
+	userService "app/internal/app/usecase/user"
+	"app/internal/pkg/common"
+	"app/internal/pkg/config"
+	"app/internal/pkg/hasher"
+	"app/internal/pkg/initializer"
+	"app/internal/pkg/mailer"
+	"app/internal/pkg/reqctx"
+	"app/internal/pkg/token"
+)
+
+// purge permanently removes users soft deleted longer than the configured
+// retention and exits, without starting the HTTP server.
+func purge(initializr *initializer.Initializer, cfg *config.Config) error {
+	defer initializr.DB.Close()
+
+	audit, err := auditRepository.NewAuditRepository(initializr.DB, initializr.Logger)
+	if err != nil {
+		return err
+	}
+
+	outbox, err := outboxRepository.NewOutboxRepository(initializr.DB, initializr.Logger)
+	if err != nil {
+		return err
+	}
+
+	repository, err := userRepository.NewUserRepository(initializr.DB, audit, outbox, initializr.Logger)
+	if err != nil {
+		return err
+	}
+
+	passwordHasher, err := hasher.New(cfg.Hasher)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := token.New(cfg.Auth)
+	if err != nil {
+		return err
+	}
+
+	mail, err := mailer.New(cfg.Mailer)
+	if err != nil {
+		return err
+	}
+
+	attempts, err := memoryAttempt.NewMemoryAttemptStore(initializr.Logger)
+	if err != nil {
+		return err
+	}
+
+	lockout, err := lockoutService.NewLockoutService(attempts, cfg.Lockout, initializr.Logger)
+	if err != nil {
+		return err
+	}
+
+	service, err := userService.NewUserService(repository, passwordHasher, lockout, tokens, mail, initializr.Bus, initializr.Metrics, cfg.User, initializr.Logger)
+	if err != nil {
+		return err
+	}
+
+	ctx := reqctx.WithRequestID(context.Background(), common.GenerateUUID())
+	purged, err := service.Purge(ctx)
+	if err != nil {
+		return err
+	}
+
+	initializr.Logger.Info(fmt.Sprintf("Purge finished, %d users removed", purged))
+	return nil
+}
@@ -1,10 +1,13 @@
 package adapter
 
 import (
+	"app/internal/pkg/cache"
 	"app/internal/pkg/config"
 	"app/internal/pkg/database"
+	"app/internal/pkg/eventbus"
 	"app/internal/pkg/httpserver"
 	"app/internal/pkg/logger"
+	"app/internal/pkg/metrics"
 )
 
 type Adapter interface {
@@ -13,6 +16,9 @@ type Adapter interface {
 		cfg config.Config,
 		server *httpserver.Server,
 		db *database.Postgres,
+		cache *cache.Redis,
+		bus *eventbus.Bus,
+		metrics *metrics.Registry,
 	) error
 
 	Name() string
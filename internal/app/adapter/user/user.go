@@ -2,21 +2,61 @@ package user
 
 import (
 	"app/internal/app/adapter"
+	auditHandler "app/internal/app/controller/rest/audit"
+	authHandler "app/internal/app/controller/rest/auth"
+	roleHandler "app/internal/app/controller/rest/role"
 	userHandler "app/internal/app/controller/rest/user"
+	"app/internal/app/repository/attempt"
+	memoryAttempt "app/internal/app/repository/memory/attempt"
+	memorySession "app/internal/app/repository/memory/session"
+	auditRepository "app/internal/app/repository/postgres/audit"
+	outboxRepository "app/internal/app/repository/postgres/outbox"
+	resetRepository "app/internal/app/repository/postgres/reset"
+	roleRepository "app/internal/app/repository/postgres/role"
 	userRepository "app/internal/app/repository/postgres/user"
+	redisAttempt "app/internal/app/repository/redis/attempt"
+	redisSession "app/internal/app/repository/redis/session"
+	redisUser "app/internal/app/repository/redis/user"
+	"app/internal/app/repository/session"
+	auditService "app/internal/app/usecase/audit"
+	authService "app/internal/app/usecase/auth"
+	lockoutService "app/internal/app/usecase/lockout"
+	outboxService "app/internal/app/usecase/outbox"
+	roleService "app/internal/app/usecase/role"
 	userService "app/internal/app/usecase/user"
+	"app/internal/pkg/cache"
 	"app/internal/pkg/config"
 	"app/internal/pkg/database"
+	"app/internal/pkg/eventbus"
+	"app/internal/pkg/hasher"
 	"app/internal/pkg/httpserver"
 	"app/internal/pkg/logger"
+	"app/internal/pkg/mailer"
+	"app/internal/pkg/metrics"
+	"app/internal/pkg/middleware"
+	"app/internal/pkg/publisher"
+	"app/internal/pkg/token"
 )
 
 const name = "user crud"
 
 type userAdapter struct {
-	Handler    userHandler.Handler
-	Repository userRepository.Repository
-	Service    userService.Service
+	Handler        userHandler.Handler
+	Repository     userRepository.Repository
+	Service        userService.Service
+	AuthHandler    authHandler.Handler
+	AuthService    authService.Service
+	Sessions       session.Store
+	Lockout        lockoutService.Service
+	Resets         resetRepository.Repository
+	Mailer         mailer.Interface
+	RoleHandler    roleHandler.Handler
+	RoleRepository roleRepository.Repository
+	RoleService    roleService.Service
+	AuditHandler   auditHandler.Handler
+	AuditService   auditService.Service
+	Outbox         outboxService.Service
+	Publisher      publisher.Interface
 }
 
 var _ adapter.Adapter = (*userAdapter)(nil)
@@ -31,21 +71,156 @@ func (u *userAdapter) Name() string {
 
 func (u *userAdapter) Initialize(
 	log logger.Interface,
-	_ config.Config,
+	cfg config.Config,
 	server *httpserver.Server,
 	db *database.Postgres,
+	cache *cache.Redis,
+	bus *eventbus.Bus,
+	metrics *metrics.Registry,
 ) error {
-	repository, err := userRepository.NewUserRepository(db, log)
+	audit, err := auditRepository.NewAuditRepository(db, log)
 	if err != nil {
 		return err
 	}
 
-	service, err := userService.NewUserService(repository, log)
+	outbox, err := outboxRepository.NewOutboxRepository(db, log)
 	if err != nil {
 		return err
 	}
 
-	handler, err := userHandler.NewUserHandler(service, log, server.Mux)
+	repository, err := userRepository.NewUserRepository(db, audit, outbox, log)
+	if err != nil {
+		return err
+	}
+
+	if cache != nil {
+		repository, err = redisUser.NewCachedUserRepository(repository, cache, cfg.Cache, log)
+		if err != nil {
+			return err
+		}
+	}
+
+	events, err := publisher.New(cfg.Outbox, log)
+	if err != nil {
+		return err
+	}
+
+	relay, err := outboxService.NewOutboxRelay(outbox, events, cfg.Outbox, log)
+	if err != nil {
+		return err
+	}
+	server.Go("outbox relay", relay.Run)
+
+	passwordHasher, err := hasher.New(cfg.Hasher)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := token.New(cfg.Auth)
+	if err != nil {
+		return err
+	}
+
+	mail, err := mailer.New(cfg.Mailer)
+	if err != nil {
+		return err
+	}
+
+	var attempts attempt.Store
+	if cache != nil {
+		attempts, err = redisAttempt.NewRedisAttemptStore(cache, log)
+	} else {
+		log.Warn("REDIS_URL is not set, failed login attempts are counted per instance")
+		attempts, err = memoryAttempt.NewMemoryAttemptStore(log)
+	}
+	if err != nil {
+		return err
+	}
+
+	lockout, err := lockoutService.NewLockoutService(attempts, cfg.Lockout, log)
+	if err != nil {
+		return err
+	}
+
+	service, err := userService.NewUserService(repository, passwordHasher, lockout, tokens, mail, bus, metrics, cfg.User, log)
+	if err != nil {
+		return err
+	}
+
+	service, err = userService.NewTracingService(service)
+	if err != nil {
+		return err
+	}
+
+	var sessions session.Store
+	if cache != nil {
+		sessions, err = redisSession.NewRedisSessionStore(cache, log)
+	} else {
+		log.Warn("REDIS_URL is not set, sessions are kept in memory and lost on restart")
+		sessions, err = memorySession.NewMemorySessionStore(log)
+	}
+	if err != nil {
+		return err
+	}
+
+	resets, err := resetRepository.NewResetRepository(db, log)
+	if err != nil {
+		return err
+	}
+
+	auth, err := authService.NewAuthService(service, tokens, sessions, resets, mail, db, bus, cfg.Auth, log)
+	if err != nil {
+		return err
+	}
+
+	auth, err = authService.NewTracingService(auth)
+	if err != nil {
+		return err
+	}
+
+	roles, err := roleRepository.NewRoleRepository(db, audit, log)
+	if err != nil {
+		return err
+	}
+
+	roleSvc, err := roleService.NewRoleService(roles, service, cfg.Auth, log)
+	if err != nil {
+		return err
+	}
+
+	roleSvc, err = roleService.NewTracingService(roleSvc)
+	if err != nil {
+		return err
+	}
+
+	auditSvc, err := auditService.NewAuditService(audit, log)
+	if err != nil {
+		return err
+	}
+
+	auditSvc, err = auditService.NewTracingService(auditSvc)
+	if err != nil {
+		return err
+	}
+
+	server.Router.SetIdentityExtractor(&middleware.BearerIdentity{Tokens: tokens, Resolver: roleSvc})
+
+	authH, err := authHandler.NewAuthHandler(auth, log, server.Router)
+	if err != nil {
+		return err
+	}
+
+	handler, err := userHandler.NewUserHandler(service, log, server.Router)
+	if err != nil {
+		return err
+	}
+
+	roleH, err := roleHandler.NewRoleHandler(roleSvc, log, server.Router)
+	if err != nil {
+		return err
+	}
+
+	auditH, err := auditHandler.NewAuditHandler(auditSvc, log, server.Router)
 	if err != nil {
 		return err
 	}
@@ -53,6 +228,19 @@ func (u *userAdapter) Initialize(
 	u.Handler = *handler
 	u.Service = service
 	u.Repository = repository
+	u.AuthHandler = *authH
+	u.AuthService = auth
+	u.Sessions = sessions
+	u.Lockout = lockout
+	u.Resets = resets
+	u.Mailer = mail
+	u.RoleHandler = *roleH
+	u.RoleRepository = roles
+	u.RoleService = roleSvc
+	u.AuditHandler = *auditH
+	u.AuditService = auditSvc
+	u.Outbox = relay
+	u.Publisher = events
 
 	return nil
 }
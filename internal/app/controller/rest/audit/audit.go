@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"app/internal/app/controller/rest/audit/converter"
+	"app/internal/app/controller/rest/response"
+	"app/internal/app/usecase/audit"
+	"app/internal/domain"
+	"app/internal/pkg/httpserver"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/middleware"
+	"errors"
+	"net/http"
+)
+
+type Handler struct {
+	Service audit.Service
+	logger  logger.Interface
+}
+
+func NewAuditHandler(
+	service audit.Service,
+	logger logger.Interface,
+	router *httpserver.Router,
+) (*Handler, error) {
+	if service == nil {
+		return nil, errors.New("Handler.NewAuditHandler: service is null")
+	}
+
+	if logger == nil {
+		return nil, errors.New("Handler.NewAuditHandler: logger is null")
+	}
+
+	if router == nil {
+		return nil, errors.New("Handler.NewAuditHandler: router is null")
+	}
+
+	handler := &Handler{Service: service, logger: logger}
+	router.HandleFunc("GET /audit", middleware.Require(domain.PermissionAuditRead), handler.ListEntries)
+	return handler, nil
+}
+
+func (h *Handler) ListEntries(w http.ResponseWriter, r *http.Request) {
+	filter, err := converter.ToAuditFilterFromQuery(r.URL.Query())
+	if err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	page, err := h.Service.List(r.Context(), filter)
+	if err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	response.JSON(w, r, h.logger, http.StatusOK, converter.ToEntryListFromDomain(page))
+}
@@ -0,0 +1,79 @@
+package converter
+
+import (
+	"app/internal/app/controller/rest/audit/model"
+	"app/internal/domain"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func ToAuditFilterFromQuery(query url.Values) (domain.AuditFilter, error) {
+	filter := domain.AuditFilter{
+		Actor:      query.Get("actor"),
+		Action:     query.Get("action"),
+		TargetUUID: query.Get("target"),
+		Cursor:     query.Get("cursor"),
+	}
+
+	if filter.TargetUUID != "" {
+		if _, err := uuid.Parse(filter.TargetUUID); err != nil {
+			return filter, domain.NewError(domain.KindInvalid, "target must be a UUID")
+		}
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, domain.NewError(domain.KindInvalid, "limit must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+
+	for key, target := range map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	} {
+		if v := query.Get(key); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, domain.NewError(domain.KindInvalid, key+" must be an RFC 3339 timestamp")
+			}
+			*target = &t
+		}
+	}
+
+	return filter, nil
+}
+
+func ToEntryResponseFromDomain(entry *domain.AuditEntry) rest.EntryResponse {
+	changes := make(map[string]rest.Change, len(entry.Changes))
+	for field, change := range entry.Changes {
+		changes[field] = rest.Change{Before: change.Before, After: change.After}
+	}
+
+	return rest.EntryResponse{
+		ID:         entry.ID,
+		Actor:      entry.Actor,
+		Action:     entry.Action,
+		TargetUUID: entry.TargetUUID,
+		RequestID:  entry.RequestID,
+		ClientIP:   entry.ClientIP,
+		Changes:    changes,
+		CreatedAt:  entry.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func ToEntryListFromDomain(page *domain.AuditPage) rest.EntryList {
+	entries := make([]rest.EntryResponse, 0, len(page.Entries))
+	for _, entry := range page.Entries {
+		entries = append(entries, ToEntryResponseFromDomain(entry))
+	}
+
+	return rest.EntryList{
+		Entries:    entries,
+		NextCursor: page.NextCursor,
+	}
+}
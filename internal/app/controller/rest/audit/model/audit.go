@@ -0,0 +1,22 @@
+package rest
+
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type EntryResponse struct {
+	ID         int64             `json:"id"`
+	Actor      string            `json:"actor,omitempty"`
+	Action     string            `json:"action"`
+	TargetUUID string            `json:"target_uuid,omitempty"`
+	RequestID  string            `json:"request_id,omitempty"`
+	ClientIP   string            `json:"client_ip,omitempty"`
+	Changes    map[string]Change `json:"changes"`
+	CreatedAt  string            `json:"created_at"`
+}
+
+type EntryList struct {
+	Entries    []EntryResponse `json:"entries"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"app/internal/app/controller/rest/auth/converter"
+	"app/internal/app/controller/rest/auth/model"
+	"app/internal/app/controller/rest/response"
+	"app/internal/app/usecase/auth"
+	"app/internal/domain"
+	"app/internal/pkg/httpserver"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/middleware"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+type Handler struct {
+	Service auth.Service
+	logger  logger.Interface
+}
+
+func NewAuthHandler(
+	service auth.Service,
+	logger logger.Interface,
+	router *httpserver.Router,
+) (*Handler, error) {
+	if service == nil {
+		return nil, errors.New("Handler.NewAuthHandler: service is null")
+	}
+
+	if logger == nil {
+		return nil, errors.New("Handler.NewAuthHandler: logger is null")
+	}
+
+	if router == nil {
+		return nil, errors.New("Handler.NewAuthHandler: router is null")
+	}
+
+	handler := &Handler{Service: service, logger: logger}
+	router.HandleFunc("POST /auth/login", middleware.Public(), handler.Login)
+	router.HandleFunc("POST /auth/refresh", middleware.Public(), handler.Refresh)
+	router.HandleFunc("POST /auth/logout", middleware.Public(), handler.Logout)
+	router.HandleFunc("POST /auth/password/forgot", middleware.Public(), handler.ForgotPassword)
+	router.HandleFunc("POST /auth/password/reset", middleware.Public(), handler.ResetPassword)
+	return handler, nil
+}
+
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	var req rest.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, r, h.logger, domain.ErrorInvalidPayload)
+		return
+	}
+
+	if !response.Validate(w, r, h.logger, req) {
+		return
+	}
+
+	tokens, err := h.Service.Login(r.Context(), req.Login, req.Password)
+	if err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	h.writeTokens(w, r, tokens)
+}
+
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req rest.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, r, h.logger, domain.ErrorInvalidPayload)
+		return
+	}
+
+	if !response.Validate(w, r, h.logger, req) {
+		return
+	}
+
+	tokens, err := h.Service.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	h.writeTokens(w, r, tokens)
+}
+
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req rest.LogoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, r, h.logger, domain.ErrorInvalidPayload)
+		return
+	}
+
+	if !response.Validate(w, r, h.logger, req) {
+		return
+	}
+
+	if err := h.Service.Logout(r.Context(), req.RefreshToken, req.All); err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ForgotPassword always answers 202, whether or not the login exists.
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req rest.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, r, h.logger, domain.ErrorInvalidPayload)
+		return
+	}
+
+	if !response.Validate(w, r, h.logger, req) {
+		return
+	}
+
+	if err := h.Service.ForgotPassword(r.Context(), req.Login); err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req rest.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, r, h.logger, domain.ErrorInvalidPayload)
+		return
+	}
+
+	if !response.Validate(w, r, h.logger, req) {
+		return
+	}
+
+	if err := h.Service.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) writeTokens(w http.ResponseWriter, r *http.Request, tokens *domain.Tokens) {
+	w.Header().Set("Cache-Control", "no-store")
+	response.JSON(w, r, h.logger, http.StatusOK, converter.ToTokenResponseFromDomain(tokens))
+}
@@ -0,0 +1,17 @@
+package converter
+
+import (
+	"app/internal/app/controller/rest/auth/model"
+	"app/internal/domain"
+	"time"
+)
+
+func ToTokenResponseFromDomain(tokens *domain.Tokens) rest.TokenResponse {
+	return rest.TokenResponse{
+		AccessToken:      tokens.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(time.Until(tokens.ExpiresAt).Seconds()),
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresIn: int64(time.Until(tokens.RefreshExpiresAt).Seconds()),
+	}
+}
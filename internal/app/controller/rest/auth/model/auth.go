@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"app/internal/pkg/validation"
+)
+
+type LoginRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+func (r LoginRequest) Validate() error {
+	return validation.New().
+		Field("login", r.Login, validation.Required()).
+		Field("password", r.Password, validation.Required()).
+		Err()
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (r RefreshRequest) Validate() error {
+	return validation.New().
+		Field("refresh_token", r.RefreshToken, validation.Required()).
+		Err()
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	All          bool   `json:"all"`
+}
+
+func (r LogoutRequest) Validate() error {
+	return validation.New().
+		Field("refresh_token", r.RefreshToken, validation.Required()).
+		Err()
+}
+
+type ForgotPasswordRequest struct {
+	Login string `json:"login"`
+}
+
+func (r ForgotPasswordRequest) Validate() error {
+	return validation.New().
+		Field("login", r.Login, validation.Required()).
+		Err()
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (r ResetPasswordRequest) Validate() error {
+	return validation.New().
+		Field("token", r.Token, validation.Required()).
+		Field("password", r.Password, validation.Required(), validation.Password(validation.DefaultPasswordPolicy)).
+		Err()
+}
+
+type TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
@@ -0,0 +1,273 @@
+package response_test
+
+import (
+	auditConverter "app/internal/app/controller/rest/audit/converter"
+	auditModel "app/internal/app/controller/rest/audit/model"
+	authConverter "app/internal/app/controller/rest/auth/converter"
+	authModel "app/internal/app/controller/rest/auth/model"
+	roleConverter "app/internal/app/controller/rest/role/converter"
+	roleModel "app/internal/app/controller/rest/role/model"
+	userConverter "app/internal/app/controller/rest/user/converter"
+	userModel "app/internal/app/controller/rest/user/model"
+	"app/internal/domain"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Domain structs carry secrets and Go style field names, so handlers convert
+// them to REST models before writing them. These tests fail when a model
+// holds a domain type or a handler writes a value that did not go through a
+// converter.
+
+var domainPkgPath = reflect.TypeOf(domain.User{}).PkgPath()
+
+// models lists a value of every type declared in the REST model packages.
+// Responses are built by their converters from fully populated domain
+// values, so that interface fields hold what they hold in production.
+func models() []interface{} {
+	now := time.Now()
+	user := &domain.User{
+		Uuid:          "6f1c2d1e-7a4b-4c55-9a0e-2f1f0c6d9b11",
+		Login:         "alice",
+		Password:      "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$a2V5",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		CreatedAt:     now,
+		UpdatedAt:     &now,
+		DeletedAt:     &now,
+		Version:       3,
+	}
+	entry := &domain.AuditEntry{
+		ID:         1,
+		Actor:      user.Uuid,
+		Action:     domain.AuditUserUpdated,
+		TargetUUID: user.Uuid,
+		RequestID:  "req-1",
+		ClientIP:   "192.0.2.1",
+		Changes: map[string]domain.AuditChange{
+			"login":    {Before: "bob", After: "alice"},
+			"password": {Before: domain.Redacted, After: domain.Redacted},
+		},
+		CreatedAt: now,
+	}
+	role := &domain.Role{Name: domain.RoleAdmin, Description: "Administrator", Permissions: []string{domain.PermissionUsersRead}}
+	tokens := &domain.Tokens{AccessToken: "access", ExpiresAt: now, RefreshToken: "refresh", RefreshExpiresAt: now}
+
+	return []interface{}{
+		auditConverter.ToEntryResponseFromDomain(entry),
+		auditConverter.ToEntryListFromDomain(&domain.AuditPage{Entries: []*domain.AuditEntry{entry}, NextCursor: "next"}),
+		auditModel.Change{Before: "bob", After: "alice"},
+
+		authConverter.ToTokenResponseFromDomain(tokens),
+		authModel.LoginRequest{},
+		authModel.RefreshRequest{},
+		authModel.LogoutRequest{},
+		authModel.ForgotPasswordRequest{},
+		authModel.ResetPasswordRequest{},
+
+		roleConverter.ToRoleListFromDomain([]*domain.Role{role}),
+		roleModel.RoleResponse{},
+		roleModel.AssignRequest{},
+
+		userConverter.ToUserResponseFromDomain(user),
+		userConverter.ToUserListFromDomain(&domain.UserPage{Users: []*domain.User{user}, NextCursor: "next"}),
+		userModel.User{},
+		userModel.UserPatch{},
+		userModel.VerifyEmailRequest{},
+		userModel.ResendVerificationRequest{},
+	}
+}
+
+func TestModelsHoldNoDomainTypes(t *testing.T) {
+	for _, model := range models() {
+		if found := findDomainType(reflect.ValueOf(model)); found != nil {
+			t.Errorf("%T holds %s, convert it to a REST model", model, found)
+		}
+	}
+}
+
+func TestEveryModelIsChecked(t *testing.T) {
+	checked := make(map[string]bool)
+	for _, model := range models() {
+		typ := reflect.TypeOf(model)
+		checked[typ.PkgPath()+"."+typ.Name()] = true
+	}
+
+	dirs, err := filepath.Glob("../*/model")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range dirs {
+		pkgPath := "app/internal/app/controller/rest/" + filepath.Base(filepath.Dir(dir)) + "/model"
+		for _, name := range exportedTypes(t, dir) {
+			if !checked[pkgPath+"."+name] {
+				t.Errorf("%s.%s is not listed in models()", pkgPath, name)
+			}
+		}
+	}
+}
+
+func TestHandlersWriteConvertedValues(t *testing.T) {
+	files, err := filepath.Glob("../*/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") || filepath.Base(filepath.Dir(path)) == "response" {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || !isSelector(call.Fun, "response", "JSON") || len(call.Args) == 0 {
+				return true
+			}
+
+			if body := call.Args[len(call.Args)-1]; !isConverted(body) {
+				t.Errorf("%s: response.JSON writes a value that did not go through a converter", fset.Position(body.Pos()))
+			}
+			return true
+		})
+	}
+}
+
+// isConverted accepts a call of a converter function and a map literal of
+// basic values, such as map[string]string{"user_id": id}.
+func isConverted(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		sel, ok := e.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		return ok && pkg.Name == "converter"
+	case *ast.CompositeLit:
+		m, ok := e.Type.(*ast.MapType)
+		if !ok {
+			return false
+		}
+		value, ok := m.Value.(*ast.Ident)
+		return ok && isBasic(value.Name)
+	default:
+		return false
+	}
+}
+
+func isBasic(name string) bool {
+	switch name {
+	case "string", "bool", "int", "int32", "int64", "float64":
+		return true
+	default:
+		return false
+	}
+}
+
+func isSelector(expr ast.Expr, pkg string, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	return ok && ident.Name == pkg
+}
+
+func exportedTypes(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	fset := token.NewFileSet()
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				if name := spec.(*ast.TypeSpec).Name; name.IsExported() {
+					names = append(names, name.Name)
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+// findDomainType returns the first domain type reachable from v through
+// exported fields, elements and the dynamic values of interfaces.
+func findDomainType(v reflect.Value) reflect.Type {
+	if !v.IsValid() {
+		return nil
+	}
+
+	// Nil pointers and empty containers still declare what they may hold.
+	t := v.Type()
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.PkgPath() == domainPkgPath {
+		return t
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if !v.IsNil() {
+			return findDomainType(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if t := findDomainType(v.Index(i)); t != nil {
+				return t
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if t := findDomainType(iter.Key()); t != nil {
+				return t
+			}
+			if t := findDomainType(iter.Value()); t != nil {
+				return t
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				if t := findDomainType(v.Field(i)); t != nil {
+					return t
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package response
+
+import (
+	"app/internal/domain"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/problem"
+	"app/internal/pkg/reqctx"
+	"app/internal/pkg/validation"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+var errorInvalidUUID = domain.NewError(domain.KindInvalid, "uuid must be a UUID")
+
+var statusByKind = map[domain.Kind]int{
+	domain.KindNotFound:           http.StatusNotFound,
+	domain.KindConflict:           http.StatusConflict,
+	domain.KindValidation:         http.StatusUnprocessableEntity,
+	domain.KindInvalid:            http.StatusBadRequest,
+	domain.KindUnauthorized:       http.StatusUnauthorized,
+	domain.KindForbidden:          http.StatusForbidden,
+	domain.KindUnavailable:        http.StatusServiceUnavailable,
+	domain.KindPreconditionFailed: http.StatusPreconditionFailed,
+	domain.KindTooManyRequests:    http.StatusTooManyRequests,
+}
+
+// Error is the single place where errors leave the REST layer. Domain errors
+// are mapped by kind, validation errors become 422 with the list of
+// violations, and anything else is logged and hidden behind a 500.
+func Error(w http.ResponseWriter, r *http.Request, log logger.Interface, err error) {
+	txID := reqctx.RequestID(r.Context())
+	log = logger.WithContext(r.Context(), log)
+
+	var violations validation.Errors
+	if errors.As(err, &violations) {
+		problem.New(r, http.StatusUnprocessableEntity, "request is not valid").
+			With("errors", violations).
+			Write(w)
+		return
+	}
+
+	var retryErr *domain.RetryError
+	if errors.As(err, &retryErr) && retryErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryErr.RetryAfter.Seconds()))))
+	}
+
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		status, ok := statusByKind[domainErr.Kind]
+		if ok {
+			if status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+			log.Debug(fmt.Sprintf("txID: %s response.Error, %s %s: %v", txID, r.Method, r.URL.Path, err))
+			problem.Write(w, r, status, domainErr.Message)
+			return
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.Warn(fmt.Sprintf("txID: %s response.Error, %s %s timed out: %v", txID, r.Method, r.URL.Path, err))
+		problem.Write(w, r, http.StatusServiceUnavailable, "the request timed out, try again later")
+		return
+	}
+
+	log.Error(fmt.Sprintf("txID: %s response.Error, %s %s: %v", txID, r.Method, r.URL.Path, err))
+	problem.Write(w, r, http.StatusInternalServerError, "")
+}
+
+// Problem writes an HTTP level failure that has no domain counterpart, such
+// as a missing header or an unsupported media type.
+func Problem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	problem.Write(w, r, status, detail)
+}
+
+// Validate runs v.Validate and, if it fails, writes the error. It reports
+// whether the caller may go on handling the request.
+func Validate(w http.ResponseWriter, r *http.Request, log logger.Interface, v validation.Validatable) bool {
+	if err := v.Validate(); err != nil {
+		Error(w, r, log, err)
+		return false
+	}
+
+	return true
+}
+
+// UUID checks that an identifier taken from the path or the query is a UUID
+// in its canonical 36 character form, writing a 400 if it is not, so that a
+// malformed one never reaches the database. It reports whether the caller
+// may go on handling the request.
+func UUID(w http.ResponseWriter, r *http.Request, log logger.Interface, value string) bool {
+	if _, err := uuid.Parse(value); err != nil || len(value) != 36 {
+		Error(w, r, log, errorInvalidUUID)
+		return false
+	}
+
+	return true
+}
+
+// JSON writes v with the given status. v must be a REST model or built of
+// basic types, never a domain struct; guard_test.go enforces this.
+func JSON(w http.ResponseWriter, r *http.Request, log logger.Interface, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		txID := reqctx.RequestID(r.Context())
+		log = logger.WithContext(r.Context(), log)
+		log.Error(fmt.Sprintf("txID: %s response.JSON, error encoding response: %v", txID, err))
+	}
+}
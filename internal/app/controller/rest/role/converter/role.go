@@ -0,0 +1,19 @@
+package converter
+
+import (
+	"app/internal/app/controller/rest/role/model"
+	"app/internal/domain"
+)
+
+func ToRoleListFromDomain(roles []*domain.Role) rest.RoleList {
+	list := rest.RoleList{Roles: make([]rest.RoleResponse, 0, len(roles))}
+	for _, r := range roles {
+		list.Roles = append(list.Roles, rest.RoleResponse{
+			Name:        r.Name,
+			Description: r.Description,
+			Permissions: append([]string{}, r.Permissions...),
+		})
+	}
+
+	return list
+}
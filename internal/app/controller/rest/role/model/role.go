@@ -0,0 +1,27 @@
+package rest
+
+import (
+	"app/internal/pkg/validation"
+)
+
+type RoleResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleList struct {
+	Roles []RoleResponse `json:"roles"`
+}
+
+type AssignRequest struct {
+	Role string `json:"role"`
+}
+
+var _ validation.Validatable = (*AssignRequest)(nil)
+
+func (a AssignRequest) Validate() error {
+	return validation.New().
+		Field("role", a.Role, validation.Required(), validation.Length(1, 64)).
+		Err()
+}
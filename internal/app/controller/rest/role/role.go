@@ -0,0 +1,109 @@
+package role
+
+import (
+	"app/internal/app/controller/rest/response"
+	"app/internal/app/controller/rest/role/converter"
+	"app/internal/app/controller/rest/role/model"
+	"app/internal/app/usecase/role"
+	"app/internal/domain"
+	"app/internal/pkg/httpserver"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/middleware"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+type Handler struct {
+	Service role.Service
+	logger  logger.Interface
+}
+
+func NewRoleHandler(
+	service role.Service,
+	logger logger.Interface,
+	router *httpserver.Router,
+) (*Handler, error) {
+	if service == nil {
+		return nil, errors.New("Handler.NewRoleHandler: service is null")
+	}
+
+	if logger == nil {
+		return nil, errors.New("Handler.NewRoleHandler: logger is null")
+	}
+
+	if router == nil {
+		return nil, errors.New("Handler.NewRoleHandler: router is null")
+	}
+
+	handler := &Handler{Service: service, logger: logger}
+	manage := middleware.Require(domain.PermissionRolesManage)
+	router.HandleFunc("GET /roles", manage, handler.ListRoles)
+	router.HandleFunc("GET /users/{uuid}/roles", middleware.OwnerOr("uuid", domain.PermissionRolesManage), handler.ListUserRoles)
+	router.HandleFunc("POST /users/{uuid}/roles", manage.WithIdempotency(), handler.AssignRole)
+	router.HandleFunc("DELETE /users/{uuid}/roles/{role}", manage, handler.RevokeRole)
+	return handler, nil
+}
+
+func (h *Handler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.Service.List(r.Context())
+	if err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	response.JSON(w, r, h.logger, http.StatusOK, converter.ToRoleListFromDomain(roles))
+}
+
+func (h *Handler) ListUserRoles(w http.ResponseWriter, r *http.Request) {
+	uuid := r.PathValue("uuid")
+	if !response.UUID(w, r, h.logger, uuid) {
+		return
+	}
+
+	roles, err := h.Service.ListByUser(r.Context(), uuid)
+	if err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	response.JSON(w, r, h.logger, http.StatusOK, converter.ToRoleListFromDomain(roles))
+}
+
+func (h *Handler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	uuid := r.PathValue("uuid")
+	if !response.UUID(w, r, h.logger, uuid) {
+		return
+	}
+
+	var req rest.AssignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, r, h.logger, domain.ErrorInvalidPayload)
+		return
+	}
+
+	if !response.Validate(w, r, h.logger, req) {
+		return
+	}
+
+	if err := h.Service.Assign(r.Context(), uuid, req.Role); err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	uuid := r.PathValue("uuid")
+	if !response.UUID(w, r, h.logger, uuid) {
+		return
+	}
+
+	if err := h.Service.Revoke(r.Context(), uuid, r.PathValue("role")); err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -3,6 +3,10 @@ package converter
 import (
 	"app/internal/app/controller/rest/user/model"
 	"app/internal/domain"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,9 +16,115 @@ func ToUserFromRest(user rest.User) *domain.User {
 	return &domain.User{
 		Uuid:      uuid.NewString(),
 		Login:     user.Login,
+		Email:     user.Email,
 		Password:  user.Password,
 		CreatedAt: time.Now(),
 		UpdatedAt: nil,
 		DeletedAt: nil,
 	}
 }
+
+func ToUserFilterFromQuery(query url.Values) (domain.UserFilter, error) {
+	filter := domain.UserFilter{
+		LoginPrefix: query.Get("login_prefix"),
+		Sort:        domain.UserSort(query.Get("sort")),
+		Cursor:      query.Get("cursor"),
+	}
+
+	switch filter.Sort {
+	case "", domain.UserSortCreatedAtAsc, domain.UserSortCreatedAtDesc:
+	default:
+		return filter, domain.NewError(domain.KindInvalid, fmt.Sprintf("sort must be %q or %q", domain.UserSortCreatedAtAsc, domain.UserSortCreatedAtDesc))
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, domain.NewError(domain.KindInvalid, "limit must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+
+	for key, target := range map[string]**time.Time{
+		"created_from": &filter.CreatedFrom,
+		"created_to":   &filter.CreatedTo,
+	} {
+		if v := query.Get(key); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, domain.NewError(domain.KindInvalid, key+" must be an RFC 3339 timestamp")
+			}
+			*target = &t
+		}
+	}
+
+	return filter, nil
+}
+
+func ToUserResponseFromDomain(user *domain.User) rest.UserResponse {
+	var updatedAt *string
+	if user.UpdatedAt != nil {
+		formatted := formatTime(*user.UpdatedAt)
+		updatedAt = &formatted
+	}
+
+	return rest.UserResponse{
+		UUID:          user.Uuid,
+		Login:         user.Login,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		CreatedAt:     formatTime(user.CreatedAt),
+		UpdatedAt:     updatedAt,
+	}
+}
+
+func ToUserListFromDomain(page *domain.UserPage) rest.UserList {
+	users := make([]rest.UserResponse, 0, len(page.Users))
+	for _, u := range page.Users {
+		users = append(users, ToUserResponseFromDomain(u))
+	}
+
+	return rest.UserList{
+		Users:      users,
+		NextCursor: page.NextCursor,
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func ToUserPatchFromRest(patch rest.UserPatch) domain.UserPatch {
+	var result domain.UserPatch
+
+	if login, ok := rest.StringMember(patch.Login); ok {
+		result.Login = &login
+	}
+
+	if password, ok := rest.StringMember(patch.Password); ok {
+		result.Password = &password
+	}
+
+	return result
+}
+
+func ToETagFromDomain(user *domain.User) string {
+	return fmt.Sprintf(`"%d"`, user.Version)
+}
+
+// ToVersionFromIfMatch extracts the version from a single strong entity tag.
+// Weak tags and "*" are rejected because they cannot guard against lost
+// updates.
+func ToVersionFromIfMatch(header string) (int64, bool) {
+	header = strings.TrimSpace(header)
+	if len(header) < 3 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+
+	return version, true
+}
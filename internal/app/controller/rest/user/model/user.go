@@ -1,6 +1,137 @@
 package rest
 
+import (
+	"app/internal/pkg/validation"
+	"bytes"
+	"encoding/json"
+)
+
+const (
+	codeNotNullable = "not_nullable"
+	codeInvalidType = "invalid_type"
+	codeEmptyPatch  = "empty_patch"
+)
+
+var (
+	loginRules = []validation.Rule{
+		validation.Required(),
+		validation.Length(3, 64),
+		validation.Login("._-@+"),
+	}
+
+	// The email address is optional: users without one predate verification
+	// and are not limited by it.
+	emailRules = []validation.Rule{
+		validation.Length(3, 254),
+		validation.Email(),
+	}
+
+	passwordRules = []validation.Rule{
+		validation.Required(),
+		validation.Password(validation.DefaultPasswordPolicy),
+	}
+)
+
 type User struct {
 	Login    string `json:"login"`
+	Email    string `json:"email"`
 	Password string `json:"password"`
 }
+
+var _ validation.Validatable = (*User)(nil)
+
+func (u User) Validate() error {
+	return validation.New().
+		Field("login", u.Login, loginRules...).
+		Field("email", u.Email, emailRules...).
+		Field("password", u.Password, passwordRules...).
+		Err()
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+func (r VerifyEmailRequest) Validate() error {
+	return validation.New().
+		Field("token", r.Token, validation.Required()).
+		Err()
+}
+
+type ResendVerificationRequest struct {
+	Login string `json:"login"`
+}
+
+func (r ResendVerificationRequest) Validate() error {
+	return validation.New().
+		Field("login", r.Login, validation.Required()).
+		Err()
+}
+
+// UserResponse is the public representation of a user. It deliberately has
+// no password field.
+type UserResponse struct {
+	UUID          string  `json:"uuid"`
+	Login         string  `json:"login"`
+	Email         string  `json:"email,omitempty"`
+	EmailVerified bool    `json:"email_verified"`
+	CreatedAt     string  `json:"created_at"`
+	UpdatedAt     *string `json:"updated_at,omitempty"`
+}
+
+type UserList struct {
+	Users      []UserResponse `json:"users"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// UserPatch is a JSON Merge Patch (RFC 7396) document. Members are kept raw
+// so that an absent member can be told apart from an explicit null.
+type UserPatch struct {
+	Login    json.RawMessage `json:"login"`
+	Password json.RawMessage `json:"password"`
+}
+
+var _ validation.Validatable = (*UserPatch)(nil)
+
+func (p UserPatch) Validate() error {
+	v := validation.New()
+
+	if p.Login == nil && p.Password == nil {
+		v.Add("", codeEmptyPatch, "patch must change login or password")
+	}
+
+	validateMember(v, "login", p.Login, loginRules)
+	validateMember(v, "password", p.Password, passwordRules)
+
+	return v.Err()
+}
+
+// StringMember decodes a present, non-null string member of a merge patch.
+func StringMember(raw json.RawMessage) (string, bool) {
+	if raw == nil || bytes.Equal(raw, []byte("null")) {
+		return "", false
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", false
+	}
+
+	return value, true
+}
+
+func validateMember(v *validation.Validator, name string, raw json.RawMessage, rules []validation.Rule) {
+	switch {
+	case raw == nil:
+		return
+	case bytes.Equal(raw, []byte("null")):
+		v.Add(name, codeNotNullable, "cannot be removed")
+	default:
+		value, ok := StringMember(raw)
+		if !ok {
+			v.Add(name, codeInvalidType, "must be a string")
+			return
+		}
+		v.Field(name, value, rules...)
+	}
+}
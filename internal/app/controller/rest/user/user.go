@@ -1,16 +1,39 @@
 package user
 
 import (
+	"app/internal/app/controller/rest/response"
 	"app/internal/app/controller/rest/user/converter"
 	"app/internal/app/controller/rest/user/model"
 	"app/internal/app/usecase/user"
 	"app/internal/domain"
+	"app/internal/pkg/httpserver"
 	"app/internal/pkg/logger"
+	"app/internal/pkg/middleware"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"html/template"
 	"net/http"
+	"strings"
 )
 
+var errorUUIDRequired = domain.NewError(domain.KindInvalid, "uuid query parameter is required")
+
+// verifyPage is what a verification link opens. It only offers to post the
+// token, so that mail scanners and link prefetchers following the link do
+// not verify the address on the user's behalf.
+var verifyPage = template.Must(template.New("verify").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Verify your email address</title></head>
+<body>
+<form method="post">
+<input type="hidden" name="token" value="{{.}}">
+<button type="submit">Verify my email address</button>
+</form>
+</body>
+</html>
+`))
+
 type Handler struct {
 	Service user.Service
 	logger  logger.Interface
@@ -19,7 +42,7 @@ type Handler struct {
 func NewUserHandler(
 	service user.Service,
 	logger logger.Interface,
-	mux *http.ServeMux,
+	router *httpserver.Router,
 ) (*Handler, error) {
 	if service == nil {
 		return nil, errors.New("Handler.NewUserHandler: service is null")
@@ -29,78 +52,242 @@ func NewUserHandler(
 		return nil, errors.New("Handler.NewUserHandler: logger is null")
 	}
 
-	if mux == nil {
-		return nil, errors.New("Handler.NewUserHandler: mux is null")
+	if router == nil {
+		return nil, errors.New("Handler.NewUserHandler: router is null")
 	}
 
 	handler := &Handler{Service: service, logger: logger}
-	mux.HandleFunc("POST /users", handler.CreateUser)
-	mux.HandleFunc("GET /users", handler.GetUser)
-	mux.HandleFunc("DELETE /users", handler.DeleteUser)
+	admin := middleware.Require(domain.PermissionUsersAdmin)
+	router.HandleFunc("POST /users", middleware.Public().WithIdempotency(), handler.CreateUser)
+	router.HandleFunc("GET /users/verify", middleware.Public(), handler.VerifyEmailPage)
+	router.HandleFunc("POST /users/verify", middleware.Public(), handler.VerifyEmail)
+	router.HandleFunc("POST /users/verify/resend", middleware.Public(), handler.ResendVerification)
+	router.HandleFunc("GET /users", middleware.OwnerOr("uuid", domain.PermissionUsersRead), handler.GetUser)
+	router.HandleFunc("PATCH /users/{uuid}", middleware.OwnerOr("uuid", domain.PermissionUsersWrite), handler.UpdateUser)
+	router.HandleFunc("DELETE /users", middleware.OwnerOr("uuid", domain.PermissionUsersDelete), handler.DeleteUser)
+	router.HandleFunc("POST /users/{uuid}/restore", admin.WithIdempotency(), handler.RestoreUser)
+	router.HandleFunc("POST /users/purge", admin, handler.PurgeUsers)
+	router.HandleFunc("POST /users/{uuid}/unlock", admin, handler.UnlockUser)
 	return handler, nil
 }
 
 func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var u rest.User
 	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
-		h.logger.Error("Handler.CreateUser: error decoding user: " + err.Error())
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		response.Error(w, r, h.logger, domain.ErrorInvalidPayload)
 		return
 	}
 
-	userID, err := h.Service.Create(r.Context(), converter.ToUserFromRest(u))
-	if err != nil {
-		h.logger.Error("Handler.CreateUser: error creating user: " + err.Error())
-		http.Error(w, "Could not create user", http.StatusInternalServerError)
+	if !response.Validate(w, r, h.logger, u) {
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	err = json.NewEncoder(w).Encode(map[string]string{"user_id": userID})
+	userID, err := h.Service.Create(r.Context(), converter.ToUserFromRest(u))
 	if err != nil {
-		h.logger.Error("Handler.CreateUser: error encoding user: " + err.Error())
+		response.Error(w, r, h.logger, err)
 		return
 	}
+
+	response.JSON(w, r, h.logger, http.StatusCreated, map[string]string{"user_id": userID})
 }
 
 func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 	uuid := r.URL.Query().Get("uuid")
 	if uuid == "" {
-		http.Error(w, "UUID is required", http.StatusBadRequest)
+		h.ListUsers(w, r)
+		return
+	}
+
+	if !response.UUID(w, r, h.logger, uuid) {
 		return
 	}
 
 	u, err := h.Service.Get(r.Context(), uuid)
 	if err != nil {
-		if errors.Is(err, domain.ErrorUserNotFound) {
-			w.WriteHeader(http.StatusNotFound)
-		} else {
-			h.logger.Error("Handler.GetUser: error fetching user: " + err.Error())
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(w).Encode(u)
+	w.Header().Set("ETag", converter.ToETagFromDomain(u))
+	response.JSON(w, r, h.logger, http.StatusOK, converter.ToUserResponseFromDomain(u))
+}
+
+func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	uuid := r.PathValue("uuid")
+	if !response.UUID(w, r, h.logger, uuid) {
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "application/merge-patch+json") && !strings.HasPrefix(contentType, "application/json") {
+		response.Problem(w, r, http.StatusUnsupportedMediaType, "Content-Type must be application/merge-patch+json")
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		response.Problem(w, r, http.StatusPreconditionRequired, "If-Match header is required")
+		return
+	}
+
+	version, ok := converter.ToVersionFromIfMatch(ifMatch)
+	if !ok {
+		response.Problem(w, r, http.StatusPreconditionFailed, "If-Match must be a single strong ETag")
+		return
+	}
+
+	var p rest.UserPatch
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&p); err != nil {
+		response.Error(w, r, h.logger, domain.ErrorInvalidPayload)
+		return
+	}
+
+	if !response.Validate(w, r, h.logger, p) {
+		return
+	}
+
+	u, err := h.Service.Update(r.Context(), uuid, converter.ToUserPatchFromRest(p), version)
 	if err != nil {
-		h.logger.Error("Handler.CreateUser: error encoding user: " + err.Error())
+		response.Error(w, r, h.logger, err)
 		return
 	}
+
+	w.Header().Set("ETag", converter.ToETagFromDomain(u))
+	response.JSON(w, r, h.logger, http.StatusOK, converter.ToUserResponseFromDomain(u))
+}
+
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	filter, err := converter.ToUserFilterFromQuery(r.URL.Query())
+	if err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	page, err := h.Service.List(r.Context(), filter)
+	if err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	response.JSON(w, r, h.logger, http.StatusOK, converter.ToUserListFromDomain(page))
 }
 
 func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	uuid := r.URL.Query().Get("uuid")
 	if uuid == "" {
-		http.Error(w, "UUID is required", http.StatusBadRequest)
+		response.Error(w, r, h.logger, errorUUIDRequired)
+		return
+	}
+
+	if !response.UUID(w, r, h.logger, uuid) {
 		return
 	}
 
 	if err := h.Service.Delete(r.Context(), uuid); err != nil {
-		h.logger.Error("Handler.DeleteUser: error deleting user: " + err.Error())
-		http.Error(w, "Could not delete user", http.StatusInternalServerError)
+		response.Error(w, r, h.logger, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
+
+func (h *Handler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	uuid := r.PathValue("uuid")
+	if !response.UUID(w, r, h.logger, uuid) {
+		return
+	}
+
+	u, err := h.Service.Restore(r.Context(), uuid)
+	if err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	w.Header().Set("ETag", converter.ToETagFromDomain(u))
+	response.JSON(w, r, h.logger, http.StatusOK, converter.ToUserResponseFromDomain(u))
+}
+
+func (h *Handler) PurgeUsers(w http.ResponseWriter, r *http.Request) {
+	purged, err := h.Service.Purge(r.Context())
+	if err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	response.JSON(w, r, h.logger, http.StatusOK, map[string]int64{"purged": purged})
+}
+
+// VerifyEmailPage serves the page a verification link opens; it changes
+// nothing itself.
+func (h *Handler) VerifyEmailPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; form-action 'self'")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := verifyPage.Execute(w, r.URL.Query().Get("token")); err != nil {
+		h.logger.Error(fmt.Sprintf("Handler.VerifyEmailPage, error rendering page: %v", err))
+	}
+}
+
+// VerifyEmail takes the token as JSON, or as the form posted by the page of
+// VerifyEmailPage.
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req rest.VerifyEmailRequest
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		req.Token = r.PostFormValue("token")
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, r, h.logger, domain.ErrorInvalidPayload)
+		return
+	}
+
+	if !response.Validate(w, r, h.logger, req) {
+		return
+	}
+
+	u, err := h.Service.VerifyEmail(r.Context(), req.Token)
+	if err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	w.Header().Set("ETag", converter.ToETagFromDomain(u))
+	response.JSON(w, r, h.logger, http.StatusOK, converter.ToUserResponseFromDomain(u))
+}
+
+// ResendVerification always answers 202, whether or not the login exists
+// or is already verified.
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req rest.ResendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, r, h.logger, domain.ErrorInvalidPayload)
+		return
+	}
+
+	if !response.Validate(w, r, h.logger, req) {
+		return
+	}
+
+	if err := h.Service.ResendVerification(r.Context(), req.Login); err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	uuid := r.PathValue("uuid")
+	if !response.UUID(w, r, h.logger, uuid) {
+		return
+	}
+
+	if err := h.Service.Unlock(r.Context(), uuid); err != nil {
+		response.Error(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,222 @@
+package user_test
+
+import (
+	restUser "app/internal/app/controller/rest/user"
+	"app/internal/app/usecase/user"
+	"app/internal/domain"
+	"app/internal/pkg/cache"
+	"app/internal/pkg/config"
+	"app/internal/pkg/httpserver"
+	"app/internal/pkg/idempotency"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/middleware"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...logger.Field) {}
+func (nopLogger) Info(string, ...logger.Field)  {}
+func (nopLogger) Warn(string, ...logger.Field)  {}
+func (nopLogger) Error(string, ...logger.Field) {}
+func (nopLogger) Fatal(string, ...logger.Field) {}
+
+// users records the calls made by the public routes; other methods are not
+// implemented.
+type users struct {
+	user.Service
+	mu       sync.Mutex
+	created  []*domain.User
+	verified []string
+}
+
+func (u *users) Create(ctx context.Context, created *domain.User) (string, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.created = append(u.created, created)
+	return created.Uuid, nil
+}
+
+func (u *users) VerifyEmail(ctx context.Context, verifyToken string) (*domain.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if verifyToken != "good" {
+		return nil, domain.ErrorInvalidVerifyToken
+	}
+	u.verified = append(u.verified, verifyToken)
+	return &domain.User{Uuid: "6f1c2d1e-7a4b-4c55-9a0e-2f1f0c6d9b11", Login: "alice", Email: "alice@example.com", EmailVerified: true, CreatedAt: time.Now(), Version: 2}, nil
+}
+
+func newServer(t *testing.T) (*httptest.Server, *users) {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store, err := idempotency.NewRedis(&cache.Redis{Client: client}, &config.Idempotency{Retention: time.Hour, LockTimeout: time.Minute}, nopLogger{})
+	if err != nil {
+		t.Fatalf("idempotency.NewRedis: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	router := httpserver.NewRouter(mux, nopLogger{})
+	router.SetIdempotencyStore(store)
+
+	service := &users{}
+	if _, err = restUser.NewUserHandler(service, nopLogger{}, router); err != nil {
+		t.Fatalf("NewUserHandler: %v", err)
+	}
+
+	server := httptest.NewServer(middleware.ClientIP(mux, nil))
+	t.Cleanup(server.Close)
+	return server, service
+}
+
+func TestCreateUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		email  string
+	}{
+		{
+			name:   "with email",
+			body:   `{"login":"alice","email":"alice@example.com","password":"Secret123!"}`,
+			status: http.StatusCreated,
+			email:  "alice@example.com",
+		},
+		{
+			name:   "without email",
+			body:   `{"login":"alice","password":"Secret123!"}`,
+			status: http.StatusCreated,
+		},
+		{
+			name:   "invalid email",
+			body:   `{"login":"alice","email":"alice","password":"Secret123!"}`,
+			status: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, service := newServer(t)
+
+			resp, err := http.Post(server.URL+"/users", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("POST /users: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("POST /users status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status != http.StatusCreated {
+				if len(service.created) != 0 {
+					t.Fatalf("created %d users, want none", len(service.created))
+				}
+				return
+			}
+			if len(service.created) != 1 || service.created[0].Email != tt.email {
+				t.Fatalf("created %v, want one user with email %q", service.created, tt.email)
+			}
+		})
+	}
+}
+
+func TestCreateUserReplayed(t *testing.T) {
+	server, service := newServer(t)
+
+	post := func(key string) (*http.Response, string) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/users", strings.NewReader(`{"login":"alice","password":"Secret123!"}`))
+		if err != nil {
+			t.Fatalf("NewRequest: %v", err)
+		}
+		req.Header.Set(middleware.HeaderIdempotencyKey, key)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST /users: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("POST /users status = %d, want %d", resp.StatusCode, http.StatusCreated)
+		}
+		return resp, string(body)
+	}
+
+	_, first := post("k1")
+	replayed, body := post("k1")
+
+	if replayed.Header.Get(middleware.HeaderIdempotentReplayed) != "true" || body != first {
+		t.Fatalf("retried POST /users = %q, want the stored %q replayed", body, first)
+	}
+	if len(service.created) != 1 {
+		t.Fatalf("created %d users, want 1", len(service.created))
+	}
+
+	if _, body = post("k2"); body == first {
+		t.Fatalf("POST /users with another key = %q, want a new user", body)
+	}
+	if len(service.created) != 2 {
+		t.Fatalf("created %d users, want 2", len(service.created))
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	server, service := newServer(t)
+
+	// Following the link only shows the page: prefetchers must not verify.
+	resp, err := http.Get(server.URL + "/users/verify?token=good")
+	if err != nil {
+		t.Fatalf("GET /users/verify: %v", err)
+	}
+	page := new(strings.Builder)
+	_, _ = io.Copy(page, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || !strings.Contains(page.String(), `value="good"`) {
+		t.Fatalf("GET /users/verify = %d %q, want the page posting the token", resp.StatusCode, page)
+	}
+	if len(service.verified) != 0 {
+		t.Fatalf("GET /users/verify verified %v, want nothing", service.verified)
+	}
+
+	for _, tt := range []struct {
+		token  string
+		status int
+	}{
+		{"good", http.StatusOK},
+		{"bad", http.StatusBadRequest},
+		{"", http.StatusUnprocessableEntity},
+	} {
+		resp, err := http.PostForm(server.URL+"/users/verify", url.Values{"token": {tt.token}})
+		if err != nil {
+			t.Fatalf("POST /users/verify: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.status {
+			t.Fatalf("POST /users/verify with token %q status = %d, want %d", tt.token, resp.StatusCode, tt.status)
+		}
+	}
+
+	if len(service.verified) != 1 {
+		t.Fatalf("verified %v, want the good token once", service.verified)
+	}
+}
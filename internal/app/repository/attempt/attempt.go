@@ -0,0 +1,68 @@
+package attempt
+
+import (
+	"context"
+	"time"
+)
+
+// Policy is how many failures one key may collect and how long it then has
+// to wait.
+type Policy struct {
+	// Window is how long a counter lives without further failures.
+	Window time.Duration
+	// Threshold locks the key for Duration once it has collected that many
+	// failures; 0 never locks.
+	Threshold int64
+	Duration  time.Duration
+	// From BackoffAfter failures on, the next attempt has to wait
+	// BackoffBase doubled per failure, at most BackoffMax.
+	BackoffAfter int64
+	BackoffBase  time.Duration
+	BackoffMax   time.Duration
+}
+
+// Backoff is how long to wait after the last failure before the next
+// attempt: nothing below BackoffAfter failures, then BackoffBase doubling
+// with every failure up to BackoffMax.
+func (p Policy) Backoff(failures int64) time.Duration {
+	if p.BackoffAfter <= 0 || p.BackoffBase <= 0 || failures < p.BackoffAfter {
+		return 0
+	}
+
+	wait := p.BackoffBase
+	for i := p.BackoffAfter; i < failures; i++ {
+		wait *= 2
+		if p.BackoffMax > 0 && wait >= p.BackoffMax {
+			return p.BackoffMax
+		}
+	}
+
+	if p.BackoffMax > 0 && wait > p.BackoffMax {
+		return p.BackoffMax
+	}
+
+	return wait
+}
+
+// Outcome is what Take decided about one attempt.
+type Outcome struct {
+	// Failures counted on the key, including this attempt if it was taken.
+	Failures int64
+	// Wait is how long the caller has to wait before trying again; zero if
+	// the attempt was taken.
+	Wait time.Duration
+	// Locked is set when this attempt found the threshold reached and
+	// locked the key.
+	Locked bool
+}
+
+// Store counts authentication attempts per key. An attempt is counted as a
+// failure when it is taken, in the same step that checks the key is not
+// locked or backing off, so that concurrent attempts cannot all pass the
+// check before any of them is counted. A successful attempt is given back
+// with Release or Reset.
+type Store interface {
+	Take(ctx context.Context, key string, at time.Time, policy Policy) (*Outcome, error)
+	Release(ctx context.Context, key string) error
+	Reset(ctx context.Context, key string) error
+}
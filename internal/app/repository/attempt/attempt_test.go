@@ -0,0 +1,37 @@
+package attempt_test
+
+import (
+	"app/internal/app/repository/attempt"
+	"testing"
+	"time"
+)
+
+func TestPolicyBackoff(t *testing.T) {
+	policy := attempt.Policy{BackoffAfter: 3, BackoffBase: time.Second, BackoffMax: 10 * time.Second}
+
+	tests := []struct {
+		name     string
+		policy   attempt.Policy
+		failures int64
+		want     time.Duration
+	}{
+		{"below threshold", policy, 2, 0},
+		{"at threshold", policy, 3, time.Second},
+		{"doubles", policy, 4, 2 * time.Second},
+		{"doubles again", policy, 6, 8 * time.Second},
+		{"capped", policy, 7, 10 * time.Second},
+		{"stays capped", policy, 100, 10 * time.Second},
+		{"no max", attempt.Policy{BackoffAfter: 1, BackoffBase: time.Second}, 5, 16 * time.Second},
+		{"base above max", attempt.Policy{BackoffAfter: 1, BackoffBase: time.Minute, BackoffMax: time.Second}, 1, time.Second},
+		{"disabled", attempt.Policy{BackoffBase: time.Second}, 10, 0},
+		{"no base", attempt.Policy{BackoffAfter: 1}, 10, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Backoff(tt.failures); got != tt.want {
+				t.Fatalf("Backoff(%d) = %s, want %s", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
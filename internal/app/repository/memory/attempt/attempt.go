@@ -0,0 +1,129 @@
+package attempt
+
+import (
+	"app/internal/app/repository/attempt"
+	"app/internal/pkg/logger"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var _ attempt.Store = (*store)(nil)
+
+const sweepInterval = time.Minute
+
+type entry struct {
+	failures    int64
+	lastFailure time.Time
+	lockedUntil time.Time
+	expiresAt   time.Time
+}
+
+// store is an in-process attempt.Store for single instance runs without
+// Redis. Counters are per process and lost on restart.
+type store struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+	now       func() time.Time
+	logger    logger.Interface
+}
+
+func NewMemoryAttemptStore(logger logger.Interface) (attempt.Store, error) {
+	if logger == nil {
+		return nil, errors.New("store.NewMemoryAttemptStore: logger is null")
+	}
+
+	return &store{
+		entries: make(map[string]*entry),
+		now:     time.Now,
+		logger:  logger,
+	}, nil
+}
+
+// Take decides like the script of the Redis store, under s.mu.
+func (s *store) Take(ctx context.Context, key string, at time.Time, policy attempt.Policy) (*attempt.Outcome, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.lookup(key)
+	if e == nil {
+		e = &entry{}
+		s.entries[key] = e
+	}
+
+	if at.Before(e.lockedUntil) {
+		return &attempt.Outcome{Failures: e.failures, Wait: e.lockedUntil.Sub(at)}, nil
+	}
+
+	if policy.Threshold > 0 && e.failures >= policy.Threshold {
+		outcome := &attempt.Outcome{Failures: e.failures, Wait: policy.Duration, Locked: true}
+		e.failures = 0
+		e.lockedUntil = at.Add(policy.Duration)
+		s.extend(e, policy.Duration)
+		return outcome, nil
+	}
+
+	if wait := e.lastFailure.Add(policy.Backoff(e.failures)).Sub(at); wait > 0 {
+		return &attempt.Outcome{Failures: e.failures, Wait: wait}, nil
+	}
+
+	e.failures++
+	e.lastFailure = at
+	s.extend(e, policy.Window)
+	return &attempt.Outcome{Failures: e.failures}, nil
+}
+
+func (s *store) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e := s.lookup(key); e != nil && e.failures > 0 {
+		e.failures--
+	}
+
+	return nil
+}
+
+func (s *store) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// extend makes e live for at least ttl from now. Callers must hold s.mu.
+func (s *store) extend(e *entry, ttl time.Duration) {
+	if expiresAt := s.now().Add(ttl); expiresAt.After(e.expiresAt) {
+		e.expiresAt = expiresAt
+	}
+}
+
+// lookup returns a live entry, evicting it if it has expired. Expired
+// entries of other keys are swept now and then so that the map cannot grow
+// without bound. Callers must hold s.mu.
+func (s *store) lookup(key string) *entry {
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, e := range s.entries {
+			if !now.Before(e.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	e, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+
+	if !now.Before(e.expiresAt) {
+		delete(s.entries, key)
+		return nil
+	}
+
+	return e
+}
@@ -0,0 +1,131 @@
+package attempt
+
+import (
+	"app/internal/app/repository/attempt"
+	"app/internal/pkg/logger"
+	"context"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...logger.Field) {}
+func (nopLogger) Info(string, ...logger.Field)  {}
+func (nopLogger) Warn(string, ...logger.Field)  {}
+func (nopLogger) Error(string, ...logger.Field) {}
+func (nopLogger) Fatal(string, ...logger.Field) {}
+
+func TestTake(t *testing.T) {
+	policy := attempt.Policy{
+		Window:       time.Hour,
+		Threshold:    4,
+		Duration:     15 * time.Minute,
+		BackoffAfter: 2,
+		BackoffBase:  time.Second,
+		BackoffMax:   time.Minute,
+	}
+
+	// op is one call on the key at an offset from the start: take, release
+	// or reset.
+	type op struct {
+		call string
+		at   time.Duration
+		want attempt.Outcome
+	}
+
+	tests := []struct {
+		name string
+		ops  []op
+	}{
+		{
+			name: "failures are counted",
+			ops: []op{
+				{"take", 0, attempt.Outcome{Failures: 1}},
+				{"take", time.Second, attempt.Outcome{Failures: 2}},
+			},
+		},
+		{
+			name: "backoff refuses early attempts",
+			ops: []op{
+				{"take", 0, attempt.Outcome{Failures: 1}},
+				{"take", 0, attempt.Outcome{Failures: 2}},
+				{"take", 500 * time.Millisecond, attempt.Outcome{Failures: 2, Wait: 500 * time.Millisecond}},
+				{"take", time.Second, attempt.Outcome{Failures: 3}},
+				{"take", 2 * time.Second, attempt.Outcome{Failures: 3, Wait: time.Second}},
+			},
+		},
+		{
+			name: "threshold locks and restarts the counter",
+			ops: []op{
+				{"take", 0, attempt.Outcome{Failures: 1}},
+				{"take", 0, attempt.Outcome{Failures: 2}},
+				{"take", time.Second, attempt.Outcome{Failures: 3}},
+				{"take", 3 * time.Second, attempt.Outcome{Failures: 4}},
+				{"take", 10 * time.Second, attempt.Outcome{Failures: 4, Wait: 15 * time.Minute, Locked: true}},
+				{"take", 11 * time.Second, attempt.Outcome{Failures: 0, Wait: 15*time.Minute - time.Second}},
+				{"take", 10*time.Second + 15*time.Minute, attempt.Outcome{Failures: 1}},
+			},
+		},
+		{
+			name: "release gives an attempt back",
+			ops: []op{
+				{"take", 0, attempt.Outcome{Failures: 1}},
+				{"release", 0, attempt.Outcome{}},
+				{"release", 0, attempt.Outcome{}},
+				{"take", 0, attempt.Outcome{Failures: 1}},
+			},
+		},
+		{
+			name: "reset clears the key",
+			ops: []op{
+				{"take", 0, attempt.Outcome{Failures: 1}},
+				{"take", 0, attempt.Outcome{Failures: 2}},
+				{"reset", 0, attempt.Outcome{}},
+				{"take", 0, attempt.Outcome{Failures: 1}},
+			},
+		},
+		{
+			name: "counter lapses after the window",
+			ops: []op{
+				{"take", 0, attempt.Outcome{Failures: 1}},
+				{"take", time.Hour, attempt.Outcome{Failures: 1}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			now := start
+
+			s, err := NewMemoryAttemptStore(nopLogger{})
+			if err != nil {
+				t.Fatalf("NewMemoryAttemptStore: %v", err)
+			}
+			s.(*store).now = func() time.Time { return now }
+
+			for i, op := range tt.ops {
+				now = start.Add(op.at)
+				switch op.call {
+				case "take":
+					outcome, err := s.Take(ctx, "login:alice", now, policy)
+					if err != nil {
+						t.Fatalf("op %d: Take: %v", i, err)
+					}
+					if *outcome != op.want {
+						t.Fatalf("op %d: Take() = %+v, want %+v", i, *outcome, op.want)
+					}
+				case "release":
+					err = s.Release(ctx, "login:alice")
+				case "reset":
+					err = s.Reset(ctx, "login:alice")
+				}
+				if err != nil {
+					t.Fatalf("op %d: %s: %v", i, op.call, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package session
+
+import (
+	"app/internal/app/repository/session"
+	"app/internal/domain"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/reqctx"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var _ session.Store = (*store)(nil)
+
+type entry struct {
+	session   domain.Session
+	tokenHash string
+}
+
+// store is an in-process session.Store for tests and single instance
+// runs without Redis. Sessions are lost on restart.
+type store struct {
+	mu       sync.Mutex
+	sessions map[string]*entry
+	byUser   map[string]map[string]struct{}
+	now      func() time.Time
+	logger   logger.Interface
+}
+
+func NewMemorySessionStore(logger logger.Interface) (session.Store, error) {
+	if logger == nil {
+		return nil, errors.New("store.NewMemorySessionStore: logger is null")
+	}
+
+	return &store{
+		sessions: make(map[string]*entry),
+		byUser:   make(map[string]map[string]struct{}),
+		now:      time.Now,
+		logger:   logger,
+	}, nil
+}
+
+func (s *store) Create(ctx context.Context, session *domain.Session, tokenHash string) error {
+	if session == nil {
+		return errors.New("store.Create: session is null")
+	}
+
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s store.Create, creating session %s for user: %s", txID, session.ID, session.UserID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.ID] = &entry{session: *session, tokenHash: tokenHash}
+	if s.byUser[session.UserID] == nil {
+		s.byUser[session.UserID] = make(map[string]struct{})
+	}
+	s.byUser[session.UserID][session.ID] = struct{}{}
+
+	return nil
+}
+
+func (s *store) Get(ctx context.Context, id string) (*domain.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.lookup(id)
+	if e == nil {
+		return nil, domain.ErrorSessionNotFound
+	}
+
+	session := e.session
+	return &session, nil
+}
+
+func (s *store) Rotate(
+	ctx context.Context,
+	id string,
+	tokenHash string,
+	newTokenHash string,
+	expiresAt time.Time,
+) (*domain.Session, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s store.Rotate, rotating session: %s", txID, id))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.lookup(id)
+	if e == nil {
+		return nil, domain.ErrorSessionNotFound
+	}
+
+	if e.tokenHash != tokenHash {
+		s.remove(id)
+		log.Warn(fmt.Sprintf("txID: %s store.Rotate, refresh token reuse detected, session %s of user %s revoked", txID, id, e.session.UserID))
+		return nil, domain.ErrorRefreshTokenReused
+	}
+
+	e.tokenHash = newTokenHash
+	e.session.ExpiresAt = expiresAt
+
+	session := e.session
+	return &session, nil
+}
+
+func (s *store) Revoke(ctx context.Context, id string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s store.Revoke, revoking session: %s", txID, id))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lookup(id) == nil {
+		return domain.ErrorSessionNotFound
+	}
+
+	s.remove(id)
+	return nil
+}
+
+func (s *store) RevokeAll(ctx context.Context, userID string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s store.RevokeAll, revoking all sessions of user: %s", txID, userID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.byUser[userID] {
+		delete(s.sessions, id)
+	}
+	delete(s.byUser, userID)
+
+	return nil
+}
+
+// lookup returns a live session entry, evicting it if it has expired.
+// Callers must hold s.mu.
+func (s *store) lookup(id string) *entry {
+	e, ok := s.sessions[id]
+	if !ok {
+		return nil
+	}
+
+	if !s.now().Before(e.session.ExpiresAt) {
+		s.remove(id)
+		return nil
+	}
+
+	return e
+}
+
+// remove deletes a session and its user index entry. Callers must hold s.mu.
+func (s *store) remove(id string) {
+	e, ok := s.sessions[id]
+	if !ok {
+		return
+	}
+
+	delete(s.sessions, id)
+	if ids := s.byUser[e.session.UserID]; ids != nil {
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(s.byUser, e.session.UserID)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package session
+
+import (
+	"app/internal/domain"
+	"app/internal/pkg/logger"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...logger.Field) {}
+func (nopLogger) Info(string, ...logger.Field)  {}
+func (nopLogger) Warn(string, ...logger.Field)  {}
+func (nopLogger) Error(string, ...logger.Field) {}
+func (nopLogger) Fatal(string, ...logger.Field) {}
+
+func newStore(t *testing.T, now *time.Time) *store {
+	t.Helper()
+
+	s, err := NewMemorySessionStore(nopLogger{})
+	if err != nil {
+		t.Fatalf("NewMemorySessionStore: %v", err)
+	}
+	memory := s.(*store)
+	memory.now = func() time.Time { return *now }
+	return memory
+}
+
+func TestRotate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// step rotates session "s1", created with token hash "h0" and expiring
+	// an hour after start, from hash to next at the given offset.
+	type step struct {
+		at   time.Duration
+		hash string
+		next string
+		err  error
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+		// live is whether the session can still be read afterwards.
+		live bool
+	}{
+		{
+			name:  "rotation",
+			steps: []step{{0, "h0", "h1", nil}, {time.Minute, "h1", "h2", nil}},
+			live:  true,
+		},
+		{
+			name: "reuse of a rotated token revokes the session",
+			steps: []step{
+				{0, "h0", "h1", nil},
+				{time.Minute, "h0", "h2", domain.ErrorRefreshTokenReused},
+				{2 * time.Minute, "h1", "h2", domain.ErrorSessionNotFound},
+			},
+			live: false,
+		},
+		{
+			name:  "unknown token revokes the session",
+			steps: []step{{0, "forged", "h1", domain.ErrorRefreshTokenReused}},
+			live:  false,
+		},
+		{
+			name:  "expired session",
+			steps: []step{{time.Hour, "h0", "h1", domain.ErrorSessionNotFound}},
+			live:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			now := start
+			s := newStore(t, &now)
+
+			err := s.Create(ctx, &domain.Session{ID: "s1", UserID: "u1", CreatedAt: start, ExpiresAt: start.Add(time.Hour)}, "h0")
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			for i, step := range tt.steps {
+				now = start.Add(step.at)
+				rotated, err := s.Rotate(ctx, "s1", step.hash, step.next, now.Add(time.Hour))
+				if !errors.Is(err, step.err) {
+					t.Fatalf("step %d: Rotate() error = %v, want %v", i, err, step.err)
+				}
+				if err == nil && !rotated.ExpiresAt.Equal(now.Add(time.Hour)) {
+					t.Fatalf("step %d: Rotate() expires at %s, want %s", i, rotated.ExpiresAt, now.Add(time.Hour))
+				}
+			}
+
+			_, err = s.Get(ctx, "s1")
+			if live := err == nil; live != tt.live {
+				t.Fatalf("Get() error = %v, want live %v", err, tt.live)
+			}
+		})
+	}
+}
+
+func TestRevokeAll(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	s := newStore(t, &now)
+
+	sessions := []domain.Session{
+		{ID: "a1", UserID: "alice", ExpiresAt: now.Add(time.Hour)},
+		{ID: "a2", UserID: "alice", ExpiresAt: now.Add(time.Hour)},
+		{ID: "b1", UserID: "bob", ExpiresAt: now.Add(time.Hour)},
+	}
+	for i := range sessions {
+		if err := s.Create(ctx, &sessions[i], "h"); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	if err := s.RevokeAll(ctx, "alice"); err != nil {
+		t.Fatalf("RevokeAll: %v", err)
+	}
+
+	for _, tt := range []struct {
+		id   string
+		live bool
+	}{{"a1", false}, {"a2", false}, {"b1", true}} {
+		if _, err := s.Get(ctx, tt.id); (err == nil) != tt.live {
+			t.Fatalf("Get(%s) error = %v, want live %v", tt.id, err, tt.live)
+		}
+	}
+
+	if err := s.Revoke(ctx, "a1"); !errors.Is(err, domain.ErrorSessionNotFound) {
+		t.Fatalf("Revoke() of a revoked session error = %v, want %v", err, domain.ErrorSessionNotFound)
+	}
+}
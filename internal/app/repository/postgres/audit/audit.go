@@ -0,0 +1,208 @@
+package audit
+
+import (
+	"app/internal/app/repository/postgres/audit/converter"
+	repoAudit "app/internal/app/repository/postgres/audit/model"
+	"app/internal/domain"
+	"app/internal/pkg/database"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/reqctx"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Repository stores the audit log. Record and Scrub join the transaction in
+// ctx, so that they commit or roll back together with the mutation they
+// belong to.
+type Repository interface {
+	Record(ctx context.Context, entry *domain.AuditEntry) error
+	Scrub(ctx context.Context, target string, fields ...string) error
+	List(ctx context.Context, filter domain.AuditFilter) (*domain.AuditPage, error)
+}
+
+var _ Repository = (*repository)(nil)
+
+type repository struct {
+	db     *database.Postgres
+	logger logger.Interface
+}
+
+func NewAuditRepository(db *database.Postgres, logger logger.Interface) (Repository, error) {
+	if db == nil {
+		return nil, errors.New("repository.NewAuditRepository: db is null")
+	}
+
+	if logger == nil {
+		return nil, errors.New("repository.NewAuditRepository: logger is null")
+	}
+
+	return &repository{
+		db:     db,
+		logger: logger,
+	}, nil
+}
+
+func (r *repository) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	if entry == nil {
+		return errors.New("repository.Record: entry is null")
+	}
+
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+	log.Debug(fmt.Sprintf("txID: %s repository.Record, recording %s of %s", txID, entry.Action, entry.TargetUUID))
+
+	e := converter.ToEntryFromDomain(entry)
+	changes, err := json.Marshal(e.Changes)
+	if err != nil {
+		return fmt.Errorf("repository.Record: %w", err)
+	}
+
+	query, args, err := r.db.Builder.
+		Insert("audit_log").
+		Columns("actor", "action", "target_uuid", "request_id", "client_ip", "changes", "created_at").
+		Values(e.Actor, e.Action, e.TargetUuid, e.RequestId, e.ClientIp, string(changes), e.CreatedAt).
+		Suffix("RETURNING audit_id").
+		ToSql()
+
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Record, error building query: %v", txID, err))
+		return fmt.Errorf("repository.Record: %w", err)
+	}
+
+	if err = r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&entry.ID); err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Record, error recording audit entry: %v", txID, err))
+		return fmt.Errorf("repository.Record: %w", err)
+	}
+
+	return nil
+}
+
+// Scrub replaces the values recorded for fields in every entry about target
+// with domain.Redacted. Values that were not set stay null, so the entries
+// still tell what changed.
+func (r *repository) Scrub(ctx context.Context, target string, fields ...string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+	log.Debug(fmt.Sprintf("txID: %s repository.Scrub, scrubbing %v of %s", txID, fields, target))
+
+	for _, field := range fields {
+		query, args, err := r.db.Builder.
+			Update("audit_log").
+			Set("changes", sq.Expr(
+				"jsonb_set(changes, ARRAY[?::text], jsonb_build_object("+
+					"'before', CASE WHEN jsonb_typeof(changes->?::text->'before') <> 'null' THEN to_jsonb(?::text) END, "+
+					"'after', CASE WHEN jsonb_typeof(changes->?::text->'after') <> 'null' THEN to_jsonb(?::text) END))",
+				field, field, domain.Redacted, field, domain.Redacted,
+			)).
+			Where(sq.Eq{"target_uuid": target}).
+			Where("changes->?::text IS NOT NULL", field).
+			ToSql()
+
+		if err != nil {
+			log.Error(fmt.Sprintf("txID: %s repository.Scrub, error building query: %v", txID, err))
+			return fmt.Errorf("repository.Scrub: %w", err)
+		}
+
+		if _, err = r.db.Conn(ctx).Exec(ctx, query, args...); err != nil {
+			log.Error(fmt.Sprintf("txID: %s repository.Scrub, error scrubbing audit entries: %v", txID, err))
+			return fmt.Errorf("repository.Scrub: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// List returns entries newest first.
+func (r *repository) List(ctx context.Context, filter domain.AuditFilter) (*domain.AuditPage, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+	log.Debug(fmt.Sprintf("txID: %s repository.List, listing audit entries with filter: %+v", txID, filter))
+
+	builder := r.db.Builder.
+		Select("audit_id", "actor", "action", "target_uuid", "request_id", "client_ip", "changes", "created_at").
+		From("audit_log")
+
+	for column, value := range map[string]string{
+		"actor":       filter.Actor,
+		"action":      filter.Action,
+		"target_uuid": filter.TargetUUID,
+	} {
+		if value != "" {
+			builder = builder.Where(sq.Eq{column: value})
+		}
+	}
+
+	if filter.From != nil {
+		builder = builder.Where(sq.GtOrEq{"created_at": *filter.From})
+	}
+
+	if filter.To != nil {
+		builder = builder.Where(sq.Lt{"created_at": *filter.To})
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := converter.ToCursorFromString(filter.Cursor)
+		if err != nil {
+			log.Debug(fmt.Sprintf("txID: %s repository.List, invalid cursor: %s", txID, filter.Cursor))
+			return nil, err
+		}
+		builder = builder.Where(sq.Lt{"audit_id": cursor.Id})
+	}
+
+	// One extra row tells whether there is a next page.
+	query, args, err := builder.OrderBy("audit_id DESC").Limit(uint64(filter.Limit) + 1).ToSql()
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.List, error building query: %v", txID, err))
+		return nil, fmt.Errorf("repository.List: %w", err)
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.List, error listing audit entries: %v", txID, err))
+		return nil, fmt.Errorf("repository.List: %w", err)
+	}
+	defer rows.Close()
+
+	page := &domain.AuditPage{Entries: make([]*domain.AuditEntry, 0, filter.Limit)}
+	for rows.Next() {
+		entry := &repoAudit.Entry{}
+		var changes []byte
+		err = rows.Scan(
+			&entry.Id,
+			&entry.Actor,
+			&entry.Action,
+			&entry.TargetUuid,
+			&entry.RequestId,
+			&entry.ClientIp,
+			&changes,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			log.Error(fmt.Sprintf("txID: %s repository.List, error scanning audit entry: %v", txID, err))
+			return nil, fmt.Errorf("repository.List: %w", err)
+		}
+
+		if err = json.Unmarshal(changes, &entry.Changes); err != nil {
+			log.Error(fmt.Sprintf("txID: %s repository.List, error decoding changes: %v", txID, err))
+			return nil, fmt.Errorf("repository.List: %w", err)
+		}
+
+		page.Entries = append(page.Entries, converter.ToEntryFromRepository(entry))
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.List, error iterating audit entries: %v", txID, err))
+		return nil, fmt.Errorf("repository.List: %w", err)
+	}
+
+	if len(page.Entries) > filter.Limit {
+		page.Entries = page.Entries[:filter.Limit]
+		page.NextCursor = converter.ToCursorFromDomain(page.Entries[filter.Limit-1])
+	}
+
+	return page, nil
+}
@@ -0,0 +1,75 @@
+package converter
+
+import (
+	"app/internal/app/repository/postgres/audit/model"
+	"app/internal/domain"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+)
+
+func ToEntryFromRepository(e *model.Entry) *domain.AuditEntry {
+	changes := make(map[string]domain.AuditChange, len(e.Changes))
+	for field, change := range e.Changes {
+		changes[field] = domain.AuditChange{Before: change.Before, After: change.After}
+	}
+
+	return &domain.AuditEntry{
+		ID:         e.Id,
+		Actor:      nullString(e.Actor),
+		Action:     e.Action,
+		TargetUUID: nullString(e.TargetUuid),
+		RequestID:  nullString(e.RequestId),
+		ClientIP:   nullString(e.ClientIp),
+		Changes:    changes,
+		CreatedAt:  e.CreatedAt,
+	}
+}
+
+func ToEntryFromDomain(e *domain.AuditEntry) *model.Entry {
+	changes := make(map[string]model.Change, len(e.Changes))
+	for field, change := range e.Changes {
+		changes[field] = model.Change{Before: change.Before, After: change.After}
+	}
+
+	return &model.Entry{
+		Id:         e.ID,
+		Actor:      toNullString(e.Actor),
+		Action:     e.Action,
+		TargetUuid: toNullString(e.TargetUUID),
+		RequestId:  toNullString(e.RequestID),
+		ClientIp:   toNullString(e.ClientIP),
+		Changes:    changes,
+		CreatedAt:  e.CreatedAt,
+	}
+}
+
+func ToCursorFromDomain(e *domain.AuditEntry) string {
+	raw, _ := json.Marshal(model.Cursor{Id: e.ID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func ToCursorFromString(cursor string) (*model.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, domain.ErrorInvalidCursor
+	}
+
+	c := &model.Cursor{}
+	if err = json.Unmarshal(raw, c); err != nil || c.Id <= 0 {
+		return nil, domain.ErrorInvalidCursor
+	}
+
+	return c, nil
+}
+
+func nullString(s *sql.NullString) string {
+	if s == nil || !s.Valid {
+		return ""
+	}
+	return s.String
+}
+
+func toNullString(s string) *sql.NullString {
+	return &sql.NullString{String: s, Valid: s != ""}
+}
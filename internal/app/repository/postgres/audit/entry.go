@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"app/internal/domain"
+	"app/internal/pkg/auth"
+	"app/internal/pkg/reqctx"
+	"context"
+	"time"
+)
+
+// NewEntry describes a mutation made on behalf of the request in ctx: the
+// authenticated principal, the request ID and the client address are taken
+// from it when present.
+func NewEntry(ctx context.Context, action string, target string, changes map[string]domain.AuditChange) *domain.AuditEntry {
+	entry := &domain.AuditEntry{
+		Action:     action,
+		TargetUUID: target,
+		Changes:    changes,
+		CreatedAt:  time.Now(),
+	}
+
+	entry.RequestID = reqctx.RequestID(ctx)
+	entry.ClientIP = reqctx.ClientIP(ctx)
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		entry.Actor = principal.Subject
+	}
+
+	return entry
+}
+
+// PersonalFields are the user fields that identify a person. They are
+// redacted from the audit log once the user is purged.
+var PersonalFields = []string{"login", "email"}
+
+// Redact replaces the values of PersonalFields in changes with
+// domain.Redacted, keeping whether they were set.
+func Redact(changes map[string]domain.AuditChange) map[string]domain.AuditChange {
+	for _, field := range PersonalFields {
+		change, ok := changes[field]
+		if !ok {
+			continue
+		}
+		if change.Before != nil {
+			change.Before = domain.Redacted
+		}
+		if change.After != nil {
+			change.After = domain.Redacted
+		}
+		changes[field] = change
+	}
+
+	return changes
+}
+
+// UserChanges lists the fields that differ between two states of a user;
+// nil stands for a user that does not exist on that side. The password
+// hash is never recorded, only the fact that it changed.
+func UserChanges(before *domain.User, after *domain.User) map[string]domain.AuditChange {
+	changes := make(map[string]domain.AuditChange)
+
+	field := func(name string, get func(u *domain.User) interface{}) {
+		var b, a interface{}
+		if before != nil {
+			b = get(before)
+		}
+		if after != nil {
+			a = get(after)
+		}
+		if b != a {
+			changes[name] = domain.AuditChange{Before: b, After: a}
+		}
+	}
+
+	field("login", func(u *domain.User) interface{} { return u.Login })
+	field("email", func(u *domain.User) interface{} { return u.Email })
+	field("email_verified", func(u *domain.User) interface{} { return u.EmailVerified })
+	field("deleted_at", func(u *domain.User) interface{} {
+		if u.DeletedAt == nil {
+			return nil
+		}
+		return u.DeletedAt.UTC().Format(time.RFC3339)
+	})
+
+	if before == nil || after == nil || before.Password != after.Password {
+		var b, a interface{}
+		if before != nil {
+			b = domain.Redacted
+		}
+		if after != nil {
+			a = domain.Redacted
+		}
+		changes["password"] = domain.AuditChange{Before: b, After: a}
+	}
+
+	return changes
+}
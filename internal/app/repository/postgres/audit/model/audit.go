@@ -0,0 +1,27 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Entry struct {
+	Id         int64
+	Actor      *sql.NullString
+	Action     string
+	TargetUuid *sql.NullString
+	RequestId  *sql.NullString
+	ClientIp   *sql.NullString
+	Changes    map[string]Change
+	CreatedAt  time.Time
+}
+
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Cursor is the position a page of audit entries continues after.
+type Cursor struct {
+	Id int64 `json:"i"`
+}
@@ -0,0 +1,239 @@
+package outbox
+
+import (
+	"app/internal/domain"
+	"app/internal/pkg/database"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/reqctx"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// maxErrorLength bounds the delivery error kept with an event.
+const maxErrorLength = 1024
+
+// Deliver hands an event on. A nil error marks the event sent; otherwise it
+// is tried again at retryAt, or abandoned when retryAt is nil.
+type Deliver func(ctx context.Context, event *domain.Event) (retryAt *time.Time, err error)
+
+// Repository stores events to relay. Add joins the transaction in ctx, so
+// that the event commits or rolls back together with the change it
+// describes.
+type Repository interface {
+	Add(ctx context.Context, event *domain.Event) error
+	Dispatch(ctx context.Context, limit int, lease time.Duration, deliver Deliver) (int, error)
+	Purge(ctx context.Context, sentBefore time.Time) (int64, error)
+}
+
+var _ Repository = (*repository)(nil)
+
+type repository struct {
+	db     *database.Postgres
+	logger logger.Interface
+}
+
+func NewOutboxRepository(db *database.Postgres, logger logger.Interface) (Repository, error) {
+	if db == nil {
+		return nil, errors.New("repository.NewOutboxRepository: db is null")
+	}
+
+	if logger == nil {
+		return nil, errors.New("repository.NewOutboxRepository: logger is null")
+	}
+
+	return &repository{
+		db:     db,
+		logger: logger,
+	}, nil
+}
+
+func (r *repository) Add(ctx context.Context, event *domain.Event) error {
+	if event == nil {
+		return errors.New("repository.Add: event is null")
+	}
+
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+	log.Debug(fmt.Sprintf("txID: %s repository.Add, adding %s event of %s", txID, event.Type, event.AggregateID))
+
+	query, args, err := r.db.Builder.
+		Insert("outbox").
+		Columns("event_type", "aggregate_id", "payload", "created_at").
+		Values(event.Type, event.AggregateID, string(event.Payload), event.CreatedAt).
+		Suffix("RETURNING event_id").
+		ToSql()
+
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Add, error building query: %v", txID, err))
+		return fmt.Errorf("repository.Add: %w", err)
+	}
+
+	if err = r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&event.ID); err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Add, error adding event: %v", txID, err))
+		return fmt.Errorf("repository.Add: %w", err)
+	}
+
+	return nil
+}
+
+// Dispatch claims up to limit due events in order, delivers them one by one
+// and records each outcome. Claiming pushes the next attempt of the events
+// lease into the future, so that concurrent relays skip them; the claim is
+// committed before delivery, which then holds no transaction open, and each
+// outcome is recorded in a transaction of its own. Events of a relay that
+// dies while delivering become due again once the lease is over. It returns
+// how many events were claimed.
+func (r *repository) Dispatch(ctx context.Context, limit int, lease time.Duration, deliver Deliver) (int, error) {
+	events, err := r.claim(ctx, limit, lease)
+	if err != nil {
+		return 0, fmt.Errorf("repository.Dispatch: %w", err)
+	}
+
+	// An outcome that cannot be recorded leaves the event to be delivered
+	// again after the lease, so the others are still recorded.
+	var errs []error
+	for _, event := range events {
+		retryAt, deliverErr := deliver(ctx, event)
+		err = r.db.InTx(ctx, func(ctx context.Context) error {
+			return r.record(ctx, event, retryAt, deliverErr)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("event %d: %w", event.ID, err))
+		}
+	}
+
+	if err = errors.Join(errs...); err != nil {
+		return len(events), fmt.Errorf("repository.Dispatch: error recording outcomes: %w", err)
+	}
+
+	return len(events), nil
+}
+
+// Purge deletes events that were sent before sentBefore. Abandoned events
+// are kept, with their last error, until someone looks into them.
+func (r *repository) Purge(ctx context.Context, sentBefore time.Time) (int64, error) {
+	query, args, err := r.db.Builder.
+		Delete("outbox").
+		Where(sq.Lt{"sent_at": sentBefore}).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("repository.Purge: %w", err)
+	}
+
+	tag, err := r.db.Conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("repository.Purge: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// claim locks up to limit due events and leases them until lease from now.
+func (r *repository) claim(ctx context.Context, limit int, lease time.Duration) ([]*domain.Event, error) {
+	query, args, err := r.db.Builder.
+		Select("event_id", "event_type", "aggregate_id", "payload", "created_at", "attempts").
+		From("outbox").
+		Where(sq.LtOrEq{"next_attempt_at": time.Now()}).
+		OrderBy("event_id").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*domain.Event
+	err = r.db.InTx(ctx, func(ctx context.Context) error {
+		// The transaction may be run again; start from scratch each time.
+		events = make([]*domain.Event, 0, limit)
+
+		rows, err := r.db.Conn(ctx).Query(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int64, 0, limit)
+		for rows.Next() {
+			event := &domain.Event{}
+			if err = rows.Scan(&event.ID, &event.Type, &event.AggregateID, &event.Payload, &event.CreatedAt, &event.Attempts); err != nil {
+				rows.Close()
+				return err
+			}
+			events = append(events, event)
+			ids = append(ids, event.ID)
+		}
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			return err
+		} else if len(ids) == 0 {
+			return nil
+		}
+
+		update, args, err := r.db.Builder.
+			Update("outbox").
+			Set("next_attempt_at", time.Now().Add(lease)).
+			Where(sq.Eq{"event_id": ids}).
+			ToSql()
+
+		if err != nil {
+			return err
+		}
+
+		_, err = r.db.Conn(ctx).Exec(ctx, update, args...)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *repository) record(ctx context.Context, event *domain.Event, retryAt *time.Time, deliverErr error) error {
+	builder := r.db.Builder.
+		Update("outbox").
+		Set("attempts", sq.Expr("attempts + 1")).
+		Where(sq.Eq{"event_id": event.ID})
+
+	if deliverErr == nil {
+		builder = builder.
+			Set("sent_at", time.Now()).
+			Set("next_attempt_at", nil).
+			Set("last_error", nil)
+	} else {
+		builder = builder.
+			Set("next_attempt_at", retryAt).
+			Set("last_error", truncate(deliverErr.Error(), maxErrorLength))
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Conn(ctx).Exec(ctx, query, args...)
+	return err
+}
+
+// truncate cuts s to at most max bytes without splitting a UTF-8 sequence,
+// which Postgres would reject in a text column.
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+
+	return s[:max]
+}
@@ -0,0 +1,36 @@
+package outbox
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		max  int
+		want string
+	}{
+		{name: "short", s: "timeout", max: 10, want: "timeout"},
+		{name: "exact", s: "timeout", max: 7, want: "timeout"},
+		{name: "ascii", s: "timeout", max: 4, want: "time"},
+		{name: "cut before a multibyte rune", s: "ab€cd", max: 4, want: "ab"},
+		{name: "cut after a multibyte rune", s: "ab€cd", max: 5, want: "ab€"},
+		{name: "only multibyte runes", s: strings.Repeat("é", 600), max: maxErrorLength, want: strings.Repeat("é", 512)},
+		{name: "nothing fits", s: "€", max: 2, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncate(tt.s, tt.max)
+			if got != tt.want {
+				t.Fatalf("truncate() = %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Fatalf("truncate() = %q, not valid UTF-8", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package reset
+
+import (
+	"app/internal/domain"
+	"app/internal/pkg/database"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/reqctx"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+)
+
+// Repository keeps password reset tokens. Only the SHA-256 hash of a token
+// is stored and a token can be consumed once.
+type Repository interface {
+	Create(ctx context.Context, userUUID string, tokenHash string, expiresAt time.Time) error
+	Consume(ctx context.Context, tokenHash string) (string, error)
+}
+
+var _ Repository = (*repository)(nil)
+
+type repository struct {
+	db     *database.Postgres
+	logger logger.Interface
+}
+
+func NewResetRepository(db *database.Postgres, logger logger.Interface) (Repository, error) {
+	if db == nil {
+		return nil, errors.New("repository.NewResetRepository: db is null")
+	}
+
+	if logger == nil {
+		return nil, errors.New("repository.NewResetRepository: logger is null")
+	}
+
+	return &repository{
+		db:     db,
+		logger: logger,
+	}, nil
+}
+
+// Create stores a new reset token for the user and invalidates the ones
+// issued before it, so that only the latest link works.
+func (r *repository) Create(ctx context.Context, userUUID string, tokenHash string, expiresAt time.Time) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+	log.Debug(fmt.Sprintf("txID: %s repository.Create, creating password reset for user with UUID: %s", txID, userUUID))
+
+	now := time.Now()
+	userID := sq.Select("user_id").From("users").Where(sq.Eq{"uuid": userUUID, "deleted_at": nil})
+
+	invalidate, invalidateArgs, err := sq.
+		Update("password_resets").
+		Set("used_at", now).
+		Where(sq.Eq{"used_at": nil}).
+		Where(sq.Expr("user_id = (?)", userID)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Create, error building query: %v", txID, err))
+		return fmt.Errorf("repository.Create: %w", err)
+	}
+
+	insert, insertArgs, err := sq.
+		Insert("password_resets").
+		Columns("user_id", "token_hash", "created_at", "expires_at").
+		Select(sq.
+			Select("user_id").
+			Column("?::char(64)", tokenHash).
+			Column("?::timestamptz", now).
+			Column("?::timestamptz", expiresAt).
+			From("users").
+			Where(sq.Eq{"uuid": userUUID, "deleted_at": nil})).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Create, error building query: %v", txID, err))
+		return fmt.Errorf("repository.Create: %w", err)
+	}
+
+	err = r.db.InTx(ctx, func(ctx context.Context) error {
+		if _, err := r.db.Conn(ctx).Exec(ctx, invalidate, invalidateArgs...); err != nil {
+			return fmt.Errorf("invalidating previous resets: %w", err)
+		}
+
+		tag, err := r.db.Conn(ctx).Exec(ctx, insert, insertArgs...)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return domain.ErrorUserNotFound
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, domain.ErrorUserNotFound) {
+			log.Debug(fmt.Sprintf("txID: %s repository.Create, user with UUID: %s not found", txID, userUUID))
+			return err
+		}
+
+		log.Error(fmt.Sprintf("txID: %s repository.Create, error creating password reset: %v", txID, err))
+		return fmt.Errorf("repository.Create: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s repository.Create, successfully created password reset for user with UUID: %s", txID, userUUID))
+	return nil
+}
+
+// Consume marks an unused, unexpired token as used and returns the UUID of
+// the user it was issued to. The check and the update are one statement, so
+// a token cannot be redeemed twice by concurrent requests.
+func (r *repository) Consume(ctx context.Context, tokenHash string) (string, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+	log.Debug(fmt.Sprintf("txID: %s repository.Consume, consuming password reset", txID))
+
+	now := time.Now()
+	query, args, err := sq.
+		Update("password_resets pr").
+		Set("used_at", now).
+		From("users u").
+		Where("pr.user_id = u.user_id").
+		Where(sq.Eq{"pr.token_hash": tokenHash, "pr.used_at": nil, "u.deleted_at": nil}).
+		Where(sq.Gt{"pr.expires_at": now}).
+		Suffix("RETURNING u.uuid").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Consume, error building query: %v", txID, err))
+		return "", fmt.Errorf("repository.Consume: %w", err)
+	}
+
+	var userUUID string
+	if err = r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&userUUID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug(fmt.Sprintf("txID: %s repository.Consume, password reset is unknown, used or expired", txID))
+			return "", domain.ErrorInvalidResetToken
+		}
+
+		log.Error(fmt.Sprintf("txID: %s repository.Consume, error consuming password reset: %v", txID, err))
+		return "", fmt.Errorf("repository.Consume: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s repository.Consume, successfully consumed password reset of user with UUID: %s", txID, userUUID))
+	return userUUID, nil
+}
@@ -0,0 +1,23 @@
+package converter
+
+import (
+	"app/internal/app/repository/postgres/role/model"
+	"app/internal/domain"
+)
+
+func ToRoleFromRepository(r *model.Role) *domain.Role {
+	if r == nil {
+		return nil
+	}
+
+	permissions := r.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	return &domain.Role{
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: permissions,
+	}
+}
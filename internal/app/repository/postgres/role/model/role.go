@@ -0,0 +1,8 @@
+package model
+
+type Role struct {
+	Id          int64
+	Name        string
+	Description string
+	Permissions []string
+}
@@ -0,0 +1,239 @@
+package role
+
+import (
+	"app/internal/app/repository/postgres/audit"
+	"app/internal/app/repository/postgres/role/converter"
+	repoRole "app/internal/app/repository/postgres/role/model"
+	"app/internal/domain"
+	"app/internal/pkg/database"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/reqctx"
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type Repository interface {
+	List(ctx context.Context) ([]*domain.Role, error)
+	ListByUser(ctx context.Context, userUUID string) ([]*domain.Role, error)
+	Assign(ctx context.Context, userUUID string, role string) error
+	Revoke(ctx context.Context, userUUID string, role string) error
+}
+
+var _ Repository = (*repository)(nil)
+
+type repository struct {
+	db     *database.Postgres
+	audit  audit.Repository
+	logger logger.Interface
+}
+
+func NewRoleRepository(db *database.Postgres, audit audit.Repository, logger logger.Interface) (Repository, error) {
+	if db == nil {
+		return nil, errors.New("repository.NewRoleRepository: db is null")
+	}
+
+	if audit == nil {
+		return nil, errors.New("repository.NewRoleRepository: audit is null")
+	}
+
+	if logger == nil {
+		return nil, errors.New("repository.NewRoleRepository: logger is null")
+	}
+
+	return &repository{
+		db:     db,
+		audit:  audit,
+		logger: logger,
+	}, nil
+}
+
+// rolesWithPermissions selects every role along with the names of the
+// permissions it grants.
+func rolesWithPermissions() sq.SelectBuilder {
+	return sq.
+		Select(
+			"r.role_id",
+			"r.name",
+			"r.description",
+			"COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')",
+		).
+		From("roles r").
+		LeftJoin("role_permissions rp ON rp.role_id = r.role_id").
+		LeftJoin("permissions p ON p.permission_id = rp.permission_id").
+		GroupBy("r.role_id").
+		OrderBy("r.name").
+		PlaceholderFormat(sq.Dollar)
+}
+
+func (r *repository) List(ctx context.Context) ([]*domain.Role, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+	log.Debug(fmt.Sprintf("txID: %s repository.List, listing roles", txID))
+
+	roles, err := r.query(ctx, rolesWithPermissions())
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.List, error listing roles: %v", txID, err))
+		return nil, fmt.Errorf("repository.List: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (r *repository) ListByUser(ctx context.Context, userUUID string) ([]*domain.Role, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+	log.Debug(fmt.Sprintf("txID: %s repository.ListByUser, listing roles of user with UUID: %s", txID, userUUID))
+
+	builder := rolesWithPermissions().
+		Join("user_roles ur ON ur.role_id = r.role_id").
+		Join("users u ON u.user_id = ur.user_id").
+		Where(sq.Eq{"u.uuid": userUUID, "u.deleted_at": nil})
+
+	roles, err := r.query(ctx, builder)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.ListByUser, error listing roles: %v", txID, err))
+		return nil, fmt.Errorf("repository.ListByUser: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (r *repository) Assign(ctx context.Context, userUUID string, role string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+	log.Debug(fmt.Sprintf("txID: %s repository.Assign, assigning role %s to user with UUID: %s", txID, role, userUUID))
+
+	userID, roleID, err := r.resolveIDs(ctx, userUUID, role)
+	if err != nil {
+		return fmt.Errorf("repository.Assign: %w", err)
+	}
+
+	query, args, err := sq.
+		Insert("user_roles").
+		Columns("user_id", "role_id").
+		Values(userID, roleID).
+		Suffix("ON CONFLICT DO NOTHING").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Assign, error building query: %v", txID, err))
+		return fmt.Errorf("repository.Assign: %w", err)
+	}
+
+	err = r.db.InTx(ctx, func(ctx context.Context) error {
+		tag, err := r.db.Conn(ctx).Exec(ctx, query, args...)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+
+		changes := map[string]domain.AuditChange{"role": {Before: nil, After: role}}
+		return r.audit.Record(ctx, audit.NewEntry(ctx, domain.AuditRoleAssigned, userUUID, changes))
+	})
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Assign, error assigning role: %v", txID, err))
+		return fmt.Errorf("repository.Assign: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s repository.Assign, successfully assigned role %s to user with UUID: %s", txID, role, userUUID))
+	return nil
+}
+
+func (r *repository) Revoke(ctx context.Context, userUUID string, role string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+	log.Debug(fmt.Sprintf("txID: %s repository.Revoke, revoking role %s from user with UUID: %s", txID, role, userUUID))
+
+	userID, roleID, err := r.resolveIDs(ctx, userUUID, role)
+	if err != nil {
+		return fmt.Errorf("repository.Revoke: %w", err)
+	}
+
+	query, args, err := sq.
+		Delete("user_roles").
+		Where(sq.Eq{"user_id": userID, "role_id": roleID}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Revoke, error building query: %v", txID, err))
+		return fmt.Errorf("repository.Revoke: %w", err)
+	}
+
+	err = r.db.InTx(ctx, func(ctx context.Context) error {
+		tag, err := r.db.Conn(ctx).Exec(ctx, query, args...)
+		if err != nil || tag.RowsAffected() == 0 {
+			return err
+		}
+
+		changes := map[string]domain.AuditChange{"role": {Before: role, After: nil}}
+		return r.audit.Record(ctx, audit.NewEntry(ctx, domain.AuditRoleRevoked, userUUID, changes))
+	})
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Revoke, error revoking role: %v", txID, err))
+		return fmt.Errorf("repository.Revoke: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s repository.Revoke, successfully revoked role %s from user with UUID: %s", txID, role, userUUID))
+	return nil
+}
+
+// resolveIDs maps a live user and a role name to their primary keys in one
+// round trip, reporting which of the two does not exist.
+func (r *repository) resolveIDs(ctx context.Context, userUUID string, role string) (int64, int64, error) {
+	userQuery := sq.Select("user_id").From("users").Where(sq.Eq{"uuid": userUUID, "deleted_at": nil})
+	roleQuery := sq.Select("role_id").From("roles").Where(sq.Eq{"name": role})
+
+	query, args, err := sq.
+		Select().
+		Column(sq.Alias(userQuery, "user_id")).
+		Column(sq.Alias(roleQuery, "role_id")).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var userID, roleID *int64
+	if err = r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&userID, &roleID); err != nil {
+		return 0, 0, err
+	}
+
+	if userID == nil {
+		return 0, 0, domain.ErrorUserNotFound
+	}
+
+	if roleID == nil {
+		return 0, 0, domain.ErrorRoleNotFound
+	}
+
+	return *userID, *roleID, nil
+}
+
+func (r *repository) query(ctx context.Context, builder sq.SelectBuilder) ([]*domain.Role, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make([]*domain.Role, 0)
+	for rows.Next() {
+		role := &repoRole.Role{}
+		if err = rows.Scan(&role.Id, &role.Name, &role.Description, &role.Permissions); err != nil {
+			return nil, err
+		}
+		roles = append(roles, converter.ToRoleFromRepository(role))
+	}
+
+	return roles, rows.Err()
+}
@@ -3,12 +3,16 @@ package converter
 import (
 	postgres "app/internal/app/repository/postgres/user/model"
 	"app/internal/domain"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"time"
 )
 
 func ToUserFromRepository(repoUser *postgres.User) *domain.User {
 	var updatedAt *time.Time
 	var deletedAt *time.Time
+	var email string
 
 	if repoUser.UpdatedAt != nil && repoUser.UpdatedAt.Valid {
 		updatedAt = &repoUser.UpdatedAt.Time
@@ -18,21 +22,96 @@ func ToUserFromRepository(repoUser *postgres.User) *domain.User {
 		deletedAt = &repoUser.DeletedAt.Time
 	}
 
+	if repoUser.Email != nil && repoUser.Email.Valid {
+		email = repoUser.Email.String
+	}
+
 	return &domain.User{
-		Uuid:      repoUser.Uuid,
-		Login:     repoUser.Login,
-		Password:  repoUser.Password,
-		CreatedAt: repoUser.CreatedAt,
-		UpdatedAt: updatedAt,
-		DeletedAt: deletedAt,
+		Uuid:          repoUser.Uuid,
+		Login:         repoUser.Login,
+		Password:      repoUser.Password,
+		Email:         email,
+		EmailVerified: repoUser.EmailVerified,
+		CreatedAt:     repoUser.CreatedAt,
+		UpdatedAt:     updatedAt,
+		DeletedAt:     deletedAt,
+		Version:       repoUser.Version,
 	}
 }
 
 func ToUserFromDomain(domainUser *domain.User) *postgres.User {
 	return &postgres.User{
-		Uuid:      domainUser.Uuid,
-		Login:     domainUser.Login,
-		Password:  domainUser.Password,
-		CreatedAt: domainUser.CreatedAt,
+		Uuid:          domainUser.Uuid,
+		Login:         domainUser.Login,
+		Password:      domainUser.Password,
+		Email:         &sql.NullString{String: domainUser.Email, Valid: domainUser.Email != ""},
+		EmailVerified: domainUser.EmailVerified,
+		CreatedAt:     domainUser.CreatedAt,
+		Version:       domainUser.Version,
 	}
 }
+
+func ToCursorFromDomain(user *domain.User, sort domain.UserSort) string {
+	raw, _ := json.Marshal(postgres.Cursor{
+		CreatedAt: user.CreatedAt,
+		Uuid:      user.Uuid,
+		Sort:      string(sort),
+	})
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func ToCursorFromString(cursor string) (*postgres.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, domain.ErrorInvalidCursor
+	}
+
+	c := &postgres.Cursor{}
+	if err = json.Unmarshal(raw, c); err != nil || c.Uuid == "" {
+		return nil, domain.ErrorInvalidCursor
+	}
+
+	return c, nil
+}
+
+func ToUserCreatedEventFromDomain(user *domain.User) (*domain.Event, error) {
+	payload, err := json.Marshal(postgres.UserCreatedEvent{
+		Uuid:      user.Uuid,
+		Login:     user.Login,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Event{
+		Type:        domain.EventUserCreated,
+		AggregateID: user.Uuid,
+		Payload:     payload,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+func ToUserDeletedEventFromDomain(user *domain.User) (*domain.Event, error) {
+	var deletedAt time.Time
+	if user.DeletedAt != nil {
+		deletedAt = *user.DeletedAt
+	}
+
+	payload, err := json.Marshal(postgres.UserDeletedEvent{
+		Uuid:      user.Uuid,
+		DeletedAt: deletedAt.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Event{
+		Type:        domain.EventUserDeleted,
+		AggregateID: user.Uuid,
+		Payload:     payload,
+		CreatedAt:   time.Now(),
+	}, nil
+}
@@ -6,11 +6,36 @@ import (
 )
 
 type User struct {
-	Id        int64
-	Uuid      string
-	Login     string
-	Password  string
-	CreatedAt time.Time
-	UpdatedAt *sql.NullTime
-	DeletedAt *sql.NullTime
+	Id            int64
+	Uuid          string
+	Login         string
+	Password      string
+	Email         *sql.NullString
+	EmailVerified bool
+	CreatedAt     time.Time
+	UpdatedAt     *sql.NullTime
+	DeletedAt     *sql.NullTime
+	Version       int64
+}
+
+// Cursor is the keyset position a page of users continues after.
+type Cursor struct {
+	CreatedAt time.Time `json:"c"`
+	Uuid      string    `json:"u"`
+	Sort      string    `json:"s"`
+}
+
+// UserCreatedEvent is the payload of the UserCreated event. It is a public
+// contract, so it never carries the password.
+type UserCreatedEvent struct {
+	Uuid      string `json:"uuid"`
+	Login     string `json:"login"`
+	Email     string `json:"email,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// UserDeletedEvent is the payload of the UserDeleted event.
+type UserDeletedEvent struct {
+	Uuid      string `json:"uuid"`
+	DeletedAt string `json:"deleted_at"`
 }
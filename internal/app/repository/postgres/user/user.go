@@ -1,45 +1,83 @@
 package user
 
 import (
+	"app/internal/app/repository/postgres/audit"
+	"app/internal/app/repository/postgres/outbox"
 	"app/internal/app/repository/postgres/user/converter"
 	repoUser "app/internal/app/repository/postgres/user/model"
 	"app/internal/domain"
 	"app/internal/pkg/database"
 	"app/internal/pkg/logger"
-	"app/internal/pkg/types"
+	"app/internal/pkg/reqctx"
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 )
 
+const (
+	uniqueViolation = "23505"
+
+	// Unique indexes whose violations are reported as conflicts.
+	loginIndex = "idx_users_login_active"
+	emailIndex = "users_email_lower_key"
+)
+
 type Repository interface {
 	Create(ctx context.Context, user *domain.User) (*domain.User, error)
 	Get(ctx context.Context, uuid string) (*domain.User, error)
 	Delete(ctx context.Context, uuid string) (*domain.User, error)
+	GetByLogin(ctx context.Context, login string) (*domain.User, error)
+	UpdatePassword(ctx context.Context, uuid string, password string) error
+	List(ctx context.Context, filter domain.UserFilter) (*domain.UserPage, error)
+	Update(ctx context.Context, user *domain.User, version int64) (*domain.User, error)
+	Restore(ctx context.Context, uuid string) (*domain.User, error)
+	VerifyEmail(ctx context.Context, uuid string) (*domain.User, error)
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
 var _ Repository = (*repository)(nil)
 
+// repository records every mutation in the audit log, and creations and
+// deletions in the outbox, within the transaction that makes it.
 type repository struct {
 	db     *database.Postgres
+	audit  audit.Repository
+	outbox outbox.Repository
 	logger logger.Interface
 }
 
-func NewUserRepository(db *database.Postgres, logger logger.Interface) (Repository, error) {
+func NewUserRepository(
+	db *database.Postgres,
+	audit audit.Repository,
+	outbox outbox.Repository,
+	logger logger.Interface,
+) (Repository, error) {
 	if db == nil {
 		return nil, errors.New("repository.NewUserRepository: db is null")
 	}
 
+	if audit == nil {
+		return nil, errors.New("repository.NewUserRepository: audit is null")
+	}
+
+	if outbox == nil {
+		return nil, errors.New("repository.NewUserRepository: outbox is null")
+	}
+
 	if logger == nil {
 		return nil, errors.New("repository.NewUserRepository: logger is null")
 	}
 
 	return &repository{
 		db:     db,
+		audit:  audit,
+		outbox: outbox,
 		logger: logger,
 	}, nil
 }
@@ -49,113 +87,659 @@ func (r *repository) Create(ctx context.Context, u *domain.User) (*domain.User,
 		return nil, errors.New("repository.Create: user is null")
 	}
 
-	txID := ctx.Value(types.CtxKey("tx")).(string)
-	r.logger.Debug(fmt.Sprintf("txID: %s repository.Create, creating user: %v", txID, u))
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+	log.Debug(fmt.Sprintf("txID: %s repository.Create, creating user: %s", txID, u.Uuid))
 
 	u.CreatedAt = time.Now()
 	repoUsr := converter.ToUserFromDomain(u)
 
 	query, args, err := sq.
 		Insert("users").
-		Columns("uuid", "login", "password", "created_at").
-		Values(repoUsr.Uuid, repoUsr.Login, repoUsr.Password, repoUsr.CreatedAt).
-		Suffix("RETURNING uuid").
+		Columns("uuid", "login", "password", "email", "created_at").
+		Values(repoUsr.Uuid, repoUsr.Login, repoUsr.Password, repoUsr.Email, repoUsr.CreatedAt).
+		Suffix("RETURNING uuid, version").
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 
 	if err != nil {
-		r.logger.Error(fmt.Sprintf("txID: %s repository.Create, error building query: %v", txID, err))
+		log.Error(fmt.Sprintf("txID: %s repository.Create, error building query: %v", txID, err))
 		return nil, fmt.Errorf("repository.Create: %w", err)
 	}
 
-	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(&repoUsr.Uuid)
+	err = r.db.InTx(ctx, func(ctx context.Context) error {
+		if err := r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&repoUsr.Uuid, &repoUsr.Version); err != nil {
+			return err
+		}
+
+		created := converter.ToUserFromRepository(repoUsr)
+		if err := r.audit.Record(ctx, audit.NewEntry(ctx, domain.AuditUserCreated, created.Uuid, audit.UserChanges(nil, created))); err != nil {
+			return err
+		}
+
+		event, err := converter.ToUserCreatedEventFromDomain(created)
+		if err != nil {
+			return err
+		}
+		return r.outbox.Add(ctx, event)
+	})
 	if err != nil {
-		r.logger.Error(fmt.Sprintf("txID: %s repository.Create, error creating user: %v", txID, err))
+		if conflict := uniqueConflict(err); conflict != nil {
+			log.Debug(fmt.Sprintf("txID: %s repository.Create, login or email of user %s is already taken", txID, repoUsr.Uuid))
+			return nil, conflict
+		}
+
+		log.Error(fmt.Sprintf("txID: %s repository.Create, error creating user: %v", txID, err))
 		return nil, fmt.Errorf("repository.Create: %w", err)
 	}
 
-	r.logger.Debug(fmt.Sprintf("txID: %s repository.Create, successfully created user with UUID: %s", txID, repoUsr.Uuid))
+	log.Debug(fmt.Sprintf("txID: %s repository.Create, successfully created user with UUID: %s", txID, repoUsr.Uuid))
 
 	return converter.ToUserFromRepository(repoUsr), nil
 }
 
 func (r *repository) Delete(ctx context.Context, uuid string) (*domain.User, error) {
-	txID := ctx.Value(types.CtxKey("tx")).(string)
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
 
-	r.logger.Debug(fmt.Sprintf("txID: %s repository.Delete, deleting user with UUID: %s", txID, uuid))
+	log.Debug(fmt.Sprintf("txID: %s repository.Delete, deleting user with UUID: %s", txID, uuid))
 
 	now := time.Now()
 	query, args, err := sq.
 		Update("users").
 		Set("deleted_at", now).
-		Where(sq.Eq{"uuid": uuid}).
-		Suffix("RETURNING uuid, login, password, created_at, updated_at, deleted_at").
+		Where(sq.Eq{"uuid": uuid, "deleted_at": nil}).
+		Suffix("RETURNING uuid, login, password, created_at, updated_at, deleted_at, version, email, email_verified").
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 
 	if err != nil {
-		r.logger.Error(fmt.Sprintf("txID: %s repository.Delete, error building query: %v", txID, err))
+		log.Error(fmt.Sprintf("txID: %s repository.Delete, error building query: %v", txID, err))
 		return nil, fmt.Errorf("repository.Delete: %w", err)
 	}
 
+	var user *repoUser.User
+	err = r.db.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if user, err = scanUser(r.db.Conn(ctx).QueryRow(ctx, query, args...)); err != nil {
+			return err
+		}
+
+		deleted := converter.ToUserFromRepository(user)
+		before := *deleted
+		before.DeletedAt = nil
+		if err = r.audit.Record(ctx, audit.NewEntry(ctx, domain.AuditUserDeleted, deleted.Uuid, audit.UserChanges(&before, deleted))); err != nil {
+			return err
+		}
+
+		event, err := converter.ToUserDeletedEventFromDomain(deleted)
+		if err != nil {
+			return err
+		}
+		return r.outbox.Add(ctx, event)
+	})
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug(fmt.Sprintf("txID: %s repository.Delete, user with UUID: %s not found", txID, uuid))
+			return nil, domain.ErrorUserNotFound
+		}
+
+		log.Error(fmt.Sprintf("txID: %s repository.Delete, error soft deleting user: %v", txID, err))
+		return nil, fmt.Errorf("repository.Delete: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s repository.Delete, successfully soft deleted user with UUID: %s", txID, user.Uuid))
+
+	return converter.ToUserFromRepository(user), nil
+}
+
+func (r *repository) Get(ctx context.Context, uuid string) (*domain.User, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+
+	log.Debug(fmt.Sprintf("txID: %s repository.Get, fetching user with UUID: %s", txID, uuid))
+
+	query, args, err := sq.
+		Select("uuid", "login", "password", "created_at", "updated_at", "deleted_at", "version", "email", "email_verified").
+		From("users").
+		Where(sq.Eq{"uuid": uuid, "deleted_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Get, error building query: %v", txID, err))
+		return nil, fmt.Errorf("repository.Get: %w", err)
+	}
+
 	user := &repoUser.User{}
-	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(
+	err = r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(
 		&user.Uuid,
 		&user.Login,
 		&user.Password,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.DeletedAt,
+		&user.Version,
+		&user.Email,
+		&user.EmailVerified,
 	)
 
 	if err != nil {
-		r.logger.Error(fmt.Sprintf("txID: %s repository.Delete, error soft deleting user: %v", txID, err))
-		return nil, fmt.Errorf("repository.Delete: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug(fmt.Sprintf("txID: %s repository.Get, user with UUID: %s not found", txID, uuid))
+			return nil, nil
+		}
+
+		log.Error(fmt.Sprintf("txID: %s repository.Get, error fetching user: %v", txID, err))
+		return nil, fmt.Errorf("repository.Get: %w", err)
 	}
 
-	r.logger.Debug(fmt.Sprintf("txID: %s repository.Delete, successfully soft deleted user with UUID: %s", txID, user.Uuid))
+	log.Debug(fmt.Sprintf("txID: %s repository.Get, successfully fetched user with UUID: %s", txID, user.Uuid))
 
 	return converter.ToUserFromRepository(user), nil
 }
 
-func (r *repository) Get(ctx context.Context, uuid string) (*domain.User, error) {
-	txID := ctx.Value(types.CtxKey("tx")).(string)
+func (r *repository) GetByLogin(ctx context.Context, login string) (*domain.User, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
 
-	r.logger.Debug(fmt.Sprintf("txID: %s repository.Get, fetching user with UUID: %s", txID, uuid))
+	log.Debug(fmt.Sprintf("txID: %s repository.GetByLogin, fetching user by login", txID))
 
 	query, args, err := sq.
-		Select("uuid", "login", "password", "created_at", "updated_at", "deleted_at").
+		Select("uuid", "login", "password", "created_at", "updated_at", "deleted_at", "version", "email", "email_verified").
 		From("users").
-		Where(sq.Eq{"uuid": uuid, "deleted_at": nil}).
+		Where("lower(login) = lower(?)", login).
+		Where(sq.Eq{"deleted_at": nil}).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
 
 	if err != nil {
-		r.logger.Error(fmt.Sprintf("txID: %s repository.Get, error building query: %v", txID, err))
-		return nil, fmt.Errorf("repository.Get: %w", err)
+		log.Error(fmt.Sprintf("txID: %s repository.GetByLogin, error building query: %v", txID, err))
+		return nil, fmt.Errorf("repository.GetByLogin: %w", err)
 	}
 
 	user := &repoUser.User{}
-	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(
+	err = r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(
 		&user.Uuid,
 		&user.Login,
 		&user.Password,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.DeletedAt,
+		&user.Version,
+		&user.Email,
+		&user.EmailVerified,
 	)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			r.logger.Debug(fmt.Sprintf("txID: %s repository.Get, user with UUID: %s not found", txID, uuid))
+			log.Debug(fmt.Sprintf("txID: %s repository.GetByLogin, no user with the login found", txID))
 			return nil, nil
 		}
 
-		r.logger.Error(fmt.Sprintf("txID: %s repository.Get, error fetching user: %v", txID, err))
-		return nil, fmt.Errorf("repository.Get: %w", err)
+		log.Error(fmt.Sprintf("txID: %s repository.GetByLogin, error fetching user: %v", txID, err))
+		return nil, fmt.Errorf("repository.GetByLogin: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s repository.GetByLogin, successfully fetched user with UUID: %s", txID, user.Uuid))
+
+	return converter.ToUserFromRepository(user), nil
+}
+
+// UpdatePassword stores a new password hash and bumps the version, so that
+// an ETag taken before the change no longer matches.
+func (r *repository) UpdatePassword(ctx context.Context, uuid string, password string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+
+	log.Debug(fmt.Sprintf("txID: %s repository.UpdatePassword, updating password of user with UUID: %s", txID, uuid))
+
+	query, args, err := sq.
+		Update("users").
+		Set("password", password).
+		Set("updated_at", time.Now()).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"uuid": uuid, "deleted_at": nil}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.UpdatePassword, error building query: %v", txID, err))
+		return fmt.Errorf("repository.UpdatePassword: %w", err)
+	}
+
+	err = r.db.InTx(ctx, func(ctx context.Context) error {
+		tag, err := r.db.Conn(ctx).Exec(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return domain.ErrorUserNotFound
+		}
+
+		changes := map[string]domain.AuditChange{"password": {Before: domain.Redacted, After: domain.Redacted}}
+		return r.audit.Record(ctx, audit.NewEntry(ctx, domain.AuditUserPasswordChanged, uuid, changes))
+	})
+
+	if err != nil {
+		if errors.Is(err, domain.ErrorUserNotFound) {
+			log.Debug(fmt.Sprintf("txID: %s repository.UpdatePassword, user with UUID: %s not found", txID, uuid))
+			return domain.ErrorUserNotFound
+		}
+
+		log.Error(fmt.Sprintf("txID: %s repository.UpdatePassword, error updating password: %v", txID, err))
+		return fmt.Errorf("repository.UpdatePassword: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s repository.UpdatePassword, successfully updated password of user with UUID: %s", txID, uuid))
+
+	return nil
+}
+
+// Update stores the login and password of the user if its version still
+// equals version, bumping the version. An empty password keeps the stored
+// one. A user that exists with another version yields
+// domain.ErrorUserModified.
+func (r *repository) Update(ctx context.Context, u *domain.User, version int64) (*domain.User, error) {
+	if u == nil {
+		return nil, errors.New("repository.Update: user is null")
+	}
+
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+
+	log.Debug(fmt.Sprintf("txID: %s repository.Update, updating user with UUID: %s at version: %d", txID, u.Uuid, version))
+
+	builder := r.db.Builder.
+		Update("users").
+		Set("login", u.Login).
+		Set("updated_at", time.Now()).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"uuid": u.Uuid, "deleted_at": nil, "version": version}).
+		Suffix("RETURNING uuid, login, password, created_at, updated_at, deleted_at, version, email, email_verified")
+
+	// Users read through the cache come without their password hash.
+	if u.Password != "" {
+		builder = builder.Set("password", u.Password)
+	}
+
+	query, args, err := builder.ToSql()
+
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Update, error building query: %v", txID, err))
+		return nil, fmt.Errorf("repository.Update: %w", err)
+	}
+
+	var user *repoUser.User
+	err = r.db.InTx(ctx, func(ctx context.Context) error {
+		current, err := r.lock(ctx, sq.Eq{"uuid": u.Uuid, "deleted_at": nil})
+		if err != nil {
+			return err
+		} else if current == nil {
+			return domain.ErrorUserNotFound
+		} else if current.Version != version {
+			log.Debug(fmt.Sprintf("txID: %s repository.Update, user with UUID: %s is at version: %d", txID, u.Uuid, current.Version))
+			return domain.ErrorUserModified
+		}
+
+		if user, err = scanUser(r.db.Conn(ctx).QueryRow(ctx, query, args...)); err != nil {
+			return err
+		}
+
+		updated := converter.ToUserFromRepository(user)
+		return r.audit.Record(ctx, audit.NewEntry(ctx, domain.AuditUserUpdated, updated.Uuid, audit.UserChanges(current, updated)))
+	})
+
+	if err != nil {
+		if errors.Is(err, domain.ErrorUserNotFound) || errors.Is(err, domain.ErrorUserModified) {
+			return nil, err
+		}
+
+		if conflict := uniqueConflict(err); conflict != nil {
+			log.Debug(fmt.Sprintf("txID: %s repository.Update, login of user %s is already taken", txID, u.Uuid))
+			return nil, conflict
+		}
+
+		log.Error(fmt.Sprintf("txID: %s repository.Update, error updating user: %v", txID, err))
+		return nil, fmt.Errorf("repository.Update: %w", err)
 	}
 
-	r.logger.Debug(fmt.Sprintf("txID: %s repository.Get, successfully fetched user with UUID: %s", txID, user.Uuid))
+	log.Debug(fmt.Sprintf("txID: %s repository.Update, successfully updated user with UUID: %s to version: %d", txID, user.Uuid, user.Version))
 
 	return converter.ToUserFromRepository(user), nil
 }
+
+func (r *repository) VerifyEmail(ctx context.Context, uuid string) (*domain.User, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+
+	log.Debug(fmt.Sprintf("txID: %s repository.VerifyEmail, verifying email of user with UUID: %s", txID, uuid))
+
+	query, args, err := r.db.Builder.
+		Update("users").
+		Set("email_verified", true).
+		Set("updated_at", time.Now()).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"uuid": uuid, "deleted_at": nil}).
+		Where(sq.NotEq{"email": nil}).
+		Suffix("RETURNING uuid, login, password, created_at, updated_at, deleted_at, version, email, email_verified").
+		ToSql()
+
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.VerifyEmail, error building query: %v", txID, err))
+		return nil, fmt.Errorf("repository.VerifyEmail: %w", err)
+	}
+
+	var user *repoUser.User
+	err = r.db.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if user, err = scanUser(r.db.Conn(ctx).QueryRow(ctx, query, args...)); err != nil {
+			return err
+		}
+
+		verified := converter.ToUserFromRepository(user)
+		before := *verified
+		before.EmailVerified = false
+		return r.audit.Record(ctx, audit.NewEntry(ctx, domain.AuditUserEmailVerified, verified.Uuid, audit.UserChanges(&before, verified)))
+	})
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug(fmt.Sprintf("txID: %s repository.VerifyEmail, user with UUID: %s and an email not found", txID, uuid))
+			return nil, domain.ErrorUserNotFound
+		}
+
+		log.Error(fmt.Sprintf("txID: %s repository.VerifyEmail, error verifying email: %v", txID, err))
+		return nil, fmt.Errorf("repository.VerifyEmail: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s repository.VerifyEmail, successfully verified email of user with UUID: %s", txID, user.Uuid))
+
+	return converter.ToUserFromRepository(user), nil
+}
+
+func (r *repository) Restore(ctx context.Context, uuid string) (*domain.User, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+
+	log.Debug(fmt.Sprintf("txID: %s repository.Restore, restoring user with UUID: %s", txID, uuid))
+
+	query, args, err := r.db.Builder.
+		Update("users").
+		Set("deleted_at", nil).
+		Set("updated_at", time.Now()).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"uuid": uuid}).
+		Where(sq.NotEq{"deleted_at": nil}).
+		Suffix("RETURNING uuid, login, password, created_at, updated_at, deleted_at, version, email, email_verified").
+		ToSql()
+
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Restore, error building query: %v", txID, err))
+		return nil, fmt.Errorf("repository.Restore: %w", err)
+	}
+
+	var user *repoUser.User
+	err = r.db.InTx(ctx, func(ctx context.Context) error {
+		deleted, err := r.lock(ctx, sq.And{sq.Eq{"uuid": uuid}, sq.NotEq{"deleted_at": nil}})
+		if err != nil {
+			return err
+		} else if deleted == nil {
+			return pgx.ErrNoRows
+		}
+
+		if user, err = scanUser(r.db.Conn(ctx).QueryRow(ctx, query, args...)); err != nil {
+			return err
+		}
+
+		restored := converter.ToUserFromRepository(user)
+		return r.audit.Record(ctx, audit.NewEntry(ctx, domain.AuditUserRestored, restored.Uuid, audit.UserChanges(deleted, restored)))
+	})
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Debug(fmt.Sprintf("txID: %s repository.Restore, deleted user with UUID: %s not found", txID, uuid))
+			return nil, domain.ErrorUserNotFound
+		}
+
+		if conflict := uniqueConflict(err); conflict != nil {
+			log.Debug(fmt.Sprintf("txID: %s repository.Restore, login or email of user with UUID: %s was taken meanwhile", txID, uuid))
+			return nil, conflict
+		}
+
+		log.Error(fmt.Sprintf("txID: %s repository.Restore, error restoring user: %v", txID, err))
+		return nil, fmt.Errorf("repository.Restore: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s repository.Restore, successfully restored user with UUID: %s", txID, user.Uuid))
+
+	return converter.ToUserFromRepository(user), nil
+}
+
+// Purge permanently deletes users that were soft deleted before deletedBefore.
+func (r *repository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+
+	log.Debug(fmt.Sprintf("txID: %s repository.Purge, purging users deleted before: %s", txID, deletedBefore))
+
+	query, args, err := r.db.Builder.
+		Delete("users").
+		Where(sq.Lt{"deleted_at": deletedBefore}).
+		Suffix("RETURNING uuid, login, password, created_at, updated_at, deleted_at, version, email, email_verified").
+		ToSql()
+
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Purge, error building query: %v", txID, err))
+		return 0, fmt.Errorf("repository.Purge: %w", err)
+	}
+
+	var purged []*domain.User
+	err = r.db.InTx(ctx, func(ctx context.Context) error {
+		rows, err := r.db.Conn(ctx).Query(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			user, err := scanUser(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			purged = append(purged, converter.ToUserFromRepository(user))
+		}
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		// Nothing left in the audit log may identify a purged user.
+		for _, user := range purged {
+			if err = r.audit.Scrub(ctx, user.Uuid, audit.PersonalFields...); err != nil {
+				return err
+			}
+			if err = r.audit.Record(ctx, audit.NewEntry(ctx, domain.AuditUserPurged, user.Uuid, audit.Redact(audit.UserChanges(user, nil)))); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.Purge, error purging users: %v", txID, err))
+		return 0, fmt.Errorf("repository.Purge: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s repository.Purge, successfully purged %d users", txID, len(purged)))
+
+	return int64(len(purged)), nil
+}
+
+func (r *repository) List(ctx context.Context, filter domain.UserFilter) (*domain.UserPage, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+
+	log.Debug(fmt.Sprintf("txID: %s repository.List, listing users with filter: %+v", txID, filter))
+
+	desc := filter.Sort == domain.UserSortCreatedAtDesc
+	builder := r.db.Builder.
+		Select("uuid", "login", "password", "created_at", "updated_at", "deleted_at", "version", "email", "email_verified").
+		From("users").
+		Where(sq.Eq{"deleted_at": nil})
+
+	if filter.LoginPrefix != "" {
+		builder = builder.Where(sq.ILike{"login": escapeLike(filter.LoginPrefix) + "%"})
+	}
+
+	if filter.CreatedFrom != nil {
+		builder = builder.Where(sq.GtOrEq{"created_at": *filter.CreatedFrom})
+	}
+
+	if filter.CreatedTo != nil {
+		builder = builder.Where(sq.Lt{"created_at": *filter.CreatedTo})
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := converter.ToCursorFromString(filter.Cursor)
+		if err != nil || cursor.Sort != string(filter.Sort) {
+			log.Debug(fmt.Sprintf("txID: %s repository.List, invalid cursor: %s", txID, filter.Cursor))
+			return nil, domain.ErrorInvalidCursor
+		}
+
+		if desc {
+			builder = builder.Where("(created_at, uuid) < (?, ?)", cursor.CreatedAt, cursor.Uuid)
+		} else {
+			builder = builder.Where("(created_at, uuid) > (?, ?)", cursor.CreatedAt, cursor.Uuid)
+		}
+	}
+
+	if desc {
+		builder = builder.OrderBy("created_at DESC", "uuid DESC")
+	} else {
+		builder = builder.OrderBy("created_at ASC", "uuid ASC")
+	}
+
+	// One extra row tells whether there is a next page.
+	query, args, err := builder.Limit(uint64(filter.Limit) + 1).ToSql()
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.List, error building query: %v", txID, err))
+		return nil, fmt.Errorf("repository.List: %w", err)
+	}
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.List, error listing users: %v", txID, err))
+		return nil, fmt.Errorf("repository.List: %w", err)
+	}
+	defer rows.Close()
+
+	page := &domain.UserPage{Users: make([]*domain.User, 0, filter.Limit)}
+	for rows.Next() {
+		user := &repoUser.User{}
+		err = rows.Scan(
+			&user.Uuid,
+			&user.Login,
+			&user.Password,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+			&user.DeletedAt,
+			&user.Version,
+			&user.Email,
+			&user.EmailVerified,
+		)
+		if err != nil {
+			log.Error(fmt.Sprintf("txID: %s repository.List, error scanning user: %v", txID, err))
+			return nil, fmt.Errorf("repository.List: %w", err)
+		}
+
+		page.Users = append(page.Users, converter.ToUserFromRepository(user))
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error(fmt.Sprintf("txID: %s repository.List, error iterating users: %v", txID, err))
+		return nil, fmt.Errorf("repository.List: %w", err)
+	}
+
+	if len(page.Users) > filter.Limit {
+		page.Users = page.Users[:filter.Limit]
+		page.NextCursor = converter.ToCursorFromDomain(page.Users[filter.Limit-1], filter.Sort)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s repository.List, successfully listed %d users", txID, len(page.Users)))
+
+	return page, nil
+}
+
+// lock selects the user matching where for the rest of the transaction, or
+// nil if there is none.
+func (r *repository) lock(ctx context.Context, where sq.Sqlizer) (*domain.User, error) {
+	query, args, err := r.db.Builder.
+		Select("uuid", "login", "password", "created_at", "updated_at", "deleted_at", "version", "email", "email_verified").
+		From("users").
+		Where(where).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := scanUser(r.db.Conn(ctx).QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return converter.ToUserFromRepository(user), nil
+}
+
+func scanUser(row pgx.Row) (*repoUser.User, error) {
+	user := &repoUser.User{}
+	err := row.Scan(
+		&user.Uuid,
+		&user.Login,
+		&user.Password,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DeletedAt,
+		&user.Version,
+		&user.Email,
+		&user.EmailVerified,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// uniqueConflict maps a violation of the login or email index to the
+// domain error reporting it, and anything else to nil.
+func uniqueConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolation {
+		return nil
+	}
+
+	switch pgErr.ConstraintName {
+	case loginIndex:
+		return domain.ErrorUserAlreadyExists
+	case emailIndex:
+		return domain.ErrorEmailAlreadyExists
+	default:
+		return nil
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
@@ -0,0 +1,147 @@
+package attempt
+
+import (
+	"app/internal/app/repository/attempt"
+	"app/internal/pkg/cache"
+	"app/internal/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ attempt.Store = (*store)(nil)
+
+const keyPrefix = "attempts:"
+
+// takeScript is attempt.Store.Take in one step. A locked key, or one whose
+// backoff has not passed, refuses the attempt with the time left. A key
+// that has reached the threshold is locked and its counter restarted, so
+// that it is not locked again as soon as the lock ends. Otherwise the
+// attempt is counted. The backoff mirrors attempt.Policy.Backoff, and the
+// expiry is never shortened, so that a lock outlives the counter window.
+//
+// ARGV: now, window, threshold, duration, backoff after, base, max; times
+// in milliseconds. Returns failures, wait and 1 if the key was locked.
+var takeScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local values = redis.call('HMGET', KEYS[1], 'failures', 'last_failure', 'locked_until')
+local failures = tonumber(values[1]) or 0
+local last = tonumber(values[2]) or 0
+local locked_until = tonumber(values[3]) or 0
+
+if locked_until > now then
+	return {failures, locked_until - now, 0}
+end
+
+local threshold = tonumber(ARGV[3])
+local duration = tonumber(ARGV[4])
+if threshold > 0 and failures >= threshold then
+	redis.call('HSET', KEYS[1], 'failures', 0, 'locked_until', now + duration)
+	if redis.call('PTTL', KEYS[1]) < duration then
+		redis.call('PEXPIRE', KEYS[1], duration)
+	end
+	return {failures, duration, 1}
+end
+
+local after = tonumber(ARGV[5])
+local base = tonumber(ARGV[6])
+local max = tonumber(ARGV[7])
+if after > 0 and base > 0 and failures >= after then
+	local wait = base
+	for i = after + 1, failures do
+		wait = wait * 2
+		if max > 0 and wait >= max then
+			break
+		end
+	end
+	if max > 0 and wait > max then
+		wait = max
+	end
+	if last + wait > now then
+		return {failures, last + wait - now, 0}
+	end
+end
+
+failures = redis.call('HINCRBY', KEYS[1], 'failures', 1)
+redis.call('HSET', KEYS[1], 'last_failure', now)
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return {failures, 0, 0}
+`)
+
+// releaseScript takes one failure back without creating the key or going
+// below zero.
+var releaseScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+if redis.call('HINCRBY', KEYS[1], 'failures', -1) < 0 then
+	redis.call('HSET', KEYS[1], 'failures', 0)
+end
+return 1
+`)
+
+type store struct {
+	client *redis.Client
+	logger logger.Interface
+}
+
+func NewRedisAttemptStore(cache *cache.Redis, logger logger.Interface) (attempt.Store, error) {
+	if cache == nil || cache.Client == nil {
+		return nil, errors.New("store.NewRedisAttemptStore: cache is null")
+	}
+
+	if logger == nil {
+		return nil, errors.New("store.NewRedisAttemptStore: logger is null")
+	}
+
+	return &store{
+		client: cache.Client,
+		logger: logger,
+	}, nil
+}
+
+func (s *store) Take(ctx context.Context, key string, at time.Time, policy attempt.Policy) (*attempt.Outcome, error) {
+	result, err := takeScript.Run(ctx, s.client, []string{keyPrefix + key},
+		at.UnixMilli(),
+		policy.Window.Milliseconds(),
+		policy.Threshold,
+		policy.Duration.Milliseconds(),
+		policy.BackoffAfter,
+		policy.BackoffBase.Milliseconds(),
+		policy.BackoffMax.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("store.Take: %w", err)
+	}
+
+	if len(result) != 3 {
+		return nil, fmt.Errorf("store.Take: unexpected result %v", result)
+	}
+
+	return &attempt.Outcome{
+		Failures: result[0],
+		Wait:     time.Duration(result[1]) * time.Millisecond,
+		Locked:   result[2] == 1,
+	}, nil
+}
+
+func (s *store) Release(ctx context.Context, key string) error {
+	if err := releaseScript.Run(ctx, s.client, []string{keyPrefix + key}).Err(); err != nil {
+		return fmt.Errorf("store.Release: %w", err)
+	}
+
+	return nil
+}
+
+func (s *store) Reset(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, keyPrefix+key).Err(); err != nil {
+		return fmt.Errorf("store.Reset: %w", err)
+	}
+
+	return nil
+}
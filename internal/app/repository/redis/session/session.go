@@ -0,0 +1,241 @@
+package session
+
+import (
+	"app/internal/app/repository/session"
+	"app/internal/domain"
+	"app/internal/pkg/cache"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/reqctx"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ session.Store = (*store)(nil)
+
+const (
+	sessionKeyPrefix      = "session:"
+	userSessionsKeyPrefix = "user_sessions:"
+
+	fieldUserID    = "user_id"
+	fieldTokenHash = "token_hash"
+	fieldCreatedAt = "created_at"
+	fieldExpiresAt = "expires_at"
+)
+
+// rotateScript replaces the token hash if the presented one is current.
+// Returns 1 on success, 0 if the session does not exist and -1 if the
+// presented hash is stale, in which case the session is deleted. The user
+// index only ever has its expiry extended so it outlives every session.
+var rotateScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'token_hash')
+if not current then
+	return 0
+end
+if current ~= ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	redis.call('SREM', KEYS[2], ARGV[3])
+	return -1
+end
+redis.call('HSET', KEYS[1], 'token_hash', ARGV[2], 'expires_at', ARGV[4])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+if redis.call('PTTL', KEYS[2]) < tonumber(ARGV[5]) then
+	redis.call('PEXPIRE', KEYS[2], ARGV[5])
+end
+return 1
+`)
+
+type store struct {
+	client *redis.Client
+	logger logger.Interface
+}
+
+func NewRedisSessionStore(cache *cache.Redis, logger logger.Interface) (session.Store, error) {
+	if cache == nil || cache.Client == nil {
+		return nil, errors.New("store.NewRedisSessionStore: cache is null")
+	}
+
+	if logger == nil {
+		return nil, errors.New("store.NewRedisSessionStore: logger is null")
+	}
+
+	return &store{
+		client: cache.Client,
+		logger: logger,
+	}, nil
+}
+
+func (s *store) Create(ctx context.Context, session *domain.Session, tokenHash string) error {
+	if session == nil {
+		return errors.New("store.Create: session is null")
+	}
+
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s store.Create, creating session %s for user: %s", txID, session.ID, session.UserID))
+
+	ttl := time.Until(session.ExpiresAt)
+	sessionKey := sessionKeyPrefix + session.ID
+	userKey := userSessionsKeyPrefix + session.UserID
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, sessionKey,
+			fieldUserID, session.UserID,
+			fieldTokenHash, tokenHash,
+			fieldCreatedAt, session.CreatedAt.UnixMilli(),
+			fieldExpiresAt, session.ExpiresAt.UnixMilli(),
+		)
+		pipe.PExpire(ctx, sessionKey, ttl)
+		pipe.SAdd(ctx, userKey, session.ID)
+		pipe.PExpire(ctx, userKey, ttl)
+		return nil
+	})
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s store.Create, error creating session: %v", txID, err))
+		return fmt.Errorf("store.Create: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s store.Create, successfully created session: %s", txID, session.ID))
+	return nil
+}
+
+func (s *store) Get(ctx context.Context, id string) (*domain.Session, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s store.Get, fetching session: %s", txID, id))
+
+	values, err := s.client.HGetAll(ctx, sessionKeyPrefix+id).Result()
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s store.Get, error fetching session: %v", txID, err))
+		return nil, fmt.Errorf("store.Get: %w", err)
+	}
+
+	if len(values) == 0 {
+		log.Debug(fmt.Sprintf("txID: %s store.Get, session %s not found", txID, id))
+		return nil, domain.ErrorSessionNotFound
+	}
+
+	session, err := toSession(id, values)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s store.Get, error decoding session: %v", txID, err))
+		return nil, fmt.Errorf("store.Get: %w", err)
+	}
+
+	return session, nil
+}
+
+func (s *store) Rotate(
+	ctx context.Context,
+	id string,
+	tokenHash string,
+	newTokenHash string,
+	expiresAt time.Time,
+) (*domain.Session, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s store.Rotate, rotating session: %s", txID, id))
+
+	session, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("store.Rotate: %w", err)
+	}
+
+	result, err := rotateScript.Run(ctx, s.client,
+		[]string{sessionKeyPrefix + id, userSessionsKeyPrefix + session.UserID},
+		tokenHash,
+		newTokenHash,
+		id,
+		expiresAt.UnixMilli(),
+		time.Until(expiresAt).Milliseconds(),
+	).Int()
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s store.Rotate, error rotating session: %v", txID, err))
+		return nil, fmt.Errorf("store.Rotate: %w", err)
+	}
+
+	switch result {
+	case 0:
+		return nil, domain.ErrorSessionNotFound
+	case -1:
+		log.Warn(fmt.Sprintf("txID: %s store.Rotate, refresh token reuse detected, session %s of user %s revoked", txID, id, session.UserID))
+		return nil, domain.ErrorRefreshTokenReused
+	}
+
+	session.ExpiresAt = expiresAt
+	log.Debug(fmt.Sprintf("txID: %s store.Rotate, successfully rotated session: %s", txID, id))
+	return session, nil
+}
+
+func (s *store) Revoke(ctx context.Context, id string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s store.Revoke, revoking session: %s", txID, id))
+
+	session, err := s.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("store.Revoke: %w", err)
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, sessionKeyPrefix+id)
+		pipe.SRem(ctx, userSessionsKeyPrefix+session.UserID, id)
+		return nil
+	})
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s store.Revoke, error revoking session: %v", txID, err))
+		return fmt.Errorf("store.Revoke: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s store.Revoke, successfully revoked session: %s", txID, id))
+	return nil
+}
+
+func (s *store) RevokeAll(ctx context.Context, userID string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s store.RevokeAll, revoking all sessions of user: %s", txID, userID))
+
+	userKey := userSessionsKeyPrefix + userID
+	ids, err := s.client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s store.RevokeAll, error fetching sessions: %v", txID, err))
+		return fmt.Errorf("store.RevokeAll: %w", err)
+	}
+
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, sessionKeyPrefix+id)
+	}
+	keys = append(keys, userKey)
+
+	if err = s.client.Del(ctx, keys...).Err(); err != nil {
+		log.Error(fmt.Sprintf("txID: %s store.RevokeAll, error revoking sessions: %v", txID, err))
+		return fmt.Errorf("store.RevokeAll: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s store.RevokeAll, successfully revoked %d sessions of user: %s", txID, len(ids), userID))
+	return nil
+}
+
+func toSession(id string, values map[string]string) (*domain.Session, error) {
+	createdAt, err := strconv.ParseInt(values[fieldCreatedAt], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", fieldCreatedAt, err)
+	}
+
+	expiresAt, err := strconv.ParseInt(values[fieldExpiresAt], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", fieldExpiresAt, err)
+	}
+
+	return &domain.Session{
+		ID:        id,
+		UserID:    values[fieldUserID],
+		CreatedAt: time.UnixMilli(createdAt),
+		ExpiresAt: time.UnixMilli(expiresAt),
+	}, nil
+}
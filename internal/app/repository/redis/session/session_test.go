@@ -0,0 +1,132 @@
+package session
+
+import (
+	"app/internal/domain"
+	"app/internal/pkg/cache"
+	"app/internal/pkg/logger"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...logger.Field) {}
+func (nopLogger) Info(string, ...logger.Field)  {}
+func (nopLogger) Warn(string, ...logger.Field)  {}
+func (nopLogger) Error(string, ...logger.Field) {}
+func (nopLogger) Fatal(string, ...logger.Field) {}
+
+func newStore(t *testing.T) *store {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	s, err := NewRedisSessionStore(&cache.Redis{Client: client}, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewRedisSessionStore: %v", err)
+	}
+	return s.(*store)
+}
+
+func TestRotate(t *testing.T) {
+	type step struct {
+		hash string
+		next string
+		err  error
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+		live  bool
+	}{
+		{
+			name:  "rotation",
+			steps: []step{{"h0", "h1", nil}, {"h1", "h2", nil}},
+			live:  true,
+		},
+		{
+			name: "reuse of a rotated token revokes the session",
+			steps: []step{
+				{"h0", "h1", nil},
+				{"h0", "h2", domain.ErrorRefreshTokenReused},
+				{"h1", "h2", domain.ErrorSessionNotFound},
+			},
+			live: false,
+		},
+		{
+			name:  "unknown token revokes the session",
+			steps: []step{{"forged", "h1", domain.ErrorRefreshTokenReused}},
+			live:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newStore(t)
+
+			now := time.Now().Truncate(time.Millisecond)
+			err := s.Create(ctx, &domain.Session{ID: "s1", UserID: "u1", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}, "h0")
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			for i, step := range tt.steps {
+				expiresAt := now.Add(2 * time.Hour)
+				rotated, err := s.Rotate(ctx, "s1", step.hash, step.next, expiresAt)
+				if !errors.Is(err, step.err) {
+					t.Fatalf("step %d: Rotate() error = %v, want %v", i, err, step.err)
+				}
+				if err == nil && !rotated.ExpiresAt.Equal(expiresAt) {
+					t.Fatalf("step %d: Rotate() expires at %s, want %s", i, rotated.ExpiresAt, expiresAt)
+				}
+			}
+
+			_, err = s.Get(ctx, "s1")
+			if live := err == nil; live != tt.live {
+				t.Fatalf("Get() error = %v, want live %v", err, tt.live)
+			}
+		})
+	}
+}
+
+func TestRevokeAll(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+	now := time.Now()
+
+	for _, session := range []domain.Session{
+		{ID: "a1", UserID: "alice", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: "a2", UserID: "alice", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: "b1", UserID: "bob", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+	} {
+		if err := s.Create(ctx, &session, "h"); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	if err := s.RevokeAll(ctx, "alice"); err != nil {
+		t.Fatalf("RevokeAll: %v", err)
+	}
+
+	for _, tt := range []struct {
+		id   string
+		live bool
+	}{{"a1", false}, {"a2", false}, {"b1", true}} {
+		if _, err := s.Get(ctx, tt.id); (err == nil) != tt.live {
+			t.Fatalf("Get(%s) error = %v, want live %v", tt.id, err, tt.live)
+		}
+	}
+
+	if err := s.Revoke(ctx, "a1"); !errors.Is(err, domain.ErrorSessionNotFound) {
+		t.Fatalf("Revoke() of a revoked session error = %v, want %v", err, domain.ErrorSessionNotFound)
+	}
+}
@@ -0,0 +1,306 @@
+package user
+
+import (
+	userRepository "app/internal/app/repository/postgres/user"
+	"app/internal/domain"
+	"app/internal/pkg/cache"
+	"app/internal/pkg/config"
+	"app/internal/pkg/database"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/reqctx"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	userKeyPrefix = "user:"
+
+	// negativeValue marks a user known not to exist.
+	negativeValue = "-"
+
+	// tombstoneValue replaces a user that was just changed. While it lives,
+	// lookups go to the database and do not cache what they read, so that
+	// a lookup that read the old row before the change committed cannot
+	// write it back. It lives longer than a lookup takes.
+	tombstoneValue = "!"
+	tombstoneTTL   = 10 * time.Second
+
+	// unavailableCooldown is how long lookups skip Redis after it failed,
+	// so that an outage costs one timeout rather than one per request.
+	unavailableCooldown = 5 * time.Second
+)
+
+var _ userRepository.Repository = (*repository)(nil)
+
+// repository serves Get from Redis and reads through to the wrapped
+// repository on a miss. Every mutation replaces the cached user with a
+// tombstone once its transaction has committed. Password hashes are never
+// cached: users served from the cache have none, and authentication reads
+// the database through GetByLogin. When Redis fails, calls pass through to
+// the wrapped repository.
+type repository struct {
+	next        userRepository.Repository
+	client      *redis.Client
+	ttl         time.Duration
+	negativeTTL time.Duration
+	logger      logger.Interface
+
+	// downUntil is when lookups use Redis again, in Unix nanoseconds.
+	downUntil atomic.Int64
+}
+
+func NewCachedUserRepository(
+	next userRepository.Repository,
+	cache *cache.Redis,
+	cfg *config.Cache,
+	logger logger.Interface,
+) (userRepository.Repository, error) {
+	if next == nil {
+		return nil, errors.New("repository.NewCachedUserRepository: repository is null")
+	}
+
+	if cache == nil || cache.Client == nil {
+		return nil, errors.New("repository.NewCachedUserRepository: cache is null")
+	}
+
+	if cfg == nil || cfg.UserTTL <= 0 {
+		return nil, errors.New("repository.NewCachedUserRepository: cfg is null or has no user ttl")
+	}
+
+	if logger == nil {
+		return nil, errors.New("repository.NewCachedUserRepository: logger is null")
+	}
+
+	return &repository{
+		next:        next,
+		client:      cache.Client,
+		ttl:         cfg.UserTTL,
+		negativeTTL: cfg.NegativeTTL,
+		logger:      logger,
+	}, nil
+}
+
+// cachedUser is how a user is stored in Redis, without the password hash.
+type cachedUser struct {
+	Uuid          string     `json:"uuid"`
+	Login         string     `json:"login"`
+	Email         string     `json:"email"`
+	EmailVerified bool       `json:"email_verified"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+	Version       int64      `json:"version"`
+}
+
+// Get reads the user from Redis. Inside a transaction it goes to the
+// database, which may hold changes not committed yet.
+func (r *repository) Get(ctx context.Context, uuid string) (*domain.User, error) {
+	if database.InTransaction(ctx) || !r.available() {
+		return r.next.Get(ctx, uuid)
+	}
+
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, r.logger)
+	key := userKeyPrefix + uuid
+
+	value, err := r.client.Get(ctx, key).Result()
+	switch {
+	case errors.Is(err, redis.Nil):
+	case err != nil:
+		r.fail(ctx, "Get", err)
+		return r.next.Get(ctx, uuid)
+	case value == tombstoneValue:
+		log.Debug(fmt.Sprintf("txID: %s cache.Get, user with UUID: %s was just changed, not caching it", txID, uuid))
+		return r.next.Get(ctx, uuid)
+	case value == negativeValue:
+		log.Debug(fmt.Sprintf("txID: %s cache.Get, user with UUID: %s is cached as missing", txID, uuid))
+		return nil, nil
+	default:
+		user, err := decode(value)
+		if err == nil {
+			log.Debug(fmt.Sprintf("txID: %s cache.Get, cache hit for user with UUID: %s", txID, uuid))
+			return user, nil
+		}
+		log.Warn(fmt.Sprintf("txID: %s cache.Get, replacing undecodable entry for user %s: %v", txID, uuid, err))
+		if err = r.client.Del(ctx, key).Err(); err != nil {
+			r.fail(ctx, "Get", err)
+			return r.next.Get(ctx, uuid)
+		}
+	}
+
+	user, err := r.next.Get(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store(ctx, key, user)
+	return user, nil
+}
+
+func (r *repository) Create(ctx context.Context, user *domain.User) (*domain.User, error) {
+	created, err := r.next.Create(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// A lookup made before the user existed may have been cached.
+	r.invalidate(ctx, created.Uuid)
+	return created, nil
+}
+
+func (r *repository) Delete(ctx context.Context, uuid string) (*domain.User, error) {
+	deleted, err := r.next.Delete(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidate(ctx, uuid)
+	return deleted, nil
+}
+
+func (r *repository) UpdatePassword(ctx context.Context, uuid string, password string) error {
+	if err := r.next.UpdatePassword(ctx, uuid, password); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, uuid)
+	return nil
+}
+
+func (r *repository) Update(ctx context.Context, user *domain.User, version int64) (*domain.User, error) {
+	updated, err := r.next.Update(ctx, user, version)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidate(ctx, user.Uuid)
+	return updated, nil
+}
+
+func (r *repository) Restore(ctx context.Context, uuid string) (*domain.User, error) {
+	restored, err := r.next.Restore(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidate(ctx, uuid)
+	return restored, nil
+}
+
+func (r *repository) VerifyEmail(ctx context.Context, uuid string) (*domain.User, error) {
+	verified, err := r.next.VerifyEmail(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidate(ctx, uuid)
+	return verified, nil
+}
+
+func (r *repository) GetByLogin(ctx context.Context, login string) (*domain.User, error) {
+	return r.next.GetByLogin(ctx, login)
+}
+
+func (r *repository) List(ctx context.Context, filter domain.UserFilter) (*domain.UserPage, error) {
+	return r.next.List(ctx, filter)
+}
+
+// Purge only removes users that were soft deleted, which Get never cached
+// as existing.
+func (r *repository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return r.next.Purge(ctx, deletedBefore)
+}
+
+func (r *repository) store(ctx context.Context, key string, user *domain.User) {
+	value, ttl := negativeValue, r.negativeTTL
+	if user != nil {
+		encoded, err := encode(user)
+		if err != nil {
+			r.logger.Warn(fmt.Sprintf("txID: %s cache.Get, error encoding user: %v", reqctx.RequestID(ctx), err))
+			return
+		}
+		value, ttl = encoded, r.ttl
+	}
+
+	if ttl <= 0 {
+		return
+	}
+
+	// Only a missing entry is filled in: a tombstone set since the miss
+	// means the user read may already be stale.
+	if err := r.client.SetNX(ctx, key, value, ttl).Err(); err != nil {
+		r.fail(ctx, "Get", err)
+	}
+}
+
+// invalidate replaces the cached user with a tombstone once the
+// transaction in ctx, if any, has committed, so that a concurrent Get cannot
+// cache the old row again. It is tried even while Redis is considered down;
+// an entry it fails to replace lives until its TTL.
+func (r *repository) invalidate(ctx context.Context, uuid string) {
+	database.AfterCommit(ctx, func(ctx context.Context) {
+		// The caller hanging up must not leave the entry stale.
+		ctx = context.WithoutCancel(ctx)
+		if err := r.client.Set(ctx, userKeyPrefix+uuid, tombstoneValue, tombstoneTTL).Err(); err != nil {
+			r.logger.Warn(fmt.Sprintf("txID: %s cache.invalidate, error dropping user %s, it may be stale for up to %s: %v",
+				reqctx.RequestID(ctx), uuid, r.ttl, err))
+		}
+	})
+}
+
+func (r *repository) available() bool {
+	return time.Now().UnixNano() >= r.downUntil.Load()
+}
+
+// fail makes lookups skip Redis for a while. Only the first failure after
+// Redis was available is logged.
+func (r *repository) fail(ctx context.Context, method string, err error) {
+	now := time.Now()
+	if r.downUntil.Swap(now.Add(unavailableCooldown).UnixNano()) > now.UnixNano() {
+		return
+	}
+
+	r.logger.Warn(fmt.Sprintf("txID: %s cache.%s, redis is unavailable, reading users from the database for %s: %v",
+		reqctx.RequestID(ctx), method, unavailableCooldown, err))
+}
+
+func encode(user *domain.User) (string, error) {
+	value, err := json.Marshal(cachedUser{
+		Uuid:          user.Uuid,
+		Login:         user.Login,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		DeletedAt:     user.DeletedAt,
+		Version:       user.Version,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+func decode(value string) (*domain.User, error) {
+	var cached cachedUser
+	if err := json.Unmarshal([]byte(value), &cached); err != nil {
+		return nil, err
+	}
+
+	return &domain.User{
+		Uuid:          cached.Uuid,
+		Login:         cached.Login,
+		Email:         cached.Email,
+		EmailVerified: cached.EmailVerified,
+		CreatedAt:     cached.CreatedAt,
+		UpdatedAt:     cached.UpdatedAt,
+		DeletedAt:     cached.DeletedAt,
+		Version:       cached.Version,
+	}, nil
+}
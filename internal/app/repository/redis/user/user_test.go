@@ -0,0 +1,163 @@
+package user
+
+import (
+	userRepository "app/internal/app/repository/postgres/user"
+	"app/internal/domain"
+	"app/internal/pkg/cache"
+	"app/internal/pkg/config"
+	"app/internal/pkg/logger"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...logger.Field) {}
+func (nopLogger) Info(string, ...logger.Field)  {}
+func (nopLogger) Warn(string, ...logger.Field)  {}
+func (nopLogger) Error(string, ...logger.Field) {}
+func (nopLogger) Fatal(string, ...logger.Field) {}
+
+// store serves users from a map and counts the lookups by UUID; methods
+// the cache only passes through are not implemented.
+type store struct {
+	userRepository.Repository
+	users map[string]*domain.User
+	gets  int
+}
+
+func (s *store) Get(ctx context.Context, uuid string) (*domain.User, error) {
+	s.gets++
+	u, ok := s.users[uuid]
+	if !ok {
+		return nil, nil
+	}
+	c := *u
+	return &c, nil
+}
+
+func (s *store) Update(ctx context.Context, u *domain.User, version int64) (*domain.User, error) {
+	s.users[u.Uuid] = u
+	return u, nil
+}
+
+const uuid = "6f1c2d1e-7a4b-4c55-9a0e-2f1f0c6d9b11"
+
+func newRepository(t *testing.T) (*repository, *store, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	db := &store{users: map[string]*domain.User{
+		uuid: {Uuid: uuid, Login: "alice", Password: "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$a2V5", CreatedAt: time.Now(), Version: 1},
+	}}
+
+	r, err := NewCachedUserRepository(db, &cache.Redis{Client: client}, &config.Cache{UserTTL: time.Minute, NegativeTTL: time.Second}, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewCachedUserRepository: %v", err)
+	}
+	return r.(*repository), db, server
+}
+
+func TestGetCachesWithoutPassword(t *testing.T) {
+	ctx := context.Background()
+	r, db, server := newRepository(t)
+
+	for i := 0; i < 2; i++ {
+		u, err := r.Get(ctx, uuid)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if u.Login != "alice" {
+			t.Fatalf("Get() login = %q, want alice", u.Login)
+		}
+	}
+
+	if db.gets != 1 {
+		t.Fatalf("database read %d times, want 1", db.gets)
+	}
+
+	cached, err := server.Get(userKeyPrefix + uuid)
+	if err != nil {
+		t.Fatalf("cached entry: %v", err)
+	}
+	if strings.Contains(cached, "argon2id") {
+		t.Fatalf("cached entry %s holds the password hash", cached)
+	}
+}
+
+func TestGetCachesMissingUsers(t *testing.T) {
+	ctx := context.Background()
+	r, db, server := newRepository(t)
+
+	const unknown = "00000000-0000-4000-8000-000000000000"
+	for i := 0; i < 2; i++ {
+		if u, err := r.Get(ctx, unknown); err != nil || u != nil {
+			t.Fatalf("Get() = %v, %v, want nil, nil", u, err)
+		}
+	}
+	if db.gets != 1 {
+		t.Fatalf("database read %d times, want 1", db.gets)
+	}
+
+	if ttl := server.TTL(userKeyPrefix + unknown); ttl != time.Second {
+		t.Fatalf("missing user cached for %s, want the negative TTL", ttl)
+	}
+}
+
+func TestChangeLeavesTombstone(t *testing.T) {
+	ctx := context.Background()
+	r, db, server := newRepository(t)
+
+	if _, err := r.Get(ctx, uuid); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if _, err := r.Update(ctx, &domain.User{Uuid: uuid, Login: "alicia", Version: 2}, 1); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	// While the tombstone lives, lookups read the database and cache nothing.
+	for i := 0; i < 2; i++ {
+		u, err := r.Get(ctx, uuid)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if u.Login != "alicia" {
+			t.Fatalf("Get() login = %q, want the updated alicia", u.Login)
+		}
+	}
+	if db.gets != 3 {
+		t.Fatalf("database read %d times, want 3", db.gets)
+	}
+
+	server.FastForward(tombstoneTTL)
+	if _, err := r.Get(ctx, uuid); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if cached, _ := server.Get(userKeyPrefix + uuid); !strings.Contains(cached, "alicia") {
+		t.Fatalf("cached entry after the tombstone = %q, want the updated user", cached)
+	}
+}
+
+func TestGetWithoutRedis(t *testing.T) {
+	ctx := context.Background()
+	r, db, server := newRepository(t)
+	server.Close()
+
+	for i := 0; i < 2; i++ {
+		if u, err := r.Get(ctx, uuid); err != nil || u == nil {
+			t.Fatalf("Get() = %v, %v, want the user from the database", u, err)
+		}
+	}
+	if db.gets != 2 {
+		t.Fatalf("database read %d times, want 2", db.gets)
+	}
+}
@@ -0,0 +1,18 @@
+package session
+
+import (
+	"app/internal/domain"
+	"context"
+	"time"
+)
+
+// Store keeps refresh sessions. Only a hash of the current refresh token is
+// stored; presenting any other token of a known session is treated as reuse
+// of a rotated token and revokes the whole session.
+type Store interface {
+	Create(ctx context.Context, session *domain.Session, tokenHash string) error
+	Get(ctx context.Context, id string) (*domain.Session, error)
+	Rotate(ctx context.Context, id string, tokenHash string, newTokenHash string, expiresAt time.Time) (*domain.Session, error)
+	Revoke(ctx context.Context, id string) error
+	RevokeAll(ctx context.Context, userID string) error
+}
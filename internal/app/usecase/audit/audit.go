@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"app/internal/app/repository/postgres/audit"
+	"app/internal/domain"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/reqctx"
+	"context"
+	"errors"
+	"fmt"
+)
+
+type Service interface {
+	List(ctx context.Context, filter domain.AuditFilter) (*domain.AuditPage, error)
+}
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+var _ Service = (*service)(nil)
+
+type service struct {
+	repository audit.Repository
+	logger     logger.Interface
+}
+
+func NewAuditService(repository audit.Repository, logger logger.Interface) (Service, error) {
+	if repository == nil {
+		return nil, errors.New("service.NewAuditService: repository is null")
+	}
+
+	if logger == nil {
+		return nil, errors.New("service.NewAuditService: logger is null")
+	}
+
+	return &service{
+		repository: repository,
+		logger:     logger,
+	}, nil
+}
+
+func (s *service) List(ctx context.Context, filter domain.AuditFilter) (*domain.AuditPage, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s service.List, listing audit entries", txID))
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	} else if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+
+	page, err := s.repository.List(ctx, filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrorInvalidCursor) {
+			return nil, err
+		}
+		log.Error(fmt.Sprintf("txID: %s service.List, error listing audit entries: %v", txID, err))
+		return nil, fmt.Errorf("service.List: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s service.List, successfully listed %d audit entries", txID, len(page.Entries)))
+	return page, nil
+}
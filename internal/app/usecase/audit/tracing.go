@@ -0,0 +1,35 @@
+package audit
+
+import (
+	"app/internal/domain"
+	"app/internal/pkg/telemetry"
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "app/internal/app/usecase/audit"
+
+// tracing wraps a Service in a span per call.
+type tracing struct {
+	next   Service
+	tracer trace.Tracer
+}
+
+var _ Service = (*tracing)(nil)
+
+func NewTracingService(next Service) (Service, error) {
+	if next == nil {
+		return nil, errors.New("service.NewTracingService: service is null")
+	}
+
+	return &tracing{next: next, tracer: telemetry.Tracer(tracerName)}, nil
+}
+
+func (t *tracing) List(ctx context.Context, filter domain.AuditFilter) (_ *domain.AuditPage, err error) {
+	ctx, span := t.tracer.Start(ctx, "audit.List")
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.List(ctx, filter)
+}
@@ -0,0 +1,408 @@
+package auth
+
+import (
+	"app/internal/app/repository/postgres/reset"
+	"app/internal/app/repository/session"
+	"app/internal/app/usecase/user"
+	"app/internal/domain"
+	"app/internal/pkg/config"
+	"app/internal/pkg/database"
+	"app/internal/pkg/eventbus"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/mailer"
+	"app/internal/pkg/reqctx"
+	"app/internal/pkg/token"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	refreshSecretLength = 32
+	resetSecretLength   = 32
+)
+
+type Service interface {
+	Login(ctx context.Context, login string, password string) (*domain.Tokens, error)
+	Refresh(ctx context.Context, refreshToken string) (*domain.Tokens, error)
+	Logout(ctx context.Context, refreshToken string, all bool) error
+	ForgotPassword(ctx context.Context, login string) error
+	ResetPassword(ctx context.Context, resetToken string, password string) error
+}
+
+var _ Service = (*service)(nil)
+
+type service struct {
+	users      user.Service
+	tokens     token.Interface
+	sessions   session.Store
+	resets     reset.Repository
+	mailer     mailer.Interface
+	tx         database.Transactor
+	events     eventbus.Publisher
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	resetTTL   time.Duration
+	resetURL   string
+	logger     logger.Interface
+}
+
+func NewAuthService(
+	users user.Service,
+	tokens token.Interface,
+	sessions session.Store,
+	resets reset.Repository,
+	mailer mailer.Interface,
+	tx database.Transactor,
+	bus *eventbus.Bus,
+	cfg *config.Auth,
+	logger logger.Interface,
+) (Service, error) {
+	if users == nil {
+		return nil, errors.New("service.NewAuthService: user service is null")
+	}
+
+	if tokens == nil {
+		return nil, errors.New("service.NewAuthService: tokens is null")
+	}
+
+	if sessions == nil {
+		return nil, errors.New("service.NewAuthService: sessions is null")
+	}
+
+	if resets == nil {
+		return nil, errors.New("service.NewAuthService: resets is null")
+	}
+
+	if mailer == nil {
+		return nil, errors.New("service.NewAuthService: mailer is null")
+	}
+
+	if tx == nil {
+		return nil, errors.New("service.NewAuthService: transactor is null")
+	}
+
+	if bus == nil {
+		return nil, errors.New("service.NewAuthService: bus is null")
+	}
+
+	if cfg == nil || cfg.AccessTokenTTL <= 0 || cfg.RefreshTokenTTL <= 0 || cfg.PasswordResetTTL <= 0 {
+		return nil, errors.New("service.NewAuthService: token ttls must be positive")
+	}
+
+	if logger == nil {
+		return nil, errors.New("service.NewAuthService: logger is null")
+	}
+
+	s := &service{
+		users:      users,
+		tokens:     tokens,
+		sessions:   sessions,
+		resets:     resets,
+		mailer:     mailer,
+		tx:         tx,
+		events:     bus,
+		accessTTL:  cfg.AccessTokenTTL,
+		refreshTTL: cfg.RefreshTokenTTL,
+		resetTTL:   cfg.PasswordResetTTL,
+		resetURL:   cfg.PasswordResetURL,
+		logger:     logger,
+	}
+
+	eventbus.Subscribe(bus, "auth.sendPasswordReset", s.onPasswordResetRequested, eventbus.Async())
+
+	return s, nil
+}
+
+func (s *service) Login(ctx context.Context, login string, password string) (*domain.Tokens, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s service.Login, logging in user", txID))
+
+	u, err := s.users.Authenticate(ctx, login, password)
+	if err != nil {
+		return nil, fmt.Errorf("service.Login: %w", err)
+	}
+
+	now := time.Now()
+	sess := &domain.Session{
+		ID:        uuid.NewString(),
+		UserID:    u.Uuid,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.refreshTTL),
+	}
+
+	refreshToken, tokenHash, err := newRefreshToken(sess.ID)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.Login, error generating refresh token: %v", txID, err))
+		return nil, fmt.Errorf("service.Login: %w", err)
+	}
+
+	if err = s.sessions.Create(ctx, sess, tokenHash); err != nil {
+		return nil, fmt.Errorf("service.Login: %w", err)
+	}
+
+	tokens, err := s.issue(sess, refreshToken)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.Login, error issuing access token: %v", txID, err))
+		return nil, fmt.Errorf("service.Login: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s service.Login, successfully logged in user: %s", txID, u.Uuid))
+	return tokens, nil
+}
+
+func (s *service) Refresh(ctx context.Context, refreshToken string) (*domain.Tokens, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+
+	sessionID, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		log.Debug(fmt.Sprintf("txID: %s service.Refresh, malformed refresh token", txID))
+		return nil, domain.ErrorSessionNotFound
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s service.Refresh, refreshing session: %s", txID, sessionID))
+
+	newToken, newHash, err := newRefreshToken(sessionID)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.Refresh, error generating refresh token: %v", txID, err))
+		return nil, fmt.Errorf("service.Refresh: %w", err)
+	}
+
+	sess, err := s.sessions.Rotate(ctx, sessionID, hashToken(refreshToken), newHash, time.Now().Add(s.refreshTTL))
+	if err != nil {
+		return nil, fmt.Errorf("service.Refresh: %w", err)
+	}
+
+	if _, err = s.users.Get(ctx, sess.UserID); err != nil {
+		if errors.Is(err, domain.ErrorUserNotFound) {
+			log.Debug(fmt.Sprintf("txID: %s service.Refresh, user %s no longer exists, revoking sessions", txID, sess.UserID))
+			_ = s.sessions.RevokeAll(ctx, sess.UserID)
+			return nil, domain.ErrorSessionNotFound
+		}
+		return nil, fmt.Errorf("service.Refresh: %w", err)
+	}
+
+	tokens, err := s.issue(sess, newToken)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.Refresh, error issuing access token: %v", txID, err))
+		return nil, fmt.Errorf("service.Refresh: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s service.Refresh, successfully refreshed session: %s", txID, sessionID))
+	return tokens, nil
+}
+
+func (s *service) Logout(ctx context.Context, refreshToken string, all bool) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+
+	sessionID, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		log.Debug(fmt.Sprintf("txID: %s service.Logout, malformed refresh token", txID))
+		return domain.ErrorSessionNotFound
+	}
+
+	// Rotating onto the same hash proves possession of the current token
+	// without changing it; a stale token revokes the session as reuse.
+	tokenHash := hashToken(refreshToken)
+	sess, err := s.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("service.Logout: %w", err)
+	}
+
+	if _, err = s.sessions.Rotate(ctx, sessionID, tokenHash, tokenHash, sess.ExpiresAt); err != nil {
+		return fmt.Errorf("service.Logout: %w", err)
+	}
+
+	if all {
+		log.Debug(fmt.Sprintf("txID: %s service.Logout, revoking all sessions of user: %s", txID, sess.UserID))
+		err = s.sessions.RevokeAll(ctx, sess.UserID)
+	} else {
+		log.Debug(fmt.Sprintf("txID: %s service.Logout, revoking session: %s", txID, sessionID))
+		err = s.sessions.Revoke(ctx, sessionID)
+	}
+
+	if err != nil {
+		return fmt.Errorf("service.Logout: %w", err)
+	}
+
+	return nil
+}
+
+// ForgotPassword requests a single-use reset token to be mailed to the user.
+// The lookup and the mail happen in the background and unknown logins are
+// not reported, so that neither the answer nor its timing reveals whether
+// an account exists.
+func (s *service) ForgotPassword(ctx context.Context, login string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s service.ForgotPassword, password reset requested", txID))
+
+	if err := s.events.Publish(ctx, domain.PasswordResetRequested{Login: user.NormalizeLogin(login)}); err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.ForgotPassword, error queueing password reset: %v", txID, err))
+		return fmt.Errorf("service.ForgotPassword: %w", err)
+	}
+
+	return nil
+}
+
+// onPasswordResetRequested mails a reset token to the user with the login,
+// if there is one with an email address. Failures are only logged: the
+// caller has long been answered.
+func (s *service) onPasswordResetRequested(ctx context.Context, event domain.PasswordResetRequested) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+
+	u, err := s.users.GetByLogin(ctx, event.Login)
+	if errors.Is(err, domain.ErrorUserNotFound) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("service.onPasswordResetRequested: %w", err)
+	}
+
+	if u.Email == "" {
+		log.Info(fmt.Sprintf("txID: %s service.onPasswordResetRequested, user %s has no email address, no reset mail sent", txID, u.Uuid))
+		return nil
+	}
+
+	resetToken, err := newSecret(resetSecretLength)
+	if err != nil {
+		return fmt.Errorf("service.onPasswordResetRequested: error generating reset token: %w", err)
+	}
+
+	if err = s.resets.Create(ctx, u.Uuid, hashToken(resetToken), time.Now().Add(s.resetTTL)); err != nil {
+		if errors.Is(err, domain.ErrorUserNotFound) {
+			return nil
+		}
+		return fmt.Errorf("service.onPasswordResetRequested: %w", err)
+	}
+
+	msg := mailer.Message{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Body:    s.resetBody(resetToken),
+	}
+
+	if err = s.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("service.onPasswordResetRequested: error sending reset mail to user %s: %w", u.Uuid, err)
+	}
+
+	log.Info(fmt.Sprintf("txID: %s service.onPasswordResetRequested, sent password reset to user: %s", txID, u.Uuid))
+	return nil
+}
+
+// ResetPassword redeems a reset token, sets the new password and signs the
+// user out everywhere.
+func (s *service) ResetPassword(ctx context.Context, resetToken string, password string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s service.ResetPassword, resetting password", txID))
+
+	// The token is only spent if the password is actually changed.
+	var userID string
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if userID, err = s.resets.Consume(ctx, hashToken(resetToken)); err != nil {
+			return err
+		}
+
+		if err = s.users.SetPassword(ctx, userID, password); errors.Is(err, domain.ErrorUserNotFound) {
+			return domain.ErrorInvalidResetToken
+		}
+		return err
+	})
+
+	if err != nil {
+		if errors.Is(err, domain.ErrorInvalidResetToken) {
+			return domain.ErrorInvalidResetToken
+		}
+		return fmt.Errorf("service.ResetPassword: %w", err)
+	}
+
+	if err = s.sessions.RevokeAll(ctx, userID); err != nil {
+		log.Warn(fmt.Sprintf("txID: %s service.ResetPassword, error revoking sessions of user %s: %v", txID, userID, err))
+	}
+
+	log.Info(fmt.Sprintf("txID: %s service.ResetPassword, password of user %s was reset", txID, userID))
+	return nil
+}
+
+func (s *service) resetBody(resetToken string) string {
+	expires := fmt.Sprintf("It expires in %s and can be used once.", s.resetTTL)
+
+	link, err := url.Parse(s.resetURL)
+	if s.resetURL == "" || err != nil {
+		return fmt.Sprintf("Use this token to reset your password:\n\n%s\n\n%s\n", resetToken, expires)
+	}
+
+	query := link.Query()
+	query.Set("token", resetToken)
+	link.RawQuery = query.Encode()
+
+	return fmt.Sprintf("Follow this link to reset your password:\n\n%s\n\n%s\n", link, expires)
+}
+
+func (s *service) issue(sess *domain.Session, refreshToken string) (*domain.Tokens, error) {
+	accessToken, claims, err := s.tokens.Issue(sess.UserID, token.TypeAccess, s.accessTTL, token.WithSession(sess.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Tokens{
+		AccessToken:      accessToken,
+		ExpiresAt:        claims.ExpiresAtTime(),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: sess.ExpiresAt,
+	}, nil
+}
+
+// newRefreshToken returns an opaque "<session id>.<secret>" token together
+// with the hash that is persisted in place of it.
+func newRefreshToken(sessionID string) (string, string, error) {
+	secret, err := newSecret(refreshSecretLength)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken := sessionID + "." + secret
+	return refreshToken, hashToken(refreshToken), nil
+}
+
+func newSecret(length int) (string, error) {
+	secret := make([]byte, length)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func parseRefreshToken(refreshToken string) (string, bool) {
+	sessionID, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || secret == "" {
+		return "", false
+	}
+
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return "", false
+	}
+
+	return sessionID, true
+}
+
+// hashToken is what is persisted in place of an opaque token.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
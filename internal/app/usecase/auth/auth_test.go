@@ -0,0 +1,180 @@
+package auth
+
+import (
+	memorySession "app/internal/app/repository/memory/session"
+	"app/internal/app/usecase/user"
+	"app/internal/domain"
+	"app/internal/pkg/config"
+	"app/internal/pkg/eventbus"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/mailer"
+	"app/internal/pkg/token"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...logger.Field) {}
+func (nopLogger) Info(string, ...logger.Field)  {}
+func (nopLogger) Warn(string, ...logger.Field)  {}
+func (nopLogger) Error(string, ...logger.Field) {}
+func (nopLogger) Fatal(string, ...logger.Field) {}
+
+// users knows users by login and records the passwords set; methods the
+// reset flow does not use are not implemented.
+type users struct {
+	user.Service
+	mu        sync.Mutex
+	byLogin   map[string]*domain.User
+	passwords map[string]string
+}
+
+func (u *users) GetByLogin(ctx context.Context, login string) (*domain.User, error) {
+	found, ok := u.byLogin[login]
+	if !ok {
+		return nil, domain.ErrorUserNotFound
+	}
+	return found, nil
+}
+
+func (u *users) SetPassword(ctx context.Context, uuid string, password string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.passwords[uuid] = password
+	return nil
+}
+
+type resetToken struct {
+	userUUID  string
+	expiresAt time.Time
+}
+
+// memoryResets keeps reset tokens by hash until they are consumed.
+type memoryResets struct {
+	tokens map[string]resetToken
+}
+
+func (r *memoryResets) Create(ctx context.Context, userUUID string, tokenHash string, expiresAt time.Time) error {
+	r.tokens[tokenHash] = resetToken{userUUID: userUUID, expiresAt: expiresAt}
+	return nil
+}
+
+func (r *memoryResets) Consume(ctx context.Context, tokenHash string) (string, error) {
+	t, ok := r.tokens[tokenHash]
+	if !ok || !time.Now().Before(t.expiresAt) {
+		return "", domain.ErrorInvalidResetToken
+	}
+	delete(r.tokens, tokenHash)
+	return t.userUUID, nil
+}
+
+// noTx runs fn without a transaction.
+type noTx struct{}
+
+func (noTx) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type memoryMailer struct {
+	sent []mailer.Message
+}
+
+func (m *memoryMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+
+	tokens, err := token.New(&config.Auth{Algorithm: token.HS256, HMACSecret: strings.Repeat("s", 32)})
+	if err != nil {
+		t.Fatalf("token.New: %v", err)
+	}
+
+	sessions, err := memorySession.NewMemorySessionStore(nopLogger{})
+	if err != nil {
+		t.Fatalf("NewMemorySessionStore: %v", err)
+	}
+
+	bus, err := eventbus.New(&config.EventBus{Workers: 1, QueueSize: 1}, nopLogger{})
+	if err != nil {
+		t.Fatalf("eventbus.New: %v", err)
+	}
+
+	accounts := &users{
+		byLogin: map[string]*domain.User{
+			"alice": {Uuid: "u1", Login: "alice", Email: "alice@example.com"},
+			"bob":   {Uuid: "u2", Login: "bob"},
+		},
+		passwords: make(map[string]string),
+	}
+	resets := &memoryResets{tokens: make(map[string]resetToken)}
+	mail := &memoryMailer{}
+
+	svc, err := NewAuthService(accounts, tokens, sessions, resets, mail, noTx{}, bus, &config.Auth{
+		AccessTokenTTL:   time.Minute,
+		RefreshTokenTTL:  time.Hour,
+		PasswordResetTTL: time.Hour,
+	}, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewAuthService: %v", err)
+	}
+	s := svc.(*service)
+
+	for _, login := range []string{"nobody", "bob"} {
+		if err = s.onPasswordResetRequested(ctx, domain.PasswordResetRequested{Login: login}); err != nil {
+			t.Fatalf("onPasswordResetRequested(%s): %v", login, err)
+		}
+	}
+	if len(mail.sent) != 0 || len(resets.tokens) != 0 {
+		t.Fatalf("users without an account or email address got %d mails and %d tokens, want none", len(mail.sent), len(resets.tokens))
+	}
+
+	if err = sessions.Create(ctx, &domain.Session{ID: "s1", UserID: "u1", ExpiresAt: time.Now().Add(time.Hour)}, "h"); err != nil {
+		t.Fatalf("Create session: %v", err)
+	}
+
+	if err = s.onPasswordResetRequested(ctx, domain.PasswordResetRequested{Login: "alice"}); err != nil {
+		t.Fatalf("onPasswordResetRequested: %v", err)
+	}
+	if len(mail.sent) != 1 || mail.sent[0].To != "alice@example.com" {
+		t.Fatalf("sent %v, want one mail to alice@example.com", mail.sent)
+	}
+
+	// Without a reset URL the token stands on a line of its own.
+	lines := strings.Split(mail.sent[0].Body, "\n")
+	if len(lines) < 3 {
+		t.Fatalf("mail body %q has no token", mail.sent[0].Body)
+	}
+	secret := lines[2]
+	for hash := range resets.tokens {
+		if hash == secret {
+			t.Fatalf("reset token is stored as sent, want it hashed")
+		}
+	}
+
+	if err = s.ResetPassword(ctx, "forged", "NewSecret123"); !errors.Is(err, domain.ErrorInvalidResetToken) {
+		t.Fatalf("ResetPassword() with a forged token error = %v, want %v", err, domain.ErrorInvalidResetToken)
+	}
+
+	if err = s.ResetPassword(ctx, secret, "NewSecret123"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if got := accounts.passwords["u1"]; got != "NewSecret123" {
+		t.Fatalf("password set to %q, want %q", got, "NewSecret123")
+	}
+	if _, err = sessions.Get(ctx, "s1"); !errors.Is(err, domain.ErrorSessionNotFound) {
+		t.Fatalf("session after reset error = %v, want %v", err, domain.ErrorSessionNotFound)
+	}
+
+	if err = s.ResetPassword(ctx, secret, "Another123"); !errors.Is(err, domain.ErrorInvalidResetToken) {
+		t.Fatalf("ResetPassword() with a spent token error = %v, want %v", err, domain.ErrorInvalidResetToken)
+	}
+}
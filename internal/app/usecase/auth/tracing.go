@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"app/internal/domain"
+	"app/internal/pkg/telemetry"
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "app/internal/app/usecase/auth"
+
+// tracing wraps a Service in a span per call. Credentials and tokens are
+// never recorded.
+type tracing struct {
+	next   Service
+	tracer trace.Tracer
+}
+
+var _ Service = (*tracing)(nil)
+
+func NewTracingService(next Service) (Service, error) {
+	if next == nil {
+		return nil, errors.New("service.NewTracingService: service is null")
+	}
+
+	return &tracing{next: next, tracer: telemetry.Tracer(tracerName)}, nil
+}
+
+func (t *tracing) Login(ctx context.Context, login string, password string) (_ *domain.Tokens, err error) {
+	ctx, span := t.tracer.Start(ctx, "auth.Login")
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.Login(ctx, login, password)
+}
+
+func (t *tracing) Refresh(ctx context.Context, refreshToken string) (_ *domain.Tokens, err error) {
+	ctx, span := t.tracer.Start(ctx, "auth.Refresh")
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.Refresh(ctx, refreshToken)
+}
+
+func (t *tracing) Logout(ctx context.Context, refreshToken string, all bool) (err error) {
+	ctx, span := t.tracer.Start(ctx, "auth.Logout", trace.WithAttributes(attribute.Bool("auth.logout.all", all)))
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.Logout(ctx, refreshToken, all)
+}
+
+func (t *tracing) ForgotPassword(ctx context.Context, login string) (err error) {
+	ctx, span := t.tracer.Start(ctx, "auth.ForgotPassword")
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.ForgotPassword(ctx, login)
+}
+
+func (t *tracing) ResetPassword(ctx context.Context, resetToken string, password string) (err error) {
+	ctx, span := t.tracer.Start(ctx, "auth.ResetPassword")
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.ResetPassword(ctx, resetToken, password)
+}
@@ -0,0 +1,191 @@
+package lockout
+
+import (
+	"app/internal/app/repository/attempt"
+	"app/internal/domain"
+	"app/internal/pkg/config"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/reqctx"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	loginKeyPrefix = "login:"
+	ipKeyPrefix    = "ip:"
+)
+
+// Service throttles authentication attempts. Begin is called before the
+// password is verified and counts the attempt as failed; Succeed gives it
+// back once the password was right, and Abort when the attempt could not be
+// decided, e.g. because the database failed. All take the normalised login
+// and the client address (which may be empty when unknown).
+type Service interface {
+	Begin(ctx context.Context, login string, ip string) error
+	Succeed(ctx context.Context, login string, ip string) error
+	Abort(ctx context.Context, login string, ip string) error
+	Unlock(ctx context.Context, login string) error
+}
+
+var _ Service = (*service)(nil)
+
+type service struct {
+	store    attempt.Store
+	login    attempt.Policy
+	ip       attempt.Policy
+	failOpen bool
+	now      func() time.Time
+	logger   logger.Interface
+}
+
+func NewLockoutService(store attempt.Store, cfg *config.Lockout, logger logger.Interface) (Service, error) {
+	if store == nil {
+		return nil, errors.New("service.NewLockoutService: store is null")
+	}
+
+	if cfg == nil || cfg.Window <= 0 || cfg.Duration <= 0 {
+		return nil, errors.New("service.NewLockoutService: window and duration must be positive")
+	}
+
+	if logger == nil {
+		return nil, errors.New("service.NewLockoutService: logger is null")
+	}
+
+	return &service{
+		store: store,
+		login: attempt.Policy{
+			Window:       cfg.Window,
+			Threshold:    int64(cfg.Threshold),
+			Duration:     cfg.Duration,
+			BackoffAfter: int64(cfg.BackoffAfter),
+			BackoffBase:  cfg.BackoffBase,
+			BackoffMax:   cfg.BackoffMax,
+		},
+		ip: attempt.Policy{
+			Window:    cfg.Window,
+			Threshold: int64(cfg.IPThreshold),
+			Duration:  cfg.Duration,
+		},
+		failOpen: cfg.FailOpen,
+		now:      time.Now,
+		logger:   logger,
+	}, nil
+}
+
+// Begin refuses the attempt with a RetryError while the address or the
+// login is locked or backing off. The address is taken first, so that an
+// attacker spraying many logins from one address is stopped there. If the
+// counters cannot be reached the attempt is let through or refused as
+// configured.
+func (s *service) Begin(ctx context.Context, login string, ip string) error {
+	now := s.now()
+
+	if ip != "" {
+		if err := s.take(ctx, ipKeyPrefix+ip, now, s.ip, "client address locked", logger.NewField("ip", ip)); err != nil {
+			return err
+		}
+	}
+
+	err := s.take(ctx, loginKeyPrefix+login, now, s.login, "account locked",
+		logger.NewField("login", login), logger.NewField("ip", ip))
+	if err != nil && ip != "" {
+		// The address counted an attempt that is not going to be made.
+		if releaseErr := s.store.Release(ctx, ipKeyPrefix+ip); releaseErr != nil {
+			logger.WithContext(ctx, s.logger).Warn(fmt.Sprintf("txID: %s service.Begin, error releasing attempt: %v", reqctx.RequestID(ctx), releaseErr))
+		}
+	}
+
+	return err
+}
+
+// Succeed clears the counter of the login and gives the attempt back to the
+// address. The address counter is not cleared, or an attacker owning one
+// account could reset it between guesses.
+func (s *service) Succeed(ctx context.Context, login string, ip string) error {
+	if err := s.store.Reset(ctx, loginKeyPrefix+login); err != nil {
+		return fmt.Errorf("service.Succeed: %w", err)
+	}
+
+	if ip != "" {
+		if err := s.store.Release(ctx, ipKeyPrefix+ip); err != nil {
+			return fmt.Errorf("service.Succeed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *service) Abort(ctx context.Context, login string, ip string) error {
+	if err := s.store.Release(ctx, loginKeyPrefix+login); err != nil {
+		return fmt.Errorf("service.Abort: %w", err)
+	}
+
+	if ip != "" {
+		if err := s.store.Release(ctx, ipKeyPrefix+ip); err != nil {
+			return fmt.Errorf("service.Abort: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *service) Unlock(ctx context.Context, login string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+
+	if err := s.store.Reset(ctx, loginKeyPrefix+login); err != nil {
+		return fmt.Errorf("service.Unlock: %w", err)
+	}
+
+	log.Info(fmt.Sprintf("txID: %s service.Unlock, account unlocked", txID),
+		logger.NewField("event", "lockout.unlocked"),
+		logger.NewField("tx", txID),
+		logger.NewField("login", login),
+	)
+
+	return nil
+}
+
+func (s *service) take(
+	ctx context.Context,
+	key string,
+	now time.Time,
+	policy attempt.Policy,
+	message string,
+	fields ...logger.Field,
+) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+
+	outcome, err := s.store.Take(ctx, key, now, policy)
+	if err != nil {
+		if s.failOpen {
+			log.Error(fmt.Sprintf("txID: %s service.Begin, lockout store failed, letting the attempt through: %v", txID, err),
+				logger.NewField("event", "lockout.unavailable"))
+			return nil
+		}
+
+		log.Error(fmt.Sprintf("txID: %s service.Begin, lockout store failed, refusing the attempt: %v", txID, err),
+			logger.NewField("event", "lockout.unavailable"))
+		return domain.ErrorLockoutUnavailable
+	}
+
+	if outcome.Locked {
+		fields = append([]logger.Field{
+			logger.NewField("event", "lockout.locked"),
+			logger.NewField("tx", txID),
+			logger.NewField("failures", outcome.Failures),
+			logger.NewField("locked_until", now.Add(outcome.Wait).UTC().Format(time.RFC3339)),
+		}, fields...)
+
+		log.Warn(fmt.Sprintf("txID: %s service.Begin, %s after %d failed attempts", txID, message, outcome.Failures), fields...)
+	}
+
+	if outcome.Wait > 0 {
+		return domain.NewRetryError(domain.ErrorTooManyAttempts, outcome.Wait)
+	}
+
+	return nil
+}
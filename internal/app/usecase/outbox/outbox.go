@@ -0,0 +1,210 @@
+package outbox
+
+import (
+	"app/internal/app/repository/postgres/outbox"
+	"app/internal/domain"
+	"app/internal/pkg/config"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/publisher"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// batchTimeout bounds one batch, including the publishing. A batch is not
+// cut short by shutdown, so that events that were published get marked sent.
+// Claimed events are leased for as long, so that another relay only takes
+// them over once the batch has timed out.
+const batchTimeout = 30 * time.Second
+
+// purgeInterval is how often events sent longer than the retention ago are
+// deleted.
+const purgeInterval = time.Hour
+
+// Service relays events from the outbox to the publisher.
+type Service interface {
+	// Run relays until ctx is done.
+	Run(ctx context.Context)
+	// Relay delivers one batch of due events and returns how many were
+	// claimed.
+	Relay(ctx context.Context) (int, error)
+	// Purge deletes events sent longer than the retention ago and returns
+	// how many.
+	Purge(ctx context.Context) (int64, error)
+}
+
+var _ Service = (*service)(nil)
+
+type service struct {
+	repository   outbox.Repository
+	publisher    publisher.Interface
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+	backoffBase  time.Duration
+	backoffMax   time.Duration
+	retention    time.Duration
+	logger       logger.Interface
+	now          func() time.Time
+}
+
+func NewOutboxRelay(
+	repository outbox.Repository,
+	publisher publisher.Interface,
+	cfg *config.Outbox,
+	logger logger.Interface,
+) (Service, error) {
+	if repository == nil {
+		return nil, errors.New("service.NewOutboxRelay: repository is null")
+	}
+
+	if publisher == nil {
+		return nil, errors.New("service.NewOutboxRelay: publisher is null")
+	}
+
+	if cfg == nil {
+		return nil, errors.New("service.NewOutboxRelay: cfg is null")
+	}
+
+	if logger == nil {
+		return nil, errors.New("service.NewOutboxRelay: logger is null")
+	}
+
+	if cfg.PollInterval <= 0 || cfg.BatchSize <= 0 {
+		return nil, errors.New("service.NewOutboxRelay: poll interval and batch size must be positive")
+	}
+
+	if cfg.BackoffBase <= 0 || cfg.BackoffMax <= 0 {
+		return nil, errors.New("service.NewOutboxRelay: backoff base and max must be positive")
+	}
+
+	if cfg.Retention <= 0 {
+		return nil, errors.New("service.NewOutboxRelay: retention must be positive")
+	}
+
+	return &service{
+		repository:   repository,
+		publisher:    publisher,
+		pollInterval: cfg.PollInterval,
+		batchSize:    cfg.BatchSize,
+		maxAttempts:  cfg.MaxAttempts,
+		backoffBase:  cfg.BackoffBase,
+		backoffMax:   cfg.BackoffMax,
+		retention:    cfg.Retention,
+		logger:       logger,
+		now:          time.Now,
+	}, nil
+}
+
+func (s *service) Run(ctx context.Context) {
+	s.logger.Info("outbox relay started", logger.NewField("poll_interval", s.pollInterval.String()))
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	purge := time.NewTicker(purgeInterval)
+	defer purge.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("outbox relay stopped")
+			return
+		case <-purge.C:
+			purged, err := s.Purge(ctx)
+			if err != nil {
+				s.logger.Error(fmt.Sprintf("service.Run, error purging sent events: %v", err))
+				continue
+			}
+			s.logger.Debug(fmt.Sprintf("service.Run, purged %d sent events", purged))
+			continue
+		case <-ticker.C:
+		}
+
+		// Keep going while batches come back full, so that a backlog drains
+		// faster than one batch per interval.
+		for ctx.Err() == nil {
+			claimed, err := s.Relay(ctx)
+			if err != nil {
+				s.logger.Error(fmt.Sprintf("service.Run, error relaying outbox: %v", err))
+				break
+			}
+
+			if claimed < s.batchSize {
+				break
+			}
+		}
+	}
+}
+
+func (s *service) Relay(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), batchTimeout)
+	defer cancel()
+
+	claimed, err := s.repository.Dispatch(ctx, s.batchSize, batchTimeout, s.deliver)
+	if err != nil {
+		return 0, fmt.Errorf("service.Relay: %w", err)
+	}
+
+	return claimed, nil
+}
+
+func (s *service) Purge(ctx context.Context) (int64, error) {
+	purged, err := s.repository.Purge(ctx, s.now().Add(-s.retention))
+	if err != nil {
+		return 0, fmt.Errorf("service.Purge: %w", err)
+	}
+
+	return purged, nil
+}
+
+func (s *service) deliver(ctx context.Context, event *domain.Event) (*time.Time, error) {
+	err := s.publisher.Publish(ctx, publisher.Message{
+		ID:          strconv.FormatInt(event.ID, 10),
+		Type:        event.Type,
+		AggregateID: event.AggregateID,
+		Payload:     event.Payload,
+		OccurredAt:  event.CreatedAt,
+	})
+	if err == nil {
+		s.logger.Debug(fmt.Sprintf("service.deliver, published %s event %d", event.Type, event.ID))
+		return nil, nil
+	}
+
+	attempts := event.Attempts + 1
+	if s.maxAttempts > 0 && attempts >= s.maxAttempts {
+		s.logger.Error("outbox event abandoned",
+			logger.NewField("event_id", event.ID),
+			logger.NewField("event_type", event.Type),
+			logger.NewField("attempts", attempts),
+			logger.NewField("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	retryAt := s.now().Add(s.backoff(attempts))
+	s.logger.Warn("outbox event delivery failed",
+		logger.NewField("event_id", event.ID),
+		logger.NewField("event_type", event.Type),
+		logger.NewField("attempts", attempts),
+		logger.NewField("retry_at", retryAt.UTC().Format(time.RFC3339)),
+		logger.NewField("error", err.Error()),
+	)
+	return &retryAt, err
+}
+
+// backoff doubles the base delay per failed attempt, up to the maximum.
+func (s *service) backoff(attempts int) time.Duration {
+	delay := s.backoffBase
+	for i := 1; i < attempts && delay < s.backoffMax; i++ {
+		delay *= 2
+	}
+
+	if s.backoffMax > 0 && delay > s.backoffMax {
+		delay = s.backoffMax
+	}
+
+	return delay
+}
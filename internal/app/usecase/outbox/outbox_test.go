@@ -0,0 +1,121 @@
+package outbox
+
+import (
+	"app/internal/app/repository/postgres/outbox"
+	"app/internal/domain"
+	"app/internal/pkg/config"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/publisher"
+	"context"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...logger.Field) {}
+func (nopLogger) Info(string, ...logger.Field)  {}
+func (nopLogger) Warn(string, ...logger.Field)  {}
+func (nopLogger) Error(string, ...logger.Field) {}
+func (nopLogger) Fatal(string, ...logger.Field) {}
+
+// memoryOutbox holds one event and records the outcomes of its deliveries
+// like the repository does.
+type memoryOutbox struct {
+	event   domain.Event
+	due     *time.Time
+	sent    bool
+	lastErr string
+}
+
+func (m *memoryOutbox) Add(ctx context.Context, event *domain.Event) error {
+	return nil
+}
+
+func (m *memoryOutbox) Dispatch(ctx context.Context, limit int, lease time.Duration, deliver outbox.Deliver) (int, error) {
+	if m.due == nil {
+		return 0, nil
+	}
+
+	event := m.event
+	retryAt, err := deliver(ctx, &event)
+	m.event.Attempts++
+	if err == nil {
+		m.due, m.sent, m.lastErr = nil, true, ""
+	} else {
+		m.due, m.lastErr = retryAt, err.Error()
+	}
+	return 1, nil
+}
+
+func (m *memoryOutbox) Purge(ctx context.Context, sentBefore time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestRelay(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		failures int
+		// retries are the delays before each retry scheduled.
+		retries   []time.Duration
+		sent      bool
+		abandoned bool
+	}{
+		{name: "published", sent: true},
+		{name: "published after retries", failures: 2, retries: []time.Duration{time.Second, 2 * time.Second}, sent: true},
+		{name: "backoff is capped", failures: 3, retries: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, sent: true},
+		{name: "abandoned", failures: 4, retries: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, abandoned: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := publisher.NewMemory()
+			pub.Fail(tt.failures)
+			repository := &memoryOutbox{event: domain.Event{ID: 1, Type: "UserCreated"}, due: &start}
+
+			s, err := NewOutboxRelay(repository, pub, &config.Outbox{
+				PollInterval: time.Second,
+				BatchSize:    10,
+				MaxAttempts:  4,
+				BackoffBase:  time.Second,
+				BackoffMax:   3 * time.Second,
+				Retention:    time.Hour,
+			}, nopLogger{})
+			if err != nil {
+				t.Fatalf("NewOutboxRelay: %v", err)
+			}
+			relay := s.(*service)
+			relay.now = func() time.Time { return start }
+
+			var retries []time.Duration
+			for repository.due != nil {
+				if _, err = relay.Relay(context.Background()); err != nil {
+					t.Fatalf("Relay: %v", err)
+				}
+				if repository.due != nil {
+					retries = append(retries, repository.due.Sub(start))
+				}
+			}
+
+			if len(retries) != len(tt.retries) {
+				t.Fatalf("retries = %v, want %v", retries, tt.retries)
+			}
+			for i := range retries {
+				if retries[i] != tt.retries[i] {
+					t.Fatalf("retries = %v, want %v", retries, tt.retries)
+				}
+			}
+			if repository.sent != tt.sent {
+				t.Fatalf("sent = %v, want %v", repository.sent, tt.sent)
+			}
+			if abandoned := !repository.sent && repository.lastErr != ""; abandoned != tt.abandoned {
+				t.Fatalf("abandoned = %v, want %v", abandoned, tt.abandoned)
+			}
+			if messages := pub.Messages(); tt.sent && (len(messages) != 1 || messages[0].ID != "1") {
+				t.Fatalf("published %v, want event 1 once", messages)
+			}
+		})
+	}
+}
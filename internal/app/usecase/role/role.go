@@ -0,0 +1,175 @@
+package role
+
+import (
+	"app/internal/app/repository/postgres/role"
+	"app/internal/app/usecase/user"
+	"app/internal/domain"
+	"app/internal/pkg/config"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/reqctx"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+type Service interface {
+	List(ctx context.Context) ([]*domain.Role, error)
+	ListByUser(ctx context.Context, uuid string) ([]*domain.Role, error)
+	Assign(ctx context.Context, uuid string, role string) error
+	Revoke(ctx context.Context, uuid string, role string) error
+	Resolve(ctx context.Context, subject string) ([]string, []string, error)
+}
+
+var _ Service = (*service)(nil)
+
+type service struct {
+	repository role.Repository
+	users      user.Service
+	// admins always hold the admin role, so that a fresh deployment has
+	// someone able to hand out roles.
+	admins []string
+	logger logger.Interface
+}
+
+func NewRoleService(
+	repository role.Repository,
+	users user.Service,
+	cfg *config.Auth,
+	logger logger.Interface,
+) (Service, error) {
+	if repository == nil {
+		return nil, errors.New("service.NewRoleService: repository is null")
+	}
+
+	if users == nil {
+		return nil, errors.New("service.NewRoleService: user service is null")
+	}
+
+	if cfg == nil {
+		return nil, errors.New("service.NewRoleService: cfg is null")
+	}
+
+	if logger == nil {
+		return nil, errors.New("service.NewRoleService: logger is null")
+	}
+
+	return &service{
+		repository: repository,
+		users:      users,
+		admins:     cfg.Admins,
+		logger:     logger,
+	}, nil
+}
+
+func (s *service) List(ctx context.Context) ([]*domain.Role, error) {
+	roles, err := s.repository.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service.List: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (s *service) ListByUser(ctx context.Context, uuid string) ([]*domain.Role, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s service.ListByUser, listing roles of user with UUID: %s", txID, uuid))
+
+	if _, err := s.users.Get(ctx, uuid); err != nil {
+		return nil, err
+	}
+
+	roles, err := s.repository.ListByUser(ctx, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("service.ListByUser: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (s *service) Assign(ctx context.Context, uuid string, role string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Info(fmt.Sprintf("txID: %s service.Assign, assigning role %s to user with UUID: %s", txID, role, uuid))
+
+	if err := s.repository.Assign(ctx, uuid, role); err != nil {
+		if errors.Is(err, domain.ErrorUserNotFound) || errors.Is(err, domain.ErrorRoleNotFound) {
+			return err
+		}
+		return fmt.Errorf("service.Assign: %w", err)
+	}
+
+	return nil
+}
+
+func (s *service) Revoke(ctx context.Context, uuid string, role string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Info(fmt.Sprintf("txID: %s service.Revoke, revoking role %s from user with UUID: %s", txID, role, uuid))
+
+	if err := s.repository.Revoke(ctx, uuid, role); err != nil {
+		if errors.Is(err, domain.ErrorUserNotFound) || errors.Is(err, domain.ErrorRoleNotFound) {
+			return err
+		}
+		return fmt.Errorf("service.Revoke: %w", err)
+	}
+
+	return nil
+}
+
+// Resolve returns the names of the roles held by subject and the union of
+// the permissions they grant. Configured admins only get the admin role while
+// their user exists and is not deleted.
+func (s *service) Resolve(ctx context.Context, subject string) ([]string, []string, error) {
+	roles, err := s.repository.ListByUser(ctx, subject)
+	if err != nil {
+		return nil, nil, fmt.Errorf("service.Resolve: %w", err)
+	}
+
+	if slices.Contains(s.admins, subject) && !slices.ContainsFunc(roles, isAdmin) {
+		if _, err := s.users.Get(ctx, subject); errors.Is(err, domain.ErrorUserNotFound) {
+			return names(roles), permissions(roles), nil
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("service.Resolve: %w", err)
+		}
+
+		all, err := s.repository.List(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("service.Resolve: %w", err)
+		}
+
+		if i := slices.IndexFunc(all, isAdmin); i >= 0 {
+			roles = append(roles, all[i])
+		}
+	}
+
+	return names(roles), permissions(roles), nil
+}
+
+func names(roles []*domain.Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, r := range roles {
+		names = append(names, r.Name)
+	}
+
+	return names
+}
+
+// permissions returns the union of the permissions granted by roles.
+func permissions(roles []*domain.Role) []string {
+	permissions := make([]string, 0)
+	for _, r := range roles {
+		for _, permission := range r.Permissions {
+			if !slices.Contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+
+	return permissions
+}
+
+func isAdmin(r *domain.Role) bool {
+	return r.Name == domain.RoleAdmin
+}
@@ -0,0 +1,144 @@
+package role
+
+import (
+	"app/internal/app/usecase/user"
+	"app/internal/domain"
+	"app/internal/pkg/config"
+	"app/internal/pkg/logger"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...logger.Field) {}
+func (nopLogger) Info(string, ...logger.Field)  {}
+func (nopLogger) Warn(string, ...logger.Field)  {}
+func (nopLogger) Error(string, ...logger.Field) {}
+func (nopLogger) Fatal(string, ...logger.Field) {}
+
+var (
+	admin  = &domain.Role{Name: domain.RoleAdmin, Permissions: []string{domain.PermissionUsersAdmin, domain.PermissionRolesManage}}
+	reader = &domain.Role{Name: "reader", Permissions: []string{domain.PermissionUsersRead}}
+	writer = &domain.Role{Name: "writer", Permissions: []string{domain.PermissionUsersRead, domain.PermissionUsersWrite}}
+)
+
+// memoryRepository holds the roles assigned to each user.
+type memoryRepository struct {
+	assigned map[string][]*domain.Role
+}
+
+func (r *memoryRepository) List(ctx context.Context) ([]*domain.Role, error) {
+	return []*domain.Role{admin, reader, writer}, nil
+}
+
+func (r *memoryRepository) ListByUser(ctx context.Context, userUUID string) ([]*domain.Role, error) {
+	return slices.Clone(r.assigned[userUUID]), nil
+}
+
+func (r *memoryRepository) Assign(ctx context.Context, userUUID string, role string) error {
+	return errors.New("not implemented")
+}
+
+func (r *memoryRepository) Revoke(ctx context.Context, userUUID string, role string) error {
+	return errors.New("not implemented")
+}
+
+// users knows which users exist; methods other than Get are not used.
+type users struct {
+	user.Service
+	live map[string]bool
+	err  error
+}
+
+func (u *users) Get(ctx context.Context, uuid string) (*domain.User, error) {
+	if u.err != nil {
+		return nil, u.err
+	} else if !u.live[uuid] {
+		return nil, domain.ErrorUserNotFound
+	}
+	return &domain.User{Uuid: uuid}, nil
+}
+
+func TestResolve(t *testing.T) {
+	failure := errors.New("database is down")
+
+	tests := []struct {
+		name        string
+		subject     string
+		assigned    []*domain.Role
+		live        bool
+		usersErr    error
+		roles       []string
+		permissions []string
+		err         error
+	}{
+		{
+			name:        "permissions are merged",
+			subject:     "u1",
+			assigned:    []*domain.Role{reader, writer},
+			live:        true,
+			roles:       []string{"reader", "writer"},
+			permissions: []string{domain.PermissionUsersRead, domain.PermissionUsersWrite},
+		},
+		{
+			name:        "no roles",
+			subject:     "u1",
+			live:        true,
+			roles:       []string{},
+			permissions: []string{},
+		},
+		{
+			name:        "configured admin",
+			subject:     "root",
+			live:        true,
+			roles:       []string{domain.RoleAdmin},
+			permissions: admin.Permissions,
+		},
+		{
+			name:        "configured admin already holding the role",
+			subject:     "root",
+			assigned:    []*domain.Role{admin},
+			live:        true,
+			roles:       []string{domain.RoleAdmin},
+			permissions: admin.Permissions,
+		},
+		{
+			name:        "deleted configured admin",
+			subject:     "root",
+			assigned:    []*domain.Role{reader},
+			roles:       []string{"reader"},
+			permissions: reader.Permissions,
+		},
+		{
+			name:     "configured admin that cannot be looked up",
+			subject:  "root",
+			live:     true,
+			usersErr: failure,
+			err:      failure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := &memoryRepository{assigned: map[string][]*domain.Role{tt.subject: tt.assigned}}
+			s, err := NewRoleService(repository, &users{live: map[string]bool{tt.subject: tt.live}, err: tt.usersErr}, &config.Auth{Admins: []string{"root"}}, nopLogger{})
+			if err != nil {
+				t.Fatalf("NewRoleService: %v", err)
+			}
+
+			roles, permissions, err := s.Resolve(context.Background(), tt.subject)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Resolve() error = %v, want %v", err, tt.err)
+			}
+			if !slices.Equal(roles, tt.roles) {
+				t.Fatalf("Resolve() roles = %v, want %v", roles, tt.roles)
+			}
+			if !slices.Equal(permissions, tt.permissions) {
+				t.Fatalf("Resolve() permissions = %v, want %v", permissions, tt.permissions)
+			}
+		})
+	}
+}
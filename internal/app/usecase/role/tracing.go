@@ -0,0 +1,69 @@
+package role
+
+import (
+	"app/internal/domain"
+	"app/internal/pkg/telemetry"
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "app/internal/app/usecase/role"
+
+var (
+	attrUUID = attribute.Key("user.uuid")
+	attrRole = attribute.Key("role.name")
+)
+
+// tracing wraps a Service in a span per call.
+type tracing struct {
+	next   Service
+	tracer trace.Tracer
+}
+
+var _ Service = (*tracing)(nil)
+
+func NewTracingService(next Service) (Service, error) {
+	if next == nil {
+		return nil, errors.New("service.NewTracingService: service is null")
+	}
+
+	return &tracing{next: next, tracer: telemetry.Tracer(tracerName)}, nil
+}
+
+func (t *tracing) List(ctx context.Context) (_ []*domain.Role, err error) {
+	ctx, span := t.tracer.Start(ctx, "role.List")
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.List(ctx)
+}
+
+func (t *tracing) ListByUser(ctx context.Context, uuid string) (_ []*domain.Role, err error) {
+	ctx, span := t.tracer.Start(ctx, "role.ListByUser", trace.WithAttributes(attrUUID.String(uuid)))
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.ListByUser(ctx, uuid)
+}
+
+func (t *tracing) Assign(ctx context.Context, uuid string, role string) (err error) {
+	ctx, span := t.tracer.Start(ctx, "role.Assign", trace.WithAttributes(attrUUID.String(uuid), attrRole.String(role)))
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.Assign(ctx, uuid, role)
+}
+
+func (t *tracing) Revoke(ctx context.Context, uuid string, role string) (err error) {
+	ctx, span := t.tracer.Start(ctx, "role.Revoke", trace.WithAttributes(attrUUID.String(uuid), attrRole.String(role)))
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.Revoke(ctx, uuid, role)
+}
+
+func (t *tracing) Resolve(ctx context.Context, subject string) (_ []string, _ []string, err error) {
+	ctx, span := t.tracer.Start(ctx, "role.Resolve", trace.WithAttributes(attrUUID.String(subject)))
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.Resolve(ctx, subject)
+}
@@ -0,0 +1,126 @@
+package user
+
+import (
+	"app/internal/domain"
+	"app/internal/pkg/telemetry"
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "app/internal/app/usecase/user"
+
+var attrUUID = attribute.Key("user.uuid")
+
+// tracing wraps a Service in a span per call. Logins, passwords and tokens
+// are never recorded.
+type tracing struct {
+	next   Service
+	tracer trace.Tracer
+}
+
+var _ Service = (*tracing)(nil)
+
+func NewTracingService(next Service) (Service, error) {
+	if next == nil {
+		return nil, errors.New("service.NewTracingService: service is null")
+	}
+
+	return &tracing{next: next, tracer: telemetry.Tracer(tracerName)}, nil
+}
+
+func (t *tracing) Create(ctx context.Context, user *domain.User) (uuid string, err error) {
+	ctx, span := t.tracer.Start(ctx, "user.Create")
+	defer func() {
+		span.SetAttributes(attrUUID.String(uuid))
+		telemetry.End(span, err)
+	}()
+
+	return t.next.Create(ctx, user)
+}
+
+func (t *tracing) Delete(ctx context.Context, uuid string) (err error) {
+	ctx, span := t.tracer.Start(ctx, "user.Delete", trace.WithAttributes(attrUUID.String(uuid)))
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.Delete(ctx, uuid)
+}
+
+func (t *tracing) Get(ctx context.Context, uuid string) (_ *domain.User, err error) {
+	ctx, span := t.tracer.Start(ctx, "user.Get", trace.WithAttributes(attrUUID.String(uuid)))
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.Get(ctx, uuid)
+}
+
+func (t *tracing) GetByLogin(ctx context.Context, login string) (_ *domain.User, err error) {
+	ctx, span := t.tracer.Start(ctx, "user.GetByLogin")
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.GetByLogin(ctx, login)
+}
+
+func (t *tracing) SetPassword(ctx context.Context, uuid string, password string) (err error) {
+	ctx, span := t.tracer.Start(ctx, "user.SetPassword", trace.WithAttributes(attrUUID.String(uuid)))
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.SetPassword(ctx, uuid, password)
+}
+
+func (t *tracing) Authenticate(ctx context.Context, login string, password string) (_ *domain.User, err error) {
+	ctx, span := t.tracer.Start(ctx, "user.Authenticate")
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.Authenticate(ctx, login, password)
+}
+
+func (t *tracing) List(ctx context.Context, filter domain.UserFilter) (_ *domain.UserPage, err error) {
+	ctx, span := t.tracer.Start(ctx, "user.List")
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.List(ctx, filter)
+}
+
+func (t *tracing) Update(ctx context.Context, uuid string, patch domain.UserPatch, version int64) (_ *domain.User, err error) {
+	ctx, span := t.tracer.Start(ctx, "user.Update", trace.WithAttributes(attrUUID.String(uuid)))
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.Update(ctx, uuid, patch, version)
+}
+
+func (t *tracing) Restore(ctx context.Context, uuid string) (_ *domain.User, err error) {
+	ctx, span := t.tracer.Start(ctx, "user.Restore", trace.WithAttributes(attrUUID.String(uuid)))
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.Restore(ctx, uuid)
+}
+
+func (t *tracing) Purge(ctx context.Context) (_ int64, err error) {
+	ctx, span := t.tracer.Start(ctx, "user.Purge")
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.Purge(ctx)
+}
+
+func (t *tracing) VerifyEmail(ctx context.Context, verifyToken string) (_ *domain.User, err error) {
+	ctx, span := t.tracer.Start(ctx, "user.VerifyEmail")
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.VerifyEmail(ctx, verifyToken)
+}
+
+func (t *tracing) ResendVerification(ctx context.Context, login string) (err error) {
+	ctx, span := t.tracer.Start(ctx, "user.ResendVerification")
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.ResendVerification(ctx, login)
+}
+
+func (t *tracing) Unlock(ctx context.Context, uuid string) (err error) {
+	ctx, span := t.tracer.Start(ctx, "user.Unlock", trace.WithAttributes(attrUUID.String(uuid)))
+	defer func() { telemetry.End(span, err) }()
+
+	return t.next.Unlock(ctx, uuid)
+}
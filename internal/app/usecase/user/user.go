@@ -2,40 +2,172 @@ package user
 
 import (
 	"app/internal/app/repository/postgres/user"
+	"app/internal/app/usecase/lockout"
 	"app/internal/domain"
+	"app/internal/pkg/config"
+	"app/internal/pkg/eventbus"
+	"app/internal/pkg/hasher"
 	"app/internal/pkg/logger"
-	"app/internal/pkg/types"
+	"app/internal/pkg/mailer"
+	"app/internal/pkg/metrics"
+	"app/internal/pkg/reqctx"
+	"app/internal/pkg/token"
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 )
 
 type Service interface {
 	Create(ctx context.Context, user *domain.User) (string, error)
 	Delete(ctx context.Context, uuid string) error
 	Get(ctx context.Context, uuid string) (*domain.User, error)
+	GetByLogin(ctx context.Context, login string) (*domain.User, error)
+	SetPassword(ctx context.Context, uuid string, password string) error
+	Authenticate(ctx context.Context, login string, password string) (*domain.User, error)
+	List(ctx context.Context, filter domain.UserFilter) (*domain.UserPage, error)
+	Update(ctx context.Context, uuid string, patch domain.UserPatch, version int64) (*domain.User, error)
+	Restore(ctx context.Context, uuid string) (*domain.User, error)
+	Purge(ctx context.Context) (int64, error)
+	VerifyEmail(ctx context.Context, verifyToken string) (*domain.User, error)
+	ResendVerification(ctx context.Context, login string) error
+	Unlock(ctx context.Context, uuid string) error
 }
 
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
 var _ Service = (*service)(nil)
 
 type service struct {
-	repository user.Repository
-	logger     logger.Interface
+	repository     user.Repository
+	hasher         hasher.Interface
+	lockout        lockout.Service
+	tokens         token.Interface
+	mailer         mailer.Interface
+	events         eventbus.Publisher
+	created        metrics.Counter
+	deleted        metrics.Counter
+	restored       metrics.Counter
+	purged         metrics.Counter
+	purgeRetention time.Duration
+	verifyTTL      time.Duration
+	verifyURL      string
+	// unverifiedLogin is how long a user with an unverified email address
+	// may still log in after signing up; zero means forever.
+	unverifiedLogin time.Duration
+	logger          logger.Interface
+
+	// dummyHash is verified against when the login is unknown, so that the
+	// response time does not reveal whether the user exists.
+	dummyHash string
 }
 
-func NewUserService(repository user.Repository, logger logger.Interface) (Service, error) {
+func NewUserService(
+	repository user.Repository,
+	hasher hasher.Interface,
+	lockout lockout.Service,
+	tokens token.Interface,
+	mailer mailer.Interface,
+	bus *eventbus.Bus,
+	metrics metrics.Interface,
+	cfg *config.User,
+	logger logger.Interface,
+) (Service, error) {
 	if repository == nil {
 		return nil, errors.New("service.NewUserService: repository is null")
 	}
 
+	if hasher == nil {
+		return nil, errors.New("service.NewUserService: hasher is null")
+	}
+
+	if lockout == nil {
+		return nil, errors.New("service.NewUserService: lockout is null")
+	}
+
+	if tokens == nil {
+		return nil, errors.New("service.NewUserService: tokens is null")
+	}
+
+	if mailer == nil {
+		return nil, errors.New("service.NewUserService: mailer is null")
+	}
+
+	if bus == nil {
+		return nil, errors.New("service.NewUserService: bus is null")
+	}
+
+	if metrics == nil {
+		return nil, errors.New("service.NewUserService: metrics is null")
+	}
+
+	if cfg == nil || cfg.VerificationTTL <= 0 {
+		return nil, errors.New("service.NewUserService: cfg is null or has no verification ttl")
+	}
+
+	// A retention of zero would purge users the moment they are deleted,
+	// leaving nothing to restore.
+	if cfg.PurgeRetention <= 0 {
+		return nil, errors.New("service.NewUserService: purge retention must be positive")
+	}
+
 	if logger == nil {
 		return nil, errors.New("service.NewUserService: logger is null")
 	}
 
-	return &service{
-		repository: repository,
-		logger:     logger,
-	}, nil
+	dummyHash, err := hasher.Hash("")
+	if err != nil {
+		return nil, fmt.Errorf("service.NewUserService: %w", err)
+	}
+
+	created, err := metrics.Counter("users_created_total", "Users signed up.")
+	if err != nil {
+		return nil, fmt.Errorf("service.NewUserService: %w", err)
+	}
+
+	deleted, err := metrics.Counter("users_deleted_total", "Users soft deleted.")
+	if err != nil {
+		return nil, fmt.Errorf("service.NewUserService: %w", err)
+	}
+
+	restored, err := metrics.Counter("users_restored_total", "Soft-deleted users restored.")
+	if err != nil {
+		return nil, fmt.Errorf("service.NewUserService: %w", err)
+	}
+
+	purged, err := metrics.Counter("users_purged_total", "Soft-deleted users removed permanently.")
+	if err != nil {
+		return nil, fmt.Errorf("service.NewUserService: %w", err)
+	}
+
+	s := &service{
+		repository:      repository,
+		hasher:          hasher,
+		lockout:         lockout,
+		tokens:          tokens,
+		mailer:          mailer,
+		events:          bus,
+		created:         created,
+		deleted:         deleted,
+		restored:        restored,
+		purged:          purged,
+		purgeRetention:  cfg.PurgeRetention,
+		verifyTTL:       cfg.VerificationTTL,
+		verifyURL:       cfg.VerificationURL,
+		unverifiedLogin: cfg.UnverifiedLoginPeriod,
+		logger:          logger,
+		dummyHash:       dummyHash,
+	}
+
+	eventbus.Subscribe(bus, "user.sendVerification", s.onUserCreated, eventbus.Async())
+	eventbus.Subscribe(bus, "user.resendVerification", s.onVerificationRequested, eventbus.Async())
+
+	return s, nil
 }
 
 func (s *service) Create(ctx context.Context, user *domain.User) (string, error) {
@@ -43,46 +175,495 @@ func (s *service) Create(ctx context.Context, user *domain.User) (string, error)
 		return "", fmt.Errorf("service.Create: user is nil")
 	}
 
-	txID := ctx.Value(types.CtxKey("tx")).(string)
-	s.logger.Debug(fmt.Sprintf("txID: %s service.Create, creating user: %v", txID, user))
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s service.Create, creating user with UUID: %s", txID, user.Uuid))
+
+	user.Login = NormalizeLogin(user.Login)
+	user.Email = strings.TrimSpace(user.Email)
+	user.EmailVerified = false
+
+	hash, err := s.hasher.Hash(user.Password)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.Create, error hashing password: %v", txID, err))
+		return "", fmt.Errorf("service.Create: %w", err)
+	}
+	user.Password = hash
 
 	u, err := s.repository.Create(ctx, user)
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("txID: %s service.Create, error creating user: %v", txID, err))
+		if errors.Is(err, domain.ErrorUserAlreadyExists) || errors.Is(err, domain.ErrorEmailAlreadyExists) {
+			log.Debug(fmt.Sprintf("txID: %s service.Create, login or email already taken", txID))
+			return "", err
+		}
+		log.Error(fmt.Sprintf("txID: %s service.Create, error creating user: %v", txID, err))
 		return "", fmt.Errorf("service.Create: %w", err)
 	}
 
-	s.logger.Debug(fmt.Sprintf("txID: %s service.Create, successfully created user: %v", txID, user))
+	s.publish(ctx, domain.UserCreated{User: u})
+	s.created.Inc()
+
+	log.Debug(fmt.Sprintf("txID: %s service.Create, successfully created user: %s", txID, u.Uuid))
 	return u.Uuid, nil
 }
 
 func (s *service) Get(ctx context.Context, uuid string) (*domain.User, error) {
-	txID := ctx.Value(types.CtxKey("tx")).(string)
-	s.logger.Debug(fmt.Sprintf("txID: %s service.Get, fetching user with UUID: %s", txID, uuid))
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s service.Get, fetching user with UUID: %s", txID, uuid))
 
 	u, err := s.repository.Get(ctx, uuid)
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("txID: %s service.Get, error fetching user: %v", txID, err))
+		log.Error(fmt.Sprintf("txID: %s service.Get, error fetching user: %v", txID, err))
 		return nil, fmt.Errorf("service.Get: %w", err)
 	} else if u == nil {
-		s.logger.Debug(fmt.Sprintf("txID: %s service.Get, user not found by UUID: %s", txID, uuid))
+		log.Debug(fmt.Sprintf("txID: %s service.Get, user not found by UUID: %s", txID, uuid))
+		return nil, domain.ErrorUserNotFound
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s service.Get, successfully fetched user: %s", txID, u.Uuid))
+	return u, nil
+}
+
+func (s *service) GetByLogin(ctx context.Context, login string) (*domain.User, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	login = NormalizeLogin(login)
+	log.Debug(fmt.Sprintf("txID: %s service.GetByLogin, fetching user by login", txID))
+
+	u, err := s.repository.GetByLogin(ctx, login)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.GetByLogin, error fetching user: %v", txID, err))
+		return nil, fmt.Errorf("service.GetByLogin: %w", err)
+	} else if u == nil {
 		return nil, domain.ErrorUserNotFound
 	}
 
-	s.logger.Debug(fmt.Sprintf("txID: %s service.Get, successfully fetched user: %v", txID, u))
 	return u, nil
 }
 
+// SetPassword replaces the password of a user without checking the old one.
+// Callers are responsible for having authorised the change.
+func (s *service) SetPassword(ctx context.Context, uuid string, password string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s service.SetPassword, setting password of user with UUID: %s", txID, uuid))
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.SetPassword, error hashing password: %v", txID, err))
+		return fmt.Errorf("service.SetPassword: %w", err)
+	}
+
+	if err = s.repository.UpdatePassword(ctx, uuid, hash); err != nil {
+		if errors.Is(err, domain.ErrorUserNotFound) {
+			return err
+		}
+		log.Error(fmt.Sprintf("txID: %s service.SetPassword, error storing password: %v", txID, err))
+		return fmt.Errorf("service.SetPassword: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s service.SetPassword, successfully set password of user: %s", txID, uuid))
+	return nil
+}
+
+func (s *service) Update(ctx context.Context, uuid string, patch domain.UserPatch, version int64) (*domain.User, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s service.Update, updating user with UUID: %s at version: %d", txID, uuid, version))
+
+	u, err := s.repository.Get(ctx, uuid)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.Update, error fetching user: %v", txID, err))
+		return nil, fmt.Errorf("service.Update: %w", err)
+	} else if u == nil {
+		return nil, domain.ErrorUserNotFound
+	} else if u.Version != version {
+		log.Debug(fmt.Sprintf("txID: %s service.Update, user %s is at version %d, expected %d", txID, uuid, u.Version, version))
+		return nil, domain.ErrorUserModified
+	}
+
+	if patch.Login != nil {
+		u.Login = NormalizeLogin(*patch.Login)
+	}
+
+	if patch.Password != nil {
+		u.Password, err = s.hasher.Hash(*patch.Password)
+		if err != nil {
+			log.Error(fmt.Sprintf("txID: %s service.Update, error hashing password: %v", txID, err))
+			return nil, fmt.Errorf("service.Update: %w", err)
+		}
+	}
+
+	updated, err := s.repository.Update(ctx, u, version)
+	if err != nil {
+		if errors.Is(err, domain.ErrorUserNotFound) ||
+			errors.Is(err, domain.ErrorUserModified) ||
+			errors.Is(err, domain.ErrorUserAlreadyExists) ||
+			errors.Is(err, domain.ErrorEmailAlreadyExists) {
+			return nil, err
+		}
+		log.Error(fmt.Sprintf("txID: %s service.Update, error updating user: %v", txID, err))
+		return nil, fmt.Errorf("service.Update: %w", err)
+	}
+
+	s.publish(ctx, domain.UserUpdated{User: updated})
+
+	log.Debug(fmt.Sprintf("txID: %s service.Update, successfully updated user: %s", txID, uuid))
+	return updated, nil
+}
+
 func (s *service) Delete(ctx context.Context, uuid string) error {
-	txID := ctx.Value(types.CtxKey("tx")).(string)
-	s.logger.Debug(fmt.Sprintf("txID: %s service.Delete, deleting user with UUID: %s", txID, uuid))
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s service.Delete, deleting user with UUID: %s", txID, uuid))
 
 	u, err := s.repository.Delete(ctx, uuid)
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("txID: %s service.Delete, error deleting user: %v", txID, err))
+		if errors.Is(err, domain.ErrorUserNotFound) {
+			log.Debug(fmt.Sprintf("txID: %s service.Delete, user not found by UUID: %s", txID, uuid))
+			return err
+		}
+		log.Error(fmt.Sprintf("txID: %s service.Delete, error deleting user: %v", txID, err))
 		return fmt.Errorf("service.Delete: %w", err)
 	}
 
-	s.logger.Debug(fmt.Sprintf("txID: %s service.Delete, successfully deleted user: %v", txID, u))
+	s.publish(ctx, domain.UserDeleted{User: u})
+	s.deleted.Inc()
+
+	log.Debug(fmt.Sprintf("txID: %s service.Delete, successfully deleted user: %s", txID, u.Uuid))
 	return nil
 }
+
+func (s *service) Restore(ctx context.Context, uuid string) (*domain.User, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s service.Restore, restoring user with UUID: %s", txID, uuid))
+
+	u, err := s.repository.Restore(ctx, uuid)
+	if err != nil {
+		if errors.Is(err, domain.ErrorUserNotFound) ||
+			errors.Is(err, domain.ErrorUserAlreadyExists) ||
+			errors.Is(err, domain.ErrorEmailAlreadyExists) {
+			return nil, err
+		}
+		log.Error(fmt.Sprintf("txID: %s service.Restore, error restoring user: %v", txID, err))
+		return nil, fmt.Errorf("service.Restore: %w", err)
+	}
+
+	s.publish(ctx, domain.UserRestored{User: u})
+	s.restored.Inc()
+
+	log.Debug(fmt.Sprintf("txID: %s service.Restore, successfully restored user: %s", txID, uuid))
+	return u, nil
+}
+
+// Purge permanently removes users that have been soft deleted for longer
+// than the configured retention.
+func (s *service) Purge(ctx context.Context) (int64, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	deletedBefore := time.Now().Add(-s.purgeRetention)
+	log.Debug(fmt.Sprintf("txID: %s service.Purge, purging users deleted before: %s", txID, deletedBefore))
+
+	purged, err := s.repository.Purge(ctx, deletedBefore)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.Purge, error purging users: %v", txID, err))
+		return 0, fmt.Errorf("service.Purge: %w", err)
+	}
+	s.purged.Add(float64(purged))
+
+	log.Info(fmt.Sprintf("txID: %s service.Purge, purged %d users deleted before %s", txID, purged, deletedBefore.Format(time.RFC3339)))
+	return purged, nil
+}
+
+func (s *service) List(ctx context.Context, filter domain.UserFilter) (*domain.UserPage, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s service.List, listing users", txID))
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	} else if filter.Limit > maxListLimit {
+		filter.Limit = maxListLimit
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = domain.UserSortCreatedAtAsc
+	}
+
+	filter.LoginPrefix = NormalizeLogin(filter.LoginPrefix)
+
+	page, err := s.repository.List(ctx, filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrorInvalidCursor) {
+			return nil, err
+		}
+		log.Error(fmt.Sprintf("txID: %s service.List, error listing users: %v", txID, err))
+		return nil, fmt.Errorf("service.List: %w", err)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s service.List, successfully listed %d users", txID, len(page.Users)))
+	return page, nil
+}
+
+func (s *service) Authenticate(ctx context.Context, login string, password string) (*domain.User, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	ip := reqctx.ClientIP(ctx)
+	login = NormalizeLogin(login)
+	log.Debug(fmt.Sprintf("txID: %s service.Authenticate, authenticating user", txID))
+
+	// Begin has already counted the attempt as failed; only a right
+	// password or an undecided attempt gives it back.
+	if err := s.lockout.Begin(ctx, login, ip); err != nil {
+		var retryErr *domain.RetryError
+		if errors.As(err, &retryErr) {
+			log.Debug(fmt.Sprintf("txID: %s service.Authenticate, attempt throttled for %s", txID, retryErr.RetryAfter))
+		}
+		return nil, err
+	}
+
+	u, err := s.verify(ctx, login, password)
+	if errors.Is(err, domain.ErrorInvalidCredentials) {
+		return nil, err
+	} else if err != nil {
+		if abortErr := s.lockout.Abort(ctx, login, ip); abortErr != nil {
+			log.Warn(fmt.Sprintf("txID: %s service.Authenticate, error releasing attempt: %v", txID, abortErr))
+		}
+		return nil, err
+	}
+
+	if err = s.lockout.Succeed(ctx, login, ip); err != nil {
+		log.Warn(fmt.Sprintf("txID: %s service.Authenticate, error clearing failed attempts: %v", txID, err))
+	}
+
+	if !s.mayLogIn(u) {
+		log.Debug(fmt.Sprintf("txID: %s service.Authenticate, email of user %s is still not verified", txID, u.Uuid))
+		return nil, domain.ErrorEmailNotVerified
+	}
+
+	if s.hasher.NeedsRehash(u.Password) {
+		s.rehash(ctx, u, password)
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s service.Authenticate, successfully authenticated user: %s", txID, u.Uuid))
+	return u, nil
+}
+
+// verify checks the password of login, spending the same time on a hash
+// whether or not the user exists.
+func (s *service) verify(ctx context.Context, login string, password string) (*domain.User, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+
+	u, err := s.repository.GetByLogin(ctx, login)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.Authenticate, error fetching user: %v", txID, err))
+		return nil, fmt.Errorf("service.Authenticate: %w", err)
+	} else if u == nil {
+		_, _ = s.hasher.Verify(password, s.dummyHash)
+		log.Debug(fmt.Sprintf("txID: %s service.Authenticate, user not found by login", txID))
+		return nil, domain.ErrorInvalidCredentials
+	}
+
+	ok, err := s.hasher.Verify(password, u.Password)
+	if errors.Is(err, hasher.ErrInvalidHash) {
+		// A hash in a format no algorithm understands can never match; the
+		// user has to reset the password.
+		log.Warn(fmt.Sprintf("txID: %s service.Authenticate, stored password of user %s is in an unknown format", txID, u.Uuid))
+		return nil, domain.ErrorInvalidCredentials
+	} else if err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.Authenticate, error verifying password: %v", txID, err))
+		return nil, fmt.Errorf("service.Authenticate: %w", err)
+	} else if !ok {
+		log.Debug(fmt.Sprintf("txID: %s service.Authenticate, wrong password for user: %s", txID, u.Uuid))
+		return nil, domain.ErrorInvalidCredentials
+	}
+
+	return u, nil
+}
+
+// Unlock clears the failed attempts and any lock on the login of a user.
+func (s *service) Unlock(ctx context.Context, uuid string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s service.Unlock, unlocking user with UUID: %s", txID, uuid))
+
+	u, err := s.Get(ctx, uuid)
+	if err != nil {
+		return err
+	}
+
+	if err = s.lockout.Unlock(ctx, u.Login); err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.Unlock, error unlocking user: %v", txID, err))
+		return fmt.Errorf("service.Unlock: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the email address of the user a verification token was
+// issued to as verified. Verifying twice is not an error.
+func (s *service) VerifyEmail(ctx context.Context, verifyToken string) (*domain.User, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+
+	claims, err := s.tokens.Verify(verifyToken, token.TypeVerifyEmail)
+	if err != nil {
+		log.Debug(fmt.Sprintf("txID: %s service.VerifyEmail, rejected token: %v", txID, err))
+		return nil, domain.ErrorInvalidVerifyToken
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s service.VerifyEmail, verifying email of user: %s", txID, claims.Subject))
+
+	u, err := s.Get(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, domain.ErrorUserNotFound) {
+			return nil, domain.ErrorInvalidVerifyToken
+		}
+		return nil, fmt.Errorf("service.VerifyEmail: %w", err)
+	}
+
+	if u.EmailVerified {
+		return u, nil
+	}
+
+	u, err = s.repository.VerifyEmail(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, domain.ErrorUserNotFound) {
+			return nil, domain.ErrorInvalidVerifyToken
+		}
+		log.Error(fmt.Sprintf("txID: %s service.VerifyEmail, error verifying email: %v", txID, err))
+		return nil, fmt.Errorf("service.VerifyEmail: %w", err)
+	}
+
+	log.Info(fmt.Sprintf("txID: %s service.VerifyEmail, verified email of user: %s", txID, u.Uuid))
+	return u, nil
+}
+
+// ResendVerification asks for a new verification mail, e.g. once the first
+// link has expired. As with password resets, unknown logins and verified
+// addresses are not reported and the mail is sent in the background.
+func (s *service) ResendVerification(ctx context.Context, login string) error {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+	log.Debug(fmt.Sprintf("txID: %s service.ResendVerification, verification mail requested", txID))
+
+	if err := s.events.Publish(ctx, domain.VerificationRequested{Login: NormalizeLogin(login)}); err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.ResendVerification, error queueing verification mail: %v", txID, err))
+		return fmt.Errorf("service.ResendVerification: %w", err)
+	}
+
+	return nil
+}
+
+// mayLogIn applies the grace period for unverified email addresses. Users
+// without an address predate verification and are not limited.
+func (s *service) mayLogIn(u *domain.User) bool {
+	if u.Email == "" || u.EmailVerified || s.unverifiedLogin <= 0 {
+		return true
+	}
+
+	return time.Since(u.CreatedAt) < s.unverifiedLogin
+}
+
+// publish tells subscribers about a committed change. Their failures are
+// logged but do not undo or fail the change.
+func (s *service) publish(ctx context.Context, event eventbus.Event) {
+	if err := s.events.Publish(ctx, event); err != nil {
+		txID := reqctx.RequestID(ctx)
+		log := logger.WithContext(ctx, s.logger)
+		log.Error(fmt.Sprintf("txID: %s service.publish, error publishing %T: %v", txID, event, err))
+	}
+}
+
+func (s *service) onUserCreated(ctx context.Context, event domain.UserCreated) error {
+	if event.User.Email != "" {
+		s.sendVerification(ctx, event.User)
+	}
+	return nil
+}
+
+func (s *service) onVerificationRequested(ctx context.Context, event domain.VerificationRequested) error {
+	u, err := s.repository.GetByLogin(ctx, event.Login)
+	if err != nil {
+		return fmt.Errorf("service.onVerificationRequested: %w", err)
+	}
+
+	if u != nil && u.Email != "" && !u.EmailVerified {
+		s.sendVerification(ctx, u)
+	}
+	return nil
+}
+
+// sendVerification mails a signed verification link. It is best effort: a
+// failure is logged and the user can still be verified later.
+func (s *service) sendVerification(ctx context.Context, u *domain.User) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+
+	verifyToken, _, err := s.tokens.Issue(u.Uuid, token.TypeVerifyEmail, s.verifyTTL)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.sendVerification, error issuing token: %v", txID, err))
+		return
+	}
+
+	msg := mailer.Message{
+		To:      u.Email,
+		Subject: "Verify your email address",
+		Body:    s.verificationBody(verifyToken),
+	}
+
+	if err = s.mailer.Send(ctx, msg); err != nil {
+		log.Error(fmt.Sprintf("txID: %s service.sendVerification, error sending mail to user %s: %v", txID, u.Uuid, err))
+		return
+	}
+
+	log.Debug(fmt.Sprintf("txID: %s service.sendVerification, sent verification mail to user: %s", txID, u.Uuid))
+}
+
+func (s *service) verificationBody(verifyToken string) string {
+	expires := fmt.Sprintf("The link expires in %s.", s.verifyTTL)
+
+	link, err := url.Parse(s.verifyURL)
+	if s.verifyURL == "" || err != nil {
+		return fmt.Sprintf("Use this token to verify your email address:\n\n%s\n\n%s\n", verifyToken, expires)
+	}
+
+	query := link.Query()
+	query.Set("token", verifyToken)
+	link.RawQuery = query.Encode()
+
+	return fmt.Sprintf("Follow this link to verify your email address:\n\n%s\n\n%s\n", link, expires)
+}
+
+// rehash upgrades a stored hash to the current algorithm and parameters. It
+// is best effort: a failure is logged and the authentication still succeeds.
+func (s *service) rehash(ctx context.Context, u *domain.User, password string) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Warn(fmt.Sprintf("txID: %s service.rehash, error hashing password: %v", txID, err))
+		return
+	}
+
+	if err = s.repository.UpdatePassword(ctx, u.Uuid, hash); err != nil {
+		log.Warn(fmt.Sprintf("txID: %s service.rehash, error storing rehashed password: %v", txID, err))
+		return
+	}
+
+	u.Password = hash
+	u.Version++
+	log.Debug(fmt.Sprintf("txID: %s service.rehash, successfully rehashed password of user: %s", txID, u.Uuid))
+}
+
+// NormalizeLogin trims surrounding spaces and lower cases the login, so that
+// logins differing only in case or padding are treated as the same one. It
+// mirrors lower(btrim(login)) in the migrations and the unique index on
+// lower(login); lookups compare with lower() in the database as well.
+func NormalizeLogin(login string) string {
+	return strings.ToLower(strings.Trim(login, " "))
+}
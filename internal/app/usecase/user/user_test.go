@@ -0,0 +1,403 @@
+package user
+
+import (
+	memoryAttempt "app/internal/app/repository/memory/attempt"
+	"app/internal/app/usecase/lockout"
+	"app/internal/domain"
+	"app/internal/pkg/config"
+	"app/internal/pkg/eventbus"
+	"app/internal/pkg/hasher"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/mailer"
+	"app/internal/pkg/metrics"
+	"app/internal/pkg/token"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...logger.Field) {}
+func (nopLogger) Info(string, ...logger.Field)  {}
+func (nopLogger) Warn(string, ...logger.Field)  {}
+func (nopLogger) Error(string, ...logger.Field) {}
+func (nopLogger) Fatal(string, ...logger.Field) {}
+
+// memoryRepository keeps users in a map, enforcing unique logins among the
+// users that are not deleted like the database does.
+type memoryRepository struct {
+	mu    sync.Mutex
+	users map[string]*domain.User
+	// creates counts the calls to Create, including those that failed.
+	creates int
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{users: make(map[string]*domain.User)}
+}
+
+func (r *memoryRepository) Create(ctx context.Context, u *domain.User) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.creates++
+	for _, existing := range r.users {
+		if existing.DeletedAt == nil && existing.Login == u.Login {
+			return nil, domain.ErrorUserAlreadyExists
+		}
+	}
+
+	created := *u
+	created.CreatedAt = time.Now()
+	created.Version = 1
+	r.users[u.Uuid] = &created
+	return r.copy(&created), nil
+}
+
+func (r *memoryRepository) Get(ctx context.Context, uuid string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[uuid]
+	if !ok || u.DeletedAt != nil {
+		return nil, nil
+	}
+	return r.copy(u), nil
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, uuid string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[uuid]
+	if !ok || u.DeletedAt != nil {
+		return nil, domain.ErrorUserNotFound
+	}
+	now := time.Now()
+	u.DeletedAt = &now
+	u.Version++
+	return r.copy(u), nil
+}
+
+func (r *memoryRepository) GetByLogin(ctx context.Context, login string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.DeletedAt == nil && u.Login == login {
+			return r.copy(u), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRepository) UpdatePassword(ctx context.Context, uuid string, password string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[uuid]
+	if !ok || u.DeletedAt != nil {
+		return domain.ErrorUserNotFound
+	}
+	u.Password = password
+	u.Version++
+	return nil
+}
+
+func (r *memoryRepository) List(ctx context.Context, filter domain.UserFilter) (*domain.UserPage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	page := &domain.UserPage{}
+	for _, u := range r.users {
+		if u.DeletedAt == nil && strings.HasPrefix(u.Login, filter.LoginPrefix) {
+			page.Users = append(page.Users, r.copy(u))
+		}
+	}
+	return page, nil
+}
+
+func (r *memoryRepository) Update(ctx context.Context, u *domain.User, version int64) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.users[u.Uuid]
+	if !ok || current.DeletedAt != nil {
+		return nil, domain.ErrorUserNotFound
+	} else if current.Version != version {
+		return nil, domain.ErrorUserModified
+	}
+
+	current.Login = u.Login
+	if u.Password != "" {
+		current.Password = u.Password
+	}
+	current.Version++
+	return r.copy(current), nil
+}
+
+func (r *memoryRepository) Restore(ctx context.Context, uuid string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[uuid]
+	if !ok || u.DeletedAt == nil {
+		return nil, domain.ErrorUserNotFound
+	}
+	u.DeletedAt = nil
+	u.Version++
+	return r.copy(u), nil
+}
+
+func (r *memoryRepository) VerifyEmail(ctx context.Context, uuid string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[uuid]
+	if !ok || u.DeletedAt != nil {
+		return nil, domain.ErrorUserNotFound
+	}
+	u.EmailVerified = true
+	u.Version++
+	return r.copy(u), nil
+}
+
+func (r *memoryRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return 0, nil
+}
+
+// stored returns the user as stored, password included.
+func (r *memoryRepository) stored(uuid string) *domain.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.copy(r.users[uuid])
+}
+
+func (r *memoryRepository) copy(u *domain.User) *domain.User {
+	if u == nil {
+		return nil
+	}
+	c := *u
+	return &c
+}
+
+// memoryMailer keeps the messages sent.
+type memoryMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+}
+
+func (m *memoryMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// Cheap hashing parameters keep the tests fast.
+func hasherConfig(algorithm string) *config.Hasher {
+	return &config.Hasher{
+		Algorithm:         algorithm,
+		Argon2Memory:      64,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		Argon2SaltLength:  16,
+		Argon2KeyLength:   32,
+		BcryptCost:        4,
+	}
+}
+
+type fixture struct {
+	service    *service
+	repository *memoryRepository
+	mailer     *memoryMailer
+	hasher     *hasher.Hasher
+	tokens     *token.Manager
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	h, err := hasher.New(hasherConfig(hasher.Argon2id))
+	if err != nil {
+		t.Fatalf("hasher.New: %v", err)
+	}
+
+	tokens, err := token.New(&config.Auth{Algorithm: token.HS256, HMACSecret: strings.Repeat("s", 32)})
+	if err != nil {
+		t.Fatalf("token.New: %v", err)
+	}
+
+	attempts, err := memoryAttempt.NewMemoryAttemptStore(nopLogger{})
+	if err != nil {
+		t.Fatalf("NewMemoryAttemptStore: %v", err)
+	}
+
+	lock, err := lockout.NewLockoutService(attempts, &config.Lockout{
+		Threshold:   5,
+		IPThreshold: 20,
+		Window:      time.Hour,
+		Duration:    time.Minute,
+	}, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewLockoutService: %v", err)
+	}
+
+	bus, err := eventbus.New(&config.EventBus{Workers: 1, QueueSize: 16}, nopLogger{})
+	if err != nil {
+		t.Fatalf("eventbus.New: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		bus.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	registry, err := metrics.New()
+	if err != nil {
+		t.Fatalf("metrics.New: %v", err)
+	}
+
+	repository := newMemoryRepository()
+	mail := &memoryMailer{}
+
+	s, err := NewUserService(repository, h, lock, tokens, mail, bus, registry, &config.User{
+		PurgeRetention:  time.Hour,
+		VerificationTTL: time.Hour,
+	}, nopLogger{})
+	if err != nil {
+		t.Fatalf("NewUserService: %v", err)
+	}
+
+	return &fixture{service: s.(*service), repository: repository, mailer: mail, hasher: h, tokens: tokens}
+}
+
+// add stores a user directly, with password stored as given.
+func (f *fixture) add(t *testing.T, uuid string, login string, password string) {
+	t.Helper()
+
+	if _, err := f.repository.Create(context.Background(), &domain.User{Uuid: uuid, Login: login, Password: password}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+}
+
+func TestCreateHashesPassword(t *testing.T) {
+	f := newFixture(t)
+
+	uuid, err := f.service.Create(context.Background(), &domain.User{Uuid: "u1", Login: " Alice ", Password: "Secret123"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	stored := f.repository.stored(uuid)
+	if stored.Password == "Secret123" || !strings.HasPrefix(stored.Password, "$argon2id$") {
+		t.Fatalf("stored password = %q, want an argon2id hash", stored.Password)
+	}
+	if ok, err := f.hasher.Verify("Secret123", stored.Password); err != nil || !ok {
+		t.Fatalf("Verify() = %v, %v, want true", ok, err)
+	}
+	if stored.Login != "alice" {
+		t.Fatalf("stored login = %q, want %q", stored.Login, "alice")
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	bcrypt, err := hasher.New(hasherConfig(hasher.Bcrypt))
+	if err != nil {
+		t.Fatalf("hasher.New: %v", err)
+	}
+	outdated, err := bcrypt.Hash("Secret123")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		stored   string
+		login    string
+		password string
+		err      error
+		// rehashed is whether the stored hash is replaced by an argon2id one.
+		rehashed bool
+	}{
+		{name: "right password", login: "alice", password: "Secret123"},
+		{name: "login in another case", login: " ALICE", password: "Secret123"},
+		{name: "wrong password", login: "alice", password: "Secret124", err: domain.ErrorInvalidCredentials},
+		{name: "unknown login", login: "bob", password: "Secret123", err: domain.ErrorInvalidCredentials},
+		{name: "plaintext is never accepted", stored: "Secret123", login: "alice", password: "Secret123", err: domain.ErrorInvalidCredentials},
+		{name: "outdated hash is upgraded", stored: outdated, login: "alice", password: "Secret123", rehashed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			stored := tt.stored
+			if stored == "" {
+				if stored, err = f.hasher.Hash("Secret123"); err != nil {
+					t.Fatalf("Hash: %v", err)
+				}
+			}
+			f.add(t, "u1", "alice", stored)
+
+			u, err := f.service.Authenticate(context.Background(), tt.login, tt.password)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.err)
+			}
+			if err == nil && u.Uuid != "u1" {
+				t.Fatalf("Authenticate() = %s, want u1", u.Uuid)
+			}
+
+			after := f.repository.stored("u1").Password
+			if rehashed := after != stored; rehashed != tt.rehashed {
+				t.Fatalf("password rehashed = %v, want %v", rehashed, tt.rehashed)
+			}
+			if tt.rehashed && !strings.HasPrefix(after, "$argon2id$") {
+				t.Fatalf("rehashed password = %q, want an argon2id hash", after)
+			}
+		})
+	}
+}
+
+func TestAuthenticateLockout(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+
+	hash, err := f.hasher.Hash("Secret123")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	f.add(t, "u1", "alice", hash)
+
+	// The fixture locks a login after five failed attempts.
+	for i := 0; i < 5; i++ {
+		if _, err = f.service.Authenticate(ctx, "alice", "wrong"); !errors.Is(err, domain.ErrorInvalidCredentials) {
+			t.Fatalf("attempt %d: Authenticate() error = %v, want %v", i, err, domain.ErrorInvalidCredentials)
+		}
+	}
+
+	_, err = f.service.Authenticate(ctx, "alice", "Secret123")
+	var retryErr *domain.RetryError
+	if !errors.As(err, &retryErr) || !errors.Is(err, domain.ErrorTooManyAttempts) {
+		t.Fatalf("Authenticate() of a locked login error = %v, want %v", err, domain.ErrorTooManyAttempts)
+	}
+
+	if err = f.service.Unlock(ctx, "u1"); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+
+	if _, err = f.service.Authenticate(ctx, "alice", "Secret123"); err != nil {
+		t.Fatalf("Authenticate() after Unlock error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"time"
+)
+
+const (
+	AuditUserCreated         = "user.created"
+	AuditUserUpdated         = "user.updated"
+	AuditUserDeleted         = "user.deleted"
+	AuditUserRestored        = "user.restored"
+	AuditUserPurged          = "user.purged"
+	AuditUserPasswordChanged = "user.password_changed"
+	AuditUserEmailVerified   = "user.email_verified"
+	AuditRoleAssigned        = "role.assigned"
+	AuditRoleRevoked         = "role.revoked"
+
+	PermissionAuditRead = "audit:read"
+
+	// Redacted replaces the values of secret fields in audit changes.
+	Redacted = "[REDACTED]"
+)
+
+// AuditChange is the value of one field before and after a mutation; nil
+// means the field did not exist on that side.
+type AuditChange struct {
+	Before interface{}
+	After  interface{}
+}
+
+type AuditEntry struct {
+	ID         int64
+	Actor      string
+	Action     string
+	TargetUUID string
+	RequestID  string
+	ClientIP   string
+	Changes    map[string]AuditChange
+	CreatedAt  time.Time
+}
+
+type AuditFilter struct {
+	Actor      string
+	Action     string
+	TargetUUID string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Cursor     string
+}
+
+type AuditPage struct {
+	Entries    []*AuditEntry
+	NextCursor string
+}
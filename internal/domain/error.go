@@ -1,7 +1,73 @@
 package domain
 
-import "errors"
+import (
+	"time"
+)
+
+// Kind classifies an Error so that transports can map it without knowing
+// every individual error.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindInvalid
+	KindUnauthorized
+	KindForbidden
+	KindUnavailable
+	KindPreconditionFailed
+	KindTooManyRequests
+)
+
+type Error struct {
+	Kind    Kind
+	Message string
+}
+
+func NewError(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// RetryError is an Error the caller may retry once RetryAfter has passed.
+type RetryError struct {
+	Err        *Error
+	RetryAfter time.Duration
+}
+
+func NewRetryError(err *Error, retryAfter time.Duration) *RetryError {
+	return &RetryError{Err: err, RetryAfter: retryAfter}
+}
+
+func (e *RetryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
 
 var (
-	ErrorUserNotFound = errors.New("user not found")
+	ErrorUserNotFound       = NewError(KindNotFound, "user not found")
+	ErrorInvalidCredentials = NewError(KindUnauthorized, "invalid credentials")
+	ErrorSessionNotFound    = NewError(KindUnauthorized, "session not found")
+	ErrorRefreshTokenReused = NewError(KindUnauthorized, "refresh token reuse detected")
+	ErrorInvalidCursor      = NewError(KindInvalid, "invalid cursor")
+	ErrorUserModified       = NewError(KindPreconditionFailed, "user was modified concurrently")
+	ErrorUserAlreadyExists  = NewError(KindConflict, "user already exists")
+	ErrorEmailAlreadyExists = NewError(KindConflict, "email address is already in use")
+	ErrorInvalidPayload     = NewError(KindInvalid, "invalid request payload")
+	ErrorUnauthenticated    = NewError(KindUnauthorized, "authentication required")
+	ErrorForbidden          = NewError(KindForbidden, "access denied")
+	ErrorRoleNotFound       = NewError(KindNotFound, "role not found")
+	ErrorInvalidResetToken  = NewError(KindInvalid, "reset token is invalid or expired")
+	ErrorInvalidVerifyToken = NewError(KindInvalid, "verification token is invalid or expired")
+	ErrorEmailNotVerified   = NewError(KindForbidden, "email address is not verified")
+	ErrorTooManyAttempts    = NewError(KindTooManyRequests, "too many failed attempts, try again later")
+	ErrorLockoutUnavailable = NewError(KindUnavailable, "signing in is temporarily unavailable, try again later")
 )
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"time"
+)
+
+const (
+	EventUserCreated = "UserCreated"
+	EventUserDeleted = "UserDeleted"
+)
+
+// Event is a change other systems are told about. It is stored in the
+// outbox together with the change itself and relayed afterwards.
+type Event struct {
+	ID          int64
+	Type        string
+	AggregateID string
+	Payload     []byte
+	CreatedAt   time.Time
+	Attempts    int
+}
+
+// UserCreated, UserUpdated, UserDeleted and UserRestored are published on
+// the in-process event bus after the change is committed.
+type UserCreated struct {
+	User *User
+}
+
+func (e UserCreated) AggregateID() string { return e.User.Uuid }
+
+type UserUpdated struct {
+	User *User
+}
+
+func (e UserUpdated) AggregateID() string { return e.User.Uuid }
+
+type UserDeleted struct {
+	User *User
+}
+
+func (e UserDeleted) AggregateID() string { return e.User.Uuid }
+
+type UserRestored struct {
+	User *User
+}
+
+func (e UserRestored) AggregateID() string { return e.User.Uuid }
+
+// PasswordResetRequested is published when a password reset is requested
+// for a login, which may not exist. It is handled asynchronously, so that
+// the request takes the same time either way.
+type PasswordResetRequested struct {
+	Login string
+}
+
+func (e PasswordResetRequested) AggregateID() string { return e.Login }
+
+// VerificationRequested is published when a new verification mail is asked
+// for a login, which may not exist. Like PasswordResetRequested it is
+// handled asynchronously.
+type VerificationRequested struct {
+	Login string
+}
+
+func (e VerificationRequested) AggregateID() string { return e.Login }
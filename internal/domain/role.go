@@ -0,0 +1,17 @@
+package domain
+
+const (
+	RoleAdmin = "admin"
+
+	PermissionUsersRead   = "users:read"
+	PermissionUsersWrite  = "users:write"
+	PermissionUsersDelete = "users:delete"
+	PermissionUsersAdmin  = "users:admin"
+	PermissionRolesManage = "roles:manage"
+)
+
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+}
@@ -0,0 +1,12 @@
+package domain
+
+import (
+	"time"
+)
+
+type Session struct {
+	ID        string
+	UserID    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
@@ -0,0 +1,12 @@
+package domain
+
+import (
+	"time"
+)
+
+type Tokens struct {
+	AccessToken      string
+	ExpiresAt        time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
@@ -5,10 +5,40 @@ import (
 )
 
 type User struct {
-	Uuid      string
-	Login     string
-	Password  string
-	CreatedAt time.Time
-	UpdatedAt *time.Time
-	DeletedAt *time.Time
+	Uuid          string
+	Login         string
+	Password      string
+	Email         string
+	EmailVerified bool
+	CreatedAt     time.Time
+	UpdatedAt     *time.Time
+	DeletedAt     *time.Time
+	Version       int64
+}
+
+// UserPatch holds the fields of a partial update; nil fields are left as is.
+type UserPatch struct {
+	Login    *string
+	Password *string
+}
+
+type UserSort string
+
+const (
+	UserSortCreatedAtAsc  UserSort = "created_at"
+	UserSortCreatedAtDesc UserSort = "-created_at"
+)
+
+type UserFilter struct {
+	LoginPrefix string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Sort        UserSort
+	Limit       int
+	Cursor      string
+}
+
+type UserPage struct {
+	Users      []*User
+	NextCursor string
 }
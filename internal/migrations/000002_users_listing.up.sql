@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_users_created_at_uuid ON users(created_at, uuid) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+-- UNIQUE(login) is not restored: deleted users may share a login with an
+-- active one by now, and logins lowered by the up migration stay lowered.
+DROP INDEX IF EXISTS idx_users_login_active;
@@ -0,0 +1,31 @@
+-- Logins are unique case-insensitively among users that are not soft deleted,
+-- so a deleted user's login can be taken again.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_login_key;
+
+-- Active users whose logins only differ in case or padding would fail the
+-- unique index. Renaming them would lock their owners out, so refuse to
+-- migrate until they are resolved by hand.
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(login || ' (' || users || ')', ', ')
+    INTO duplicates
+    FROM (
+        SELECT lower(btrim(login)) AS login, string_agg(uuid::TEXT, ', ' ORDER BY user_id) AS users
+        FROM users
+        WHERE deleted_at IS NULL
+        GROUP BY lower(btrim(login))
+        HAVING count(*) > 1
+    ) d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'active users share logins differing only in case or padding: %', duplicates
+            USING HINT = 'Rename or delete all but one user of each login, then rerun the migration.';
+    END IF;
+END
+$$;
+
+UPDATE users SET login = lower(btrim(login)) WHERE login <> lower(btrim(login));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_login_active ON users(lower(login)) WHERE deleted_at IS NULL;
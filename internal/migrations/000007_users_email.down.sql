@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS users_email_lower_key;
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified;
+ALTER TABLE users DROP COLUMN IF EXISTS email;
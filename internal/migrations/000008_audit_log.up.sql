@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    audit_id BIGSERIAL PRIMARY KEY,
+    actor VARCHAR(64),
+    action VARCHAR(64) NOT NULL,
+    target_uuid UUID,
+    request_id VARCHAR(64),
+    client_ip VARCHAR(64),
+    changes JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target_uuid, audit_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor, audit_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+
+INSERT INTO permissions (name, description) VALUES ('audit:read', 'Read the audit log')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'audit:read'
+ON CONFLICT DO NOTHING;
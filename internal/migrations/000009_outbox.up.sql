@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    event_id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(64) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ DEFAULT now(),
+    last_error TEXT,
+    sent_at TIMESTAMPTZ
+);
+
+-- Only events still waiting for delivery are polled; sent and abandoned ones
+-- have no next attempt.
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(next_attempt_at, event_id) WHERE next_attempt_at IS NOT NULL;
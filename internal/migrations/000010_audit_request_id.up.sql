@@ -0,0 +1 @@
+ALTER TABLE audit_log ALTER COLUMN request_id TYPE VARCHAR(128);
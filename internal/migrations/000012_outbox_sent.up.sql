@@ -0,0 +1,2 @@
+-- Sent events are purged by age.
+CREATE INDEX IF NOT EXISTS idx_outbox_sent ON outbox(sent_at) WHERE sent_at IS NOT NULL;
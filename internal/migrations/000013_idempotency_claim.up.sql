@@ -0,0 +1,2 @@
+-- The token of the request holding a key; cleared once its response is stored.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS claim CHAR(32);
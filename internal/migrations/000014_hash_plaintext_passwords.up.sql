@@ -0,0 +1,9 @@
+-- Passwords stored before hashing was introduced are still plaintext, which
+-- the hasher rejects as an unknown format. Hash them with bcrypt, which the
+-- bcrypt verifier accepts; they are upgraded to the configured algorithm on
+-- the next login.
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+UPDATE users
+SET password = crypt(password, gen_salt('bf', 12))
+WHERE password !~ '^\$(argon2id|2[aby])\$';
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"app/internal/pkg/types"
+	"context"
+	"slices"
+	"time"
+)
+
+const principalKey = types.CtxKey("principal")
+
+// Principal is the authenticated caller of a request. TokenID identifies
+// the access token, SessionID the session it was issued for, which stays the
+// same across refreshes.
+type Principal struct {
+	Subject     string
+	TokenID     string
+	SessionID   string
+	ExpiresAt   time.Time
+	Roles       []string
+	Permissions []string
+}
+
+// Can reports whether the principal has been granted permission through any
+// of its roles.
+func (p *Principal) Can(permission string) bool {
+	return slices.Contains(p.Permissions, permission)
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(*Principal)
+	return principal, ok && principal != nil
+}
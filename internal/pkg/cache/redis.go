@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"app/internal/pkg/config"
+	"app/internal/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const pingTimeout = 5 * time.Second
+
+type Redis struct {
+	Client *redis.Client
+
+	logger logger.Interface
+}
+
+var rd *Redis
+
+var hdlOnce sync.Once
+
+func NewOrGetSingletonRedis(cfg *config.Cache, logger logger.Interface) (*Redis, error) {
+	if cfg == nil || cfg.URL == "" {
+		return nil, errors.New("cfg is not valid")
+	}
+
+	var er error
+	hdlOnce.Do(func() {
+		r, err := newRedis(cfg, logger)
+		if err != nil {
+			er = err
+		}
+
+		rd = r
+	})
+
+	return rd, er
+}
+
+func newRedis(cfg *config.Cache, log logger.Interface) (*Redis, error) {
+	opts, err := redis.ParseURL(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("redis - NewRedis - redis.ParseURL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+
+	if err = client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("redis - NewRedis - client.Ping: %w", err)
+	}
+
+	log.Info(fmt.Sprintf("Redis is connected to %s", opts.Addr))
+
+	return &Redis{
+		Client: client,
+		logger: log,
+	}, nil
+}
+
+// Ping is the readiness check of Redis.
+func (r *Redis) Ping(ctx context.Context) error {
+	if err := r.Client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis - Ping: %w", err)
+	}
+	return nil
+}
+
+func (r *Redis) Close() {
+	if r != nil && r.Client != nil {
+		_ = r.Client.Close()
+	}
+}
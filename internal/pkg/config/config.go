@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -30,15 +31,91 @@ const (
 
 	defaultDBConnectionTimeout  = 30
 	defaultDBConnectionAttempts = 3
+
+	defaultHasherAlgorithm         = "argon2id"
+	defaultHasherArgon2Memory      = 64 * 1024
+	defaultHasherArgon2Iterations  = 3
+	defaultHasherArgon2Parallelism = 2
+	defaultHasherArgon2SaltLength  = 16
+	defaultHasherArgon2KeyLength   = 32
+	defaultHasherBcryptCost        = 12
+
+	defaultAuthAlgorithm        = "HS256"
+	defaultAuthAccessTokenTTL   = 15 * time.Minute
+	defaultAuthRefreshTokenTTL  = 30 * 24 * time.Hour
+	defaultAuthPasswordResetTTL = time.Hour
+
+	defaultLockoutThreshold    = 5
+	defaultLockoutIPThreshold  = 50
+	defaultLockoutWindow       = 15 * time.Minute
+	defaultLockoutDuration     = 15 * time.Minute
+	defaultLockoutBackoffAfter = 3
+	defaultLockoutBackoffBase  = time.Second
+	defaultLockoutBackoffMax   = 30 * time.Second
+	defaultLockoutFailOpen     = false
+
+	defaultMailerDriver    = "file"
+	defaultMailerFrom      = "no-reply@localhost"
+	defaultMailerDirectory = "var/mail"
+	defaultMailerSMTPPort  = 587
+	defaultMailerTimeout   = 10 * time.Second
+
+	defaultOutboxPublisher    = "log"
+	defaultOutboxPollInterval = time.Second
+	defaultOutboxBatchSize    = 100
+	defaultOutboxMaxAttempts  = 10
+	defaultOutboxBackoffBase  = time.Second
+	defaultOutboxBackoffMax   = 5 * time.Minute
+	defaultOutboxRetention    = 7 * 24 * time.Hour
+
+	defaultEventBusWorkers   = 4
+	defaultEventBusQueueSize = 256
+
+	defaultTelemetryExporter     = "none"
+	defaultTelemetryOTLPEndpoint = "localhost:4318"
+	defaultTelemetrySampleRatio  = 1.0
+
+	defaultMetricsAddress = ":9090"
+	defaultMetricsPath    = "/metrics"
+
+	defaultRateLimitAlgorithm = "token_bucket"
+	defaultRateLimitKey       = "ip"
+	defaultRateLimitRoutes    = "POST /users=10/1h,burst=3;POST /auth/login=20/1m;POST /auth/password/forgot=5/1h;POST /users/verify/resend=5/1h"
+
+	defaultIdempotencyRetention   = 24 * time.Hour
+	defaultIdempotencyLockTimeout = time.Minute
+
+	defaultCacheUserTTL     = 5 * time.Minute
+	defaultCacheNegativeTTL = 30 * time.Second
+
+	defaultHealthCheckTimeout = 2 * time.Second
+	defaultHealthCacheTTL     = time.Second
+	defaultHealthDrainDelay   = 5 * time.Second
+
+	defaultUserPurgeRetention        = 30 * 24 * time.Hour
+	defaultUserVerificationTTL       = 48 * time.Hour
+	defaultUserUnverifiedLoginPeriod = 7 * 24 * time.Hour
 )
 
 type (
 	Config struct {
-		App   *App
-		Http  *HTTP
-		Log   *Log
-		DB    *DB
-		Cache *Cache
+		App         *App
+		Http        *HTTP
+		Log         *Log
+		DB          *DB
+		Cache       *Cache
+		Hasher      *Hasher
+		Auth        *Auth
+		User        *User
+		Mailer      *Mailer
+		Lockout     *Lockout
+		Outbox      *Outbox
+		EventBus    *EventBus
+		Telemetry   *Telemetry
+		Metrics     *Metrics
+		Health      *Health
+		RateLimit   *RateLimit
+		Idempotency *Idempotency
 	}
 
 	App struct {
@@ -46,11 +123,15 @@ type (
 		Version string
 	}
 
+	// HTTP serves the API on Address. TrustedProxies lists the addresses
+	// and CIDR ranges of reverse proxies whose X-Forwarded-For header names
+	// the client; without them the peer address is the client.
 	HTTP struct {
 		Address         string
 		ReadTimeout     time.Duration
 		WriteTimeout    time.Duration
 		ShutdownTimeout time.Duration
+		TrustedProxies  []string
 	}
 
 	Log struct {
@@ -72,8 +153,138 @@ type (
 		ConnectionAttempts int
 	}
 
+	// Cache is Redis, used when URL is set. Users are cached for UserTTL,
+	// and lookups of unknown users for NegativeTTL.
 	Cache struct {
-		URL string
+		URL         string
+		UserTTL     time.Duration
+		NegativeTTL time.Duration
+	}
+
+	Hasher struct {
+		Algorithm         string
+		Argon2Memory      uint32
+		Argon2Iterations  uint32
+		Argon2Parallelism uint8
+		Argon2SaltLength  uint32
+		Argon2KeyLength   uint32
+		BcryptCost        int
+	}
+
+	Auth struct {
+		Algorithm         string
+		HMACSecret        string
+		Ed25519PrivateKey string
+		Issuer            string
+		AccessTokenTTL    time.Duration
+		RefreshTokenTTL   time.Duration
+		Admins            []string
+		PasswordResetTTL  time.Duration
+		PasswordResetURL  string
+	}
+
+	// Lockout limits password guessing. Failures are counted per login and
+	// per client address; reaching a threshold locks the key for Duration.
+	// From BackoffAfter failures on, each further attempt on a login has to
+	// wait BackoffBase doubled per failure, at most BackoffMax. FailOpen
+	// lets logins through while the counters cannot be reached; by default
+	// they are refused with 503.
+	Lockout struct {
+		Threshold    int
+		IPThreshold  int
+		Window       time.Duration
+		Duration     time.Duration
+		BackoffAfter int
+		BackoffBase  time.Duration
+		BackoffMax   time.Duration
+		FailOpen     bool
+	}
+
+	Mailer struct {
+		Driver       string
+		From         string
+		Directory    string
+		SMTPHost     string
+		SMTPPort     int
+		SMTPUsername string
+		SMTPPassword string
+		Timeout      time.Duration
+	}
+
+	// Outbox relays events stored with the data they describe to Publisher.
+	// A failed event is retried after BackoffBase doubled per attempt, at
+	// most BackoffMax, and given up after MaxAttempts. Sent events are
+	// deleted after Retention.
+	Outbox struct {
+		Publisher    string
+		PollInterval time.Duration
+		BatchSize    int
+		MaxAttempts  int
+		BackoffBase  time.Duration
+		BackoffMax   time.Duration
+		Retention    time.Duration
+	}
+
+	// EventBus delivers asynchronous handlers on Workers goroutines, each
+	// with a queue of QueueSize events.
+	EventBus struct {
+		Workers   int
+		QueueSize int
+	}
+
+	// Telemetry selects where spans go: "stdout", "otlp" (OTLP over HTTP to
+	// OTLPEndpoint) or "none". SampleRatio applies to traces started here;
+	// callers' sampling decisions are followed.
+	Telemetry struct {
+		Exporter     string
+		OTLPEndpoint string
+		OTLPInsecure bool
+		SampleRatio  float64
+	}
+
+	// Metrics exposes the Prometheus metrics on Path of a listener of its
+	// own at Address, apart from the API. An empty Address leaves them
+	// unexposed.
+	Metrics struct {
+		Address string
+		Path    string
+	}
+
+	// Health bounds every readiness check by CheckTimeout and reuses its
+	// result for CacheTTL. On shutdown the server reports not ready for
+	// DrainDelay before it stops accepting connections.
+	Health struct {
+		CheckTimeout time.Duration
+		CacheTTL     time.Duration
+		DrainDelay   time.Duration
+	}
+
+	// RateLimit limits the routes listed in Routes, see ratelimit.ParseRules.
+	// Algorithm and Key apply to routes that do not set their own.
+	RateLimit struct {
+		Algorithm string
+		Key       string
+		Routes    string
+	}
+
+	// Idempotency keeps responses to requests with an Idempotency-Key in
+	// Store, "redis" or "postgres", for Retention. Left empty, Redis is used
+	// when it is configured. A request that has not finished within
+	// LockTimeout no longer holds its key.
+	Idempotency struct {
+		Store       string
+		Retention   time.Duration
+		LockTimeout time.Duration
+	}
+
+	User struct {
+		PurgeRetention  time.Duration
+		VerificationTTL time.Duration
+		VerificationURL string
+		// UnverifiedLoginPeriod is how long after sign up a user may log in
+		// without having verified their email address. Zero disables the
+		// limit.
+		UnverifiedLoginPeriod time.Duration
 	}
 )
 
@@ -114,8 +325,86 @@ func Default() *Config {
 			OutputPath:   defaultLogOutputPath,
 			ErrorEnabled: defaultLogErrorEnabled,
 		},
-		DB:    &DB{},
-		Cache: &Cache{},
+		DB: &DB{},
+		Cache: &Cache{
+			UserTTL:     defaultCacheUserTTL,
+			NegativeTTL: defaultCacheNegativeTTL,
+		},
+		Hasher: &Hasher{
+			Algorithm:         defaultHasherAlgorithm,
+			Argon2Memory:      defaultHasherArgon2Memory,
+			Argon2Iterations:  defaultHasherArgon2Iterations,
+			Argon2Parallelism: defaultHasherArgon2Parallelism,
+			Argon2SaltLength:  defaultHasherArgon2SaltLength,
+			Argon2KeyLength:   defaultHasherArgon2KeyLength,
+			BcryptCost:        defaultHasherBcryptCost,
+		},
+		Auth: &Auth{
+			Algorithm:        defaultAuthAlgorithm,
+			Issuer:           defaultAppName,
+			AccessTokenTTL:   defaultAuthAccessTokenTTL,
+			RefreshTokenTTL:  defaultAuthRefreshTokenTTL,
+			PasswordResetTTL: defaultAuthPasswordResetTTL,
+		},
+		User: &User{
+			PurgeRetention:        defaultUserPurgeRetention,
+			VerificationTTL:       defaultUserVerificationTTL,
+			UnverifiedLoginPeriod: defaultUserUnverifiedLoginPeriod,
+		},
+		Lockout: &Lockout{
+			Threshold:    defaultLockoutThreshold,
+			IPThreshold:  defaultLockoutIPThreshold,
+			Window:       defaultLockoutWindow,
+			Duration:     defaultLockoutDuration,
+			BackoffAfter: defaultLockoutBackoffAfter,
+			BackoffBase:  defaultLockoutBackoffBase,
+			BackoffMax:   defaultLockoutBackoffMax,
+			FailOpen:     defaultLockoutFailOpen,
+		},
+		Telemetry: &Telemetry{
+			Exporter:     defaultTelemetryExporter,
+			OTLPEndpoint: defaultTelemetryOTLPEndpoint,
+			OTLPInsecure: true,
+			SampleRatio:  defaultTelemetrySampleRatio,
+		},
+		Metrics: &Metrics{
+			Address: defaultMetricsAddress,
+			Path:    defaultMetricsPath,
+		},
+		Health: &Health{
+			CheckTimeout: defaultHealthCheckTimeout,
+			CacheTTL:     defaultHealthCacheTTL,
+			DrainDelay:   defaultHealthDrainDelay,
+		},
+		RateLimit: &RateLimit{
+			Algorithm: defaultRateLimitAlgorithm,
+			Key:       defaultRateLimitKey,
+			Routes:    defaultRateLimitRoutes,
+		},
+		Idempotency: &Idempotency{
+			Retention:   defaultIdempotencyRetention,
+			LockTimeout: defaultIdempotencyLockTimeout,
+		},
+		EventBus: &EventBus{
+			Workers:   defaultEventBusWorkers,
+			QueueSize: defaultEventBusQueueSize,
+		},
+		Outbox: &Outbox{
+			Publisher:    defaultOutboxPublisher,
+			PollInterval: defaultOutboxPollInterval,
+			BatchSize:    defaultOutboxBatchSize,
+			MaxAttempts:  defaultOutboxMaxAttempts,
+			BackoffBase:  defaultOutboxBackoffBase,
+			BackoffMax:   defaultOutboxBackoffMax,
+			Retention:    defaultOutboxRetention,
+		},
+		Mailer: &Mailer{
+			Driver:    defaultMailerDriver,
+			From:      defaultMailerFrom,
+			Directory: defaultMailerDirectory,
+			SMTPPort:  defaultMailerSMTPPort,
+			Timeout:   defaultMailerTimeout,
+		},
 	}
 }
 
@@ -130,6 +419,7 @@ func New() *Config {
 			ReadTimeout:     getEnvAsDuration("HTTP_READ_TIMEOUT", defaultReadTimeout),
 			WriteTimeout:    getEnvAsDuration("HTTP_WRITE_TIMEOUT", defaultWriteTimeout),
 			ShutdownTimeout: getEnvAsDuration("HTTP_SHUTDOWN_TIMEOUT", defaultShutdownTimeout),
+			TrustedProxies:  getEnvAsSlice("HTTP_TRUSTED_PROXIES", nil),
 		},
 		Log: &Log{
 			Level:      getEnv("LOG_LEVEL", defaultLogLevel),
@@ -148,7 +438,93 @@ func New() *Config {
 			ConnectionAttempts: getEnvAsInt("DB_CONNECTION_ATTEMPTS", defaultDBConnectionAttempts),
 		},
 		Cache: &Cache{
-			URL: getEnv("REDIS_URL", ""),
+			URL:         getEnv("REDIS_URL", ""),
+			UserTTL:     getEnvAsDuration("CACHE_USER_TTL", defaultCacheUserTTL),
+			NegativeTTL: getEnvAsDuration("CACHE_NEGATIVE_TTL", defaultCacheNegativeTTL),
+		},
+		Hasher: &Hasher{
+			Algorithm:         getEnv("HASHER_ALGORITHM", defaultHasherAlgorithm),
+			Argon2Memory:      uint32(getEnvAsInt("HASHER_ARGON2_MEMORY", defaultHasherArgon2Memory)),
+			Argon2Iterations:  uint32(getEnvAsInt("HASHER_ARGON2_ITERATIONS", defaultHasherArgon2Iterations)),
+			Argon2Parallelism: uint8(getEnvAsInt("HASHER_ARGON2_PARALLELISM", defaultHasherArgon2Parallelism)),
+			Argon2SaltLength:  uint32(getEnvAsInt("HASHER_ARGON2_SALT_LENGTH", defaultHasherArgon2SaltLength)),
+			Argon2KeyLength:   uint32(getEnvAsInt("HASHER_ARGON2_KEY_LENGTH", defaultHasherArgon2KeyLength)),
+			BcryptCost:        getEnvAsInt("HASHER_BCRYPT_COST", defaultHasherBcryptCost),
+		},
+		Auth: &Auth{
+			Algorithm:         getEnv("AUTH_ALGORITHM", defaultAuthAlgorithm),
+			HMACSecret:        getEnv("AUTH_HMAC_SECRET", ""),
+			Ed25519PrivateKey: getEnv("AUTH_ED25519_PRIVATE_KEY", ""),
+			Issuer:            getEnv("AUTH_ISSUER", getEnv("APP_NAME", defaultAppName)),
+			AccessTokenTTL:    getEnvAsDuration("AUTH_ACCESS_TOKEN_TTL", defaultAuthAccessTokenTTL),
+			RefreshTokenTTL:   getEnvAsDuration("AUTH_REFRESH_TOKEN_TTL", defaultAuthRefreshTokenTTL),
+			Admins:            getEnvAsSlice("AUTH_ADMINS", nil),
+			PasswordResetTTL:  getEnvAsDuration("AUTH_PASSWORD_RESET_TTL", defaultAuthPasswordResetTTL),
+			PasswordResetURL:  getEnv("AUTH_PASSWORD_RESET_URL", ""),
+		},
+		User: &User{
+			PurgeRetention:        getEnvAsDuration("USER_PURGE_RETENTION", defaultUserPurgeRetention),
+			VerificationTTL:       getEnvAsDuration("USER_VERIFICATION_TTL", defaultUserVerificationTTL),
+			VerificationURL:       getEnv("USER_VERIFICATION_URL", ""),
+			UnverifiedLoginPeriod: getEnvAsDuration("USER_UNVERIFIED_LOGIN_PERIOD", defaultUserUnverifiedLoginPeriod),
+		},
+		Lockout: &Lockout{
+			Threshold:    getEnvAsInt("LOCKOUT_THRESHOLD", defaultLockoutThreshold),
+			IPThreshold:  getEnvAsInt("LOCKOUT_IP_THRESHOLD", defaultLockoutIPThreshold),
+			Window:       getEnvAsDuration("LOCKOUT_WINDOW", defaultLockoutWindow),
+			Duration:     getEnvAsDuration("LOCKOUT_DURATION", defaultLockoutDuration),
+			BackoffAfter: getEnvAsInt("LOCKOUT_BACKOFF_AFTER", defaultLockoutBackoffAfter),
+			BackoffBase:  getEnvAsDuration("LOCKOUT_BACKOFF_BASE", defaultLockoutBackoffBase),
+			BackoffMax:   getEnvAsDuration("LOCKOUT_BACKOFF_MAX", defaultLockoutBackoffMax),
+			FailOpen:     getEnvAsBool("LOCKOUT_FAIL_OPEN", defaultLockoutFailOpen),
+		},
+		Telemetry: &Telemetry{
+			Exporter:     getEnv("TELEMETRY_EXPORTER", defaultTelemetryExporter),
+			OTLPEndpoint: getEnv("TELEMETRY_OTLP_ENDPOINT", defaultTelemetryOTLPEndpoint),
+			OTLPInsecure: getEnvAsBool("TELEMETRY_OTLP_INSECURE", true),
+			SampleRatio:  getEnvAsFloat("TELEMETRY_SAMPLE_RATIO", defaultTelemetrySampleRatio),
+		},
+		Metrics: &Metrics{
+			Address: getEnv("METRICS_ADDRESS", defaultMetricsAddress),
+			Path:    getEnv("METRICS_PATH", defaultMetricsPath),
+		},
+		Health: &Health{
+			CheckTimeout: getEnvAsDuration("HEALTH_CHECK_TIMEOUT", defaultHealthCheckTimeout),
+			CacheTTL:     getEnvAsDuration("HEALTH_CACHE_TTL", defaultHealthCacheTTL),
+			DrainDelay:   getEnvAsDuration("HEALTH_DRAIN_DELAY", defaultHealthDrainDelay),
+		},
+		RateLimit: &RateLimit{
+			Algorithm: getEnv("RATELIMIT_ALGORITHM", defaultRateLimitAlgorithm),
+			Key:       getEnv("RATELIMIT_KEY", defaultRateLimitKey),
+			Routes:    getEnv("RATELIMIT_ROUTES", defaultRateLimitRoutes),
+		},
+		Idempotency: &Idempotency{
+			Store:       getEnv("IDEMPOTENCY_STORE", ""),
+			Retention:   getEnvAsDuration("IDEMPOTENCY_RETENTION", defaultIdempotencyRetention),
+			LockTimeout: getEnvAsDuration("IDEMPOTENCY_LOCK_TIMEOUT", defaultIdempotencyLockTimeout),
+		},
+		EventBus: &EventBus{
+			Workers:   getEnvAsInt("EVENTBUS_WORKERS", defaultEventBusWorkers),
+			QueueSize: getEnvAsInt("EVENTBUS_QUEUE_SIZE", defaultEventBusQueueSize),
+		},
+		Outbox: &Outbox{
+			Publisher:    getEnv("OUTBOX_PUBLISHER", defaultOutboxPublisher),
+			PollInterval: getEnvAsDuration("OUTBOX_POLL_INTERVAL", defaultOutboxPollInterval),
+			BatchSize:    getEnvAsInt("OUTBOX_BATCH_SIZE", defaultOutboxBatchSize),
+			MaxAttempts:  getEnvAsInt("OUTBOX_MAX_ATTEMPTS", defaultOutboxMaxAttempts),
+			BackoffBase:  getEnvAsDuration("OUTBOX_BACKOFF_BASE", defaultOutboxBackoffBase),
+			BackoffMax:   getEnvAsDuration("OUTBOX_BACKOFF_MAX", defaultOutboxBackoffMax),
+			Retention:    getEnvAsDuration("OUTBOX_RETENTION", defaultOutboxRetention),
+		},
+		Mailer: &Mailer{
+			Driver:       getEnv("MAILER_DRIVER", defaultMailerDriver),
+			From:         getEnv("MAILER_FROM", defaultMailerFrom),
+			Directory:    getEnv("MAILER_DIRECTORY", defaultMailerDirectory),
+			SMTPHost:     getEnv("MAILER_SMTP_HOST", ""),
+			SMTPPort:     getEnvAsInt("MAILER_SMTP_PORT", defaultMailerSMTPPort),
+			SMTPUsername: getEnv("MAILER_SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("MAILER_SMTP_PASSWORD", ""),
+			Timeout:      getEnvAsDuration("MAILER_TIMEOUT", defaultMailerTimeout),
 		},
 	}
 }
@@ -176,6 +552,14 @@ func getEnvAsBool(name string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getEnvAsFloat(name string, defaultValue float64) float64 {
+	valStr := getEnv(name, "")
+	if val, err := strconv.ParseFloat(valStr, 64); err == nil {
+		return val
+	}
+	return defaultValue
+}
+
 func getEnvAsDuration(name string, defaultValue time.Duration) time.Duration {
 	valStr := getEnv(name, "")
 	if val, err := time.ParseDuration(valStr); err == nil {
@@ -184,6 +568,21 @@ func getEnvAsDuration(name string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+func getEnvAsSlice(name string, defaultValue []string) []string {
+	valStr := getEnv(name, "")
+	if valStr == "" {
+		return defaultValue
+	}
+
+	values := make([]string, 0)
+	for _, v := range strings.Split(valStr, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 func getEnvPath() *string {
 	configPath := flag.String(
 		"env",
@@ -93,6 +93,15 @@ func newDatabase(cfg *config.DB, log logger.Interface) (*Postgres, error) {
 	return pg, nil
 }
 
+// Ping checks that a connection can be acquired and used; it is the
+// readiness check of the database.
+func (p *Postgres) Ping(ctx context.Context) error {
+	if err := p.Pool.Ping(ctx); err != nil {
+		return fmt.Errorf("postgres - Ping: %w", err)
+	}
+	return nil
+}
+
 func (p *Postgres) Close() {
 	if p.Pool != nil {
 		p.Pool.Close()
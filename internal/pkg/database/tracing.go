@@ -0,0 +1,129 @@
+package database
+
+import (
+	"app/internal/pkg/telemetry"
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "app/internal/pkg/database"
+
+// rowsAffected is recorded on query spans; it is not part of the semantic
+// conventions yet.
+const rowsAffected = attribute.Key("db.rows_affected")
+
+// tracedQuerier starts a client span for every statement. Only the SQL text
+// is recorded, never the arguments, which may hold secrets.
+type tracedQuerier struct {
+	querier Querier
+	tracer  trace.Tracer
+}
+
+var _ Querier = (*tracedQuerier)(nil)
+
+func traced(querier Querier) Querier {
+	return &tracedQuerier{querier: querier, tracer: telemetry.Tracer(tracerName)}
+}
+
+func (q *tracedQuerier) start(ctx context.Context, sql string) (context.Context, trace.Span) {
+	return q.tracer.Start(ctx, operation(sql),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBQueryText(sql),
+		),
+	)
+}
+
+func (q *tracedQuerier) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := q.start(ctx, sql)
+	tag, err := q.querier.Exec(ctx, sql, arguments...)
+	if err == nil {
+		span.SetAttributes(rowsAffected.Int64(tag.RowsAffected()))
+	}
+	telemetry.End(span, err)
+
+	return tag, err
+}
+
+func (q *tracedQuerier) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := q.start(ctx, sql)
+	rows, err := q.querier.Query(ctx, sql, args...)
+	if err != nil {
+		telemetry.End(span, err)
+		return nil, err
+	}
+
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (q *tracedQuerier) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	ctx, span := q.start(ctx, sql)
+	return &tracedRow{row: q.querier.QueryRow(ctx, sql, args...), span: span}
+}
+
+// tracedRows ends the span when the result set is closed, which is when
+// the statement has actually finished.
+type tracedRows struct {
+	pgx.Rows
+	span  trace.Span
+	count int64
+	ended bool
+}
+
+func (r *tracedRows) Next() bool {
+	if r.Rows.Next() {
+		r.count++
+		return true
+	}
+	r.end()
+	return false
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	r.end()
+}
+
+func (r *tracedRows) end() {
+	if r.ended {
+		return
+	}
+	r.ended = true
+
+	r.span.SetAttributes(rowsAffected.Int64(r.count))
+	telemetry.End(r.span, r.Rows.Err())
+}
+
+type tracedRow struct {
+	row  pgx.Row
+	span trace.Span
+}
+
+func (r *tracedRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		telemetry.End(r.span, nil)
+		return err
+	}
+	telemetry.End(r.span, err)
+
+	return err
+}
+
+// operation names the span after the statement's leading keyword, e.g.
+// SELECT or INSERT, so that span names stay few.
+func operation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "SQL"
+	}
+	return strings.ToUpper(fields[0])
+}
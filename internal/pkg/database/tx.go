@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+
+	// maxTxAttempts bounds how often a transaction is run again after
+	// losing a serialization conflict or a deadlock.
+	maxTxAttempts = 5
+	txRetryDelay  = 10 * time.Millisecond
+)
+
+type (
+	txKey          struct{}
+	afterCommitKey struct{}
+)
+
+// afterCommit collects the functions to run once the outermost transaction
+// commits. A savepoint has its own, handed up to its parent when released.
+type afterCommit struct {
+	fns []func(ctx context.Context)
+}
+
+// Transactor runs a function inside a database transaction. Repositories
+// called with the context handed to fn take part in the transaction.
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+var _ Transactor = (*Postgres)(nil)
+
+// InTx runs fn in a read committed transaction; see InTxWithOptions.
+func (p *Postgres) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return p.InTxWithOptions(ctx, pgx.TxOptions{}, fn)
+}
+
+// InTxWithOptions runs fn in a transaction that commits if fn returns nil
+// and rolls back otherwise. When ctx already carries a transaction, fn runs
+// in a savepoint of it instead and opts are ignored: an error then only
+// undoes what fn did. The outermost transaction is run again from the start
+// when it fails with a serialization failure or a deadlock, so fn must not
+// have effects outside the database.
+func (p *Postgres) InTxWithOptions(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return p.run(ctx, tx.Begin, fn)
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = p.run(ctx, func(ctx context.Context) (pgx.Tx, error) {
+			return p.Pool.BeginTx(ctx, opts)
+		}, fn)
+
+		if !retryable(err) || attempt == maxTxAttempts {
+			break
+		}
+
+		delay := txRetryDelay<<(attempt-1) + rand.N(txRetryDelay)
+		p.logger.Warn(fmt.Sprintf("postgres - InTx - attempt %d failed, retrying in %s: %v", attempt, delay, err))
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+
+	return err
+}
+
+func (p *Postgres) run(ctx context.Context, begin func(ctx context.Context) (pgx.Tx, error), fn func(ctx context.Context) error) (err error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("postgres - InTx - begin: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback(ctx)
+			panic(r)
+		}
+	}()
+
+	hooks := &afterCommit{}
+	txCtx := context.WithValue(context.WithValue(ctx, txKey{}, tx), afterCommitKey{}, hooks)
+
+	if err = fn(txCtx); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			return errors.Join(err, fmt.Errorf("postgres - InTx - rollback: %w", rbErr))
+		}
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("postgres - InTx - commit: %w", err)
+	}
+
+	if parent, ok := ctx.Value(afterCommitKey{}).(*afterCommit); ok {
+		parent.fns = append(parent.fns, hooks.fns...)
+		return nil
+	}
+
+	for _, fn := range hooks.fns {
+		fn(ctx)
+	}
+
+	return nil
+}
+
+// AfterCommit runs fn once the transaction carried by ctx has committed, or
+// at once when there is none. fn is dropped when the transaction rolls back,
+// and runs only once even if the transaction is retried.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if hooks, ok := ctx.Value(afterCommitKey{}).(*afterCommit); ok {
+		hooks.fns = append(hooks.fns, fn)
+		return
+	}
+
+	fn(ctx)
+}
+
+// InTransaction reports whether ctx carries a transaction.
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return ok
+}
+
+// Conn returns the transaction carried by ctx, or the pool when there is
+// none. Repositories run their queries on it. Every statement is traced.
+func (p *Postgres) Conn(ctx context.Context) Querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return traced(tx)
+	}
+
+	return traced(p.Pool)
+}
+
+func retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected)
+}
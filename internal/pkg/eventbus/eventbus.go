@@ -0,0 +1,226 @@
+package eventbus
+
+import (
+	"app/internal/pkg/config"
+	"app/internal/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"runtime/debug"
+	"slices"
+	"sync"
+)
+
+var ErrClosed = errors.New("eventbus: bus is closed")
+
+// Event is anything published on the bus. Asynchronous handlers see the
+// events of one aggregate in the order they were published.
+type Event interface {
+	AggregateID() string
+}
+
+// Handler reacts to events of type E.
+type Handler[E Event] func(ctx context.Context, event E) error
+
+// Publisher is the side of the bus usecases depend on.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+type Option func(*subscription)
+
+// Async delivers events to the handler on the worker pool instead of in the
+// publishing goroutine. Publish then does not wait for the handler and does
+// not see its error, which is logged instead.
+func Async() Option {
+	return func(s *subscription) {
+		s.async = true
+	}
+}
+
+type subscription struct {
+	name  string
+	async bool
+	call  func(ctx context.Context, event Event) error
+}
+
+type delivery struct {
+	ctx   context.Context
+	event Event
+	sub   *subscription
+}
+
+var _ Publisher = (*Bus)(nil)
+
+// Bus dispatches events to the handlers subscribed to their type. Handlers
+// are isolated from each other: an error or a panic in one is reported and
+// the others still run.
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions map[reflect.Type][]*subscription
+	shards        []chan delivery
+	closed        bool
+	// done is closed with closed set, releasing publishers waiting for room.
+	done chan struct{}
+	// sending counts publishers that may still queue, so that the shards
+	// are only closed once none is left.
+	sending sync.WaitGroup
+	logger  logger.Interface
+}
+
+func New(cfg *config.EventBus, logger logger.Interface) (*Bus, error) {
+	if cfg == nil {
+		return nil, errors.New("eventbus.New: cfg is null")
+	}
+
+	if logger == nil {
+		return nil, errors.New("eventbus.New: logger is null")
+	}
+
+	if cfg.Workers <= 0 || cfg.QueueSize <= 0 {
+		return nil, errors.New("eventbus.New: workers and queue size must be positive")
+	}
+
+	shards := make([]chan delivery, cfg.Workers)
+	for i := range shards {
+		shards[i] = make(chan delivery, cfg.QueueSize)
+	}
+
+	return &Bus{
+		subscriptions: make(map[reflect.Type][]*subscription),
+		shards:        shards,
+		done:          make(chan struct{}),
+		logger:        logger,
+	}, nil
+}
+
+// Subscribe registers handler for events of type E under name, which
+// identifies it in logs.
+func Subscribe[E Event](b *Bus, name string, handler Handler[E], opts ...Option) {
+	sub := &subscription{
+		name: name,
+		call: func(ctx context.Context, event Event) error {
+			return handler(ctx, event.(E))
+		},
+	}
+	for _, opt := range opts {
+		opt(sub)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	typ := reflect.TypeFor[E]()
+	b.subscriptions[typ] = append(b.subscriptions[typ], sub)
+}
+
+// Publish runs the synchronous handlers of event and queues it for the
+// asynchronous ones. It returns the errors of the synchronous handlers; a
+// full queue makes it wait until there is room, ctx is done or the bus is
+// closed. No lock is held meanwhile, so handlers may publish and subscribe.
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	if event == nil {
+		return errors.New("eventbus.Publish: event is null")
+	}
+
+	b.mu.RLock()
+	subs := slices.Clone(b.subscriptions[reflect.TypeOf(event)])
+	closed := b.closed
+	queues := !closed && slices.ContainsFunc(subs, func(sub *subscription) bool { return sub.async })
+	if queues {
+		b.sending.Add(1)
+	}
+	b.mu.RUnlock()
+
+	if queues {
+		defer b.sending.Done()
+	}
+
+	var errs []error
+	for _, sub := range subs {
+		if !sub.async {
+			if err := b.deliver(ctx, event, sub); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if closed {
+			errs = append(errs, fmt.Errorf("eventbus.Publish: %s: %w", sub.name, ErrClosed))
+			continue
+		}
+
+		select {
+		case b.shards[b.shard(event.AggregateID())] <- delivery{ctx: context.WithoutCancel(ctx), event: event, sub: sub}:
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("eventbus.Publish: %s: %w", sub.name, ctx.Err()))
+		case <-b.done:
+			errs = append(errs, fmt.Errorf("eventbus.Publish: %s: %w", sub.name, ErrClosed))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run delivers queued events until ctx is done. It then stops accepting
+// asynchronous deliveries and returns once the queued ones are handled.
+func (b *Bus) Run(ctx context.Context) {
+	var workers sync.WaitGroup
+	for _, shard := range b.shards {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for d := range shard {
+				if err := b.deliver(d.ctx, d.event, d.sub); err != nil {
+					b.logger.Error(err.Error())
+				}
+			}
+		}()
+	}
+
+	<-ctx.Done()
+
+	b.mu.Lock()
+	b.closed = true
+	close(b.done)
+	b.mu.Unlock()
+
+	// Publishers that saw the bus open either queue or give up on done;
+	// sending to a closed shard would panic.
+	b.sending.Wait()
+	for _, shard := range b.shards {
+		close(shard)
+	}
+
+	workers.Wait()
+}
+
+// deliver calls the handler, turning a panic into an error.
+func (b *Bus) deliver(ctx context.Context, event Event, sub *subscription) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("event handler panicked",
+				logger.NewField("handler", sub.name),
+				logger.NewField("event", reflect.TypeOf(event).String()),
+				logger.NewField("aggregate_id", event.AggregateID()),
+				logger.NewField("panic", fmt.Sprint(r)),
+				logger.NewField("stack", string(debug.Stack())),
+			)
+			err = fmt.Errorf("eventbus: %s: panic: %v", sub.name, r)
+		}
+	}()
+
+	if err = sub.call(ctx, event); err != nil {
+		return fmt.Errorf("eventbus: %s: %w", sub.name, err)
+	}
+
+	return nil
+}
+
+func (b *Bus) shard(aggregateID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(aggregateID))
+	return int(h.Sum32() % uint32(len(b.shards)))
+}
@@ -0,0 +1,184 @@
+package eventbus_test
+
+import (
+	"app/internal/pkg/config"
+	"app/internal/pkg/eventbus"
+	"app/internal/pkg/logger"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...logger.Field) {}
+func (nopLogger) Info(string, ...logger.Field)  {}
+func (nopLogger) Warn(string, ...logger.Field)  {}
+func (nopLogger) Error(string, ...logger.Field) {}
+func (nopLogger) Fatal(string, ...logger.Field) {}
+
+type created struct {
+	id string
+}
+
+func (e created) AggregateID() string { return e.id }
+
+type followUp struct {
+	id string
+}
+
+func (e followUp) AggregateID() string { return e.id }
+
+func newBus(t *testing.T, workers int, queueSize int) *eventbus.Bus {
+	t.Helper()
+
+	bus, err := eventbus.New(&config.EventBus{Workers: workers, QueueSize: queueSize}, nopLogger{})
+	if err != nil {
+		t.Fatalf("eventbus.New: %v", err)
+	}
+	return bus
+}
+
+// run starts the bus and returns a function stopping it, which fails the
+// test if the bus does not stop in time.
+func run(t *testing.T, bus *eventbus.Bus) func() {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		bus.Run(ctx)
+		close(done)
+	}()
+
+	return func() {
+		t.Helper()
+
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Run did not return after its context was done")
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	bus := newBus(t, 2, 4)
+
+	var mu sync.Mutex
+	var calls []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, name)
+	}
+
+	failure := errors.New("mail server is down")
+	eventbus.Subscribe(bus, "sync", func(ctx context.Context, e created) error {
+		record("sync")
+		return failure
+	})
+	eventbus.Subscribe(bus, "panics", func(ctx context.Context, e created) error {
+		panic("boom")
+	})
+	eventbus.Subscribe(bus, "async", func(ctx context.Context, e created) error {
+		record("async")
+		return failure
+	}, eventbus.Async())
+
+	stop := run(t, bus)
+
+	err := bus.Publish(context.Background(), created{id: "u1"})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Publish() error = %v, want the synchronous handler's %v", err, failure)
+	}
+
+	stop()
+
+	if len(calls) != 2 || calls[0] != "sync" || calls[1] != "async" {
+		t.Fatalf("calls = %v, want [sync async]", calls)
+	}
+
+	if err = bus.Publish(context.Background(), created{id: "u1"}); !errors.Is(err, eventbus.ErrClosed) {
+		t.Fatalf("Publish() after Run returned error = %v, want %v", err, eventbus.ErrClosed)
+	}
+}
+
+func TestPublishFromHandler(t *testing.T) {
+	bus := newBus(t, 1, 1)
+
+	followed := make(chan struct{})
+	eventbus.Subscribe(bus, "follow", func(ctx context.Context, e created) error {
+		// Subscribing and publishing from a handler takes the bus lock.
+		eventbus.Subscribe(bus, "noop", func(ctx context.Context, e followUp) error { return nil })
+		return bus.Publish(ctx, followUp{id: e.id})
+	})
+	eventbus.Subscribe(bus, "followed", func(ctx context.Context, e followUp) error {
+		close(followed)
+		return nil
+	}, eventbus.Async())
+
+	stop := run(t, bus)
+	defer stop()
+
+	if err := bus.Publish(context.Background(), created{id: "u1"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	select {
+	case <-followed:
+	case <-time.After(time.Second):
+		t.Fatalf("follow-up event was not handled")
+	}
+}
+
+func TestRunReleasesBlockedPublishers(t *testing.T) {
+	bus := newBus(t, 1, 1)
+
+	started := make(chan struct{}, 4)
+	release := make(chan struct{})
+	eventbus.Subscribe(bus, "slow", func(ctx context.Context, e created) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}, eventbus.Async())
+
+	stop := run(t, bus)
+
+	// The first event keeps the only worker busy and the second fills the
+	// queue, so the third waits for room.
+	if err := bus.Publish(context.Background(), created{id: "u1"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	<-started
+	if err := bus.Publish(context.Background(), created{id: "u1"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- bus.Publish(context.Background(), created{id: "u1"})
+	}()
+
+	// A waiting publisher must not hold up other publishers or subscribers.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := bus.Publish(ctx, created{id: "u1"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Publish() to a full queue error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	eventbus.Subscribe(bus, "late", func(ctx context.Context, e followUp) error { return nil })
+
+	go func() {
+		// Let the queued event through once shutdown has begun.
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	stop()
+
+	if err := <-blocked; err != nil && !errors.Is(err, eventbus.ErrClosed) {
+		t.Fatalf("blocked Publish() error = %v, want nil or %v", err, eventbus.ErrClosed)
+	}
+}
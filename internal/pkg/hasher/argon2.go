@@ -0,0 +1,124 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2Prefix = "$" + Argon2id + "$"
+
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Argon2Hasher produces PHC strings of the form
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>.
+type Argon2Hasher struct {
+	params Argon2Params
+}
+
+var _ Interface = (*Argon2Hasher)(nil)
+
+func NewArgon2(params Argon2Params) *Argon2Hasher {
+	return &Argon2Hasher{params: params}
+}
+
+func (a *Argon2Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, a.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("hasher.Argon2Hasher.Hash: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.params.Iterations, a.params.Memory, a.params.Parallelism, a.params.KeyLength)
+
+	return fmt.Sprintf(
+		"$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		Argon2id,
+		argon2.Version,
+		a.params.Memory,
+		a.params.Iterations,
+		a.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a *Argon2Hasher) Verify(password string, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (a *Argon2Hasher) NeedsRehash(encoded string) bool {
+	params, _, _, err := decodeArgon2(encoded)
+	if err != nil {
+		return true
+	}
+
+	return params != a.params
+}
+
+func (a *Argon2Hasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, argon2Prefix)
+}
+
+func (a *Argon2Hasher) validate() error {
+	if a.params.Memory == 0 || a.params.Iterations == 0 || a.params.Parallelism == 0 {
+		return errors.New("argon2id: memory, iterations and parallelism must be positive")
+	}
+
+	if a.params.SaltLength < 8 || a.params.KeyLength < 16 {
+		return errors.New("argon2id: salt must be at least 8 bytes and key at least 16 bytes")
+	}
+
+	return nil
+}
+
+func decodeArgon2(encoded string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != Argon2id {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
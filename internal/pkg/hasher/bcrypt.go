@@ -0,0 +1,66 @@
+package hasher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher relies on the modular crypt format of bcrypt, which already
+// carries the cost and the salt: $2a$<cost>$<salt+digest>.
+type BcryptHasher struct {
+	cost int
+}
+
+var _ Interface = (*BcryptHasher)(nil)
+
+func NewBcrypt(cost int) *BcryptHasher {
+	return &BcryptHasher{cost: cost}
+}
+
+func (b *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	if err != nil {
+		return "", fmt.Errorf("hasher.BcryptHasher.Hash: %w", err)
+	}
+
+	return string(hash), nil
+}
+
+func (b *BcryptHasher) Verify(password string, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+
+		return false, ErrInvalidHash
+	}
+
+	return true, nil
+}
+
+func (b *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return true
+	}
+
+	return cost != b.cost
+}
+
+func (b *BcryptHasher) Supports(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+func (b *BcryptHasher) validate() error {
+	if b.cost < bcrypt.MinCost || b.cost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt: cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	return nil
+}
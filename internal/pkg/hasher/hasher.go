@@ -0,0 +1,105 @@
+package hasher
+
+import (
+	"app/internal/pkg/config"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("hasher: unsupported algorithm")
+	ErrInvalidHash          = errors.New("hasher: invalid encoded hash")
+)
+
+// Interface hashes passwords into self-describing strings: the algorithm and
+// its parameters are encoded alongside the salt and the digest, so a stored
+// value can always be verified even after the configuration has changed.
+type Interface interface {
+	Hash(password string) (string, error)
+	Verify(password string, encoded string) (bool, error)
+	NeedsRehash(encoded string) bool
+}
+
+type algorithm interface {
+	Interface
+	Supports(encoded string) bool
+	validate() error
+}
+
+var _ Interface = (*Hasher)(nil)
+
+// Hasher hashes with the configured algorithm and verifies values produced by
+// any supported one, reporting outdated values through NeedsRehash.
+type Hasher struct {
+	primary    algorithm
+	algorithms []algorithm
+}
+
+func New(cfg *config.Hasher) (*Hasher, error) {
+	if cfg == nil {
+		return nil, errors.New("hasher.New: cfg is null")
+	}
+
+	argon := NewArgon2(Argon2Params{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+		SaltLength:  cfg.Argon2SaltLength,
+		KeyLength:   cfg.Argon2KeyLength,
+	})
+	bcrypt := NewBcrypt(cfg.BcryptCost)
+
+	h := &Hasher{algorithms: []algorithm{argon, bcrypt}}
+
+	switch strings.ToLower(cfg.Algorithm) {
+	case Argon2id:
+		h.primary = argon
+	case Bcrypt:
+		h.primary = bcrypt
+	default:
+		return nil, fmt.Errorf("hasher.New: %w: %s", ErrUnsupportedAlgorithm, cfg.Algorithm)
+	}
+
+	if err := h.primary.validate(); err != nil {
+		return nil, fmt.Errorf("hasher.New: %w", err)
+	}
+
+	return h, nil
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.primary.Hash(password)
+}
+
+func (h *Hasher) Verify(password string, encoded string) (bool, error) {
+	a, err := h.lookup(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	return a.Verify(password, encoded)
+}
+
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	if !h.primary.Supports(encoded) {
+		return true
+	}
+
+	return h.primary.NeedsRehash(encoded)
+}
+
+func (h *Hasher) lookup(encoded string) (algorithm, error) {
+	for _, a := range h.algorithms {
+		if a.Supports(encoded) {
+			return a, nil
+		}
+	}
+
+	return nil, ErrInvalidHash
+}
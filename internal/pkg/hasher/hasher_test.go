@@ -0,0 +1,129 @@
+package hasher_test
+
+import (
+	"app/internal/pkg/config"
+	"app/internal/pkg/hasher"
+	"errors"
+	"testing"
+)
+
+// Cheap parameters keep the tests fast; they are not meant for production.
+func newHasher(t *testing.T, algorithm string, memory uint32, cost int) *hasher.Hasher {
+	t.Helper()
+
+	h, err := hasher.New(&config.Hasher{
+		Algorithm:         algorithm,
+		Argon2Memory:      memory,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		Argon2SaltLength:  16,
+		Argon2KeyLength:   32,
+		BcryptCost:        cost,
+	})
+	if err != nil {
+		t.Fatalf("hasher.New: %v", err)
+	}
+	return h
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.Hasher
+		err  bool
+	}{
+		{"no config", nil, true},
+		{"unknown algorithm", &config.Hasher{Algorithm: "md5"}, true},
+		{"argon2id without memory", &config.Hasher{Algorithm: hasher.Argon2id, Argon2Iterations: 1, Argon2Parallelism: 1, Argon2SaltLength: 16, Argon2KeyLength: 32}, true},
+		{"argon2id with short salt", &config.Hasher{Algorithm: hasher.Argon2id, Argon2Memory: 64, Argon2Iterations: 1, Argon2Parallelism: 1, Argon2SaltLength: 4, Argon2KeyLength: 32}, true},
+		{"bcrypt with low cost", &config.Hasher{Algorithm: hasher.Bcrypt, BcryptCost: 1}, true},
+		{"bcrypt", &config.Hasher{Algorithm: hasher.Bcrypt, BcryptCost: 4}, false},
+		{"algorithm in upper case", &config.Hasher{Algorithm: "ARGON2ID", Argon2Memory: 64, Argon2Iterations: 1, Argon2Parallelism: 1, Argon2SaltLength: 16, Argon2KeyLength: 32}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := hasher.New(tt.cfg)
+			if (err != nil) != tt.err {
+				t.Fatalf("hasher.New() error = %v, want error %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	argon := newHasher(t, hasher.Argon2id, 64, 4)
+	bcrypt := newHasher(t, hasher.Bcrypt, 64, 4)
+
+	argonHash, err := argon.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	bcryptHash, err := bcrypt.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		encoded  string
+		ok       bool
+		err      error
+	}{
+		{"argon2id match", "correct horse", argonHash, true, nil},
+		{"argon2id mismatch", "wrong horse", argonHash, false, nil},
+		{"bcrypt hash verified by argon2id hasher", "correct horse", bcryptHash, true, nil},
+		{"bcrypt mismatch", "wrong horse", bcryptHash, false, nil},
+		{"unknown format", "correct horse", "plain", false, hasher.ErrInvalidHash},
+		{"malformed argon2id", "correct horse", "$argon2id$v=19$m=x$salt$key", false, hasher.ErrInvalidHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := argon.Verify(tt.password, tt.encoded)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.err)
+			}
+			if ok != tt.ok {
+				t.Fatalf("Verify() = %v, want %v", ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	argon := newHasher(t, hasher.Argon2id, 64, 4)
+
+	current, err := argon.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	weaker, err := newHasher(t, hasher.Argon2id, 32, 4).Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	bcrypt, err := newHasher(t, hasher.Bcrypt, 64, 4).Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+		rehash  bool
+	}{
+		{"current parameters", current, false},
+		{"other argon2id parameters", weaker, true},
+		{"other algorithm", bcrypt, true},
+		{"malformed", "$argon2id$broken", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := argon.NeedsRehash(tt.encoded); got != tt.rehash {
+				t.Fatalf("NeedsRehash() = %v, want %v", got, tt.rehash)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// LiveHandler answers as long as the process serves HTTP. It does not look
+// at dependencies: restarting the service would not bring them back.
+func (h *Health) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		write(w, http.StatusOK, Report{Status: StatusUp})
+	})
+}
+
+// ReadyHandler answers 200 when every check passes and 503 with the failing
+// checks otherwise.
+func (h *Health) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := h.Ready(r.Context())
+
+		status := http.StatusOK
+		if !report.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+		write(w, status, report)
+	})
+}
+
+func write(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,198 @@
+package health
+
+import (
+	"app/internal/pkg/config"
+	"app/internal/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+var ErrDraining = errors.New("server is shutting down")
+
+// Checker reports whether a dependency can serve requests.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function, such as a Ping method, to Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result is the outcome of one check. Only the status is served: errors
+// may name hosts, users or queries, so they are logged instead.
+type Result struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"-"`
+	Duration  string    `json:"-"`
+	CheckedAt time.Time `json:"-"`
+}
+
+// Report is the readiness of the service with the result of every check.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+func (r Report) Ready() bool {
+	return r.Status == StatusUp
+}
+
+// Health aggregates the checks registered by the initializer and adapters.
+// Results are cached for a short while, so that frequent probes from several
+// load balancers do not each hit the database.
+type Health struct {
+	timeout    time.Duration
+	ttl        time.Duration
+	drainDelay time.Duration
+	logger     logger.Interface
+
+	mu       sync.RWMutex
+	checks   map[string]*check
+	draining atomic.Bool
+}
+
+func New(cfg *config.Health, logger logger.Interface) (*Health, error) {
+	if cfg == nil || cfg.CheckTimeout <= 0 {
+		return nil, errors.New("health.New: cfg is null or has no check timeout")
+	}
+
+	if logger == nil {
+		return nil, errors.New("health.New: logger is null")
+	}
+
+	return &Health{
+		timeout:    cfg.CheckTimeout,
+		ttl:        cfg.CacheTTL,
+		drainDelay: cfg.DrainDelay,
+		logger:     logger,
+		checks:     make(map[string]*check),
+	}, nil
+}
+
+// Register adds a check under name, replacing one registered before.
+func (h *Health) Register(name string, checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks[name] = &check{checker: checker}
+}
+
+// Drain marks the service as not ready for good and waits for the drain
+// delay. It is called as soon as shutdown begins, so that load balancers
+// stop sending traffic before the server stops accepting it.
+func (h *Health) Drain(ctx context.Context) {
+	h.draining.Store(true)
+	if h.drainDelay <= 0 {
+		return
+	}
+
+	h.logger.Info(fmt.Sprintf("Reporting not ready for %s before shutting down", h.drainDelay))
+
+	timer := time.NewTimer(h.drainDelay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+func (h *Health) Draining() bool {
+	return h.draining.Load()
+}
+
+// Ready runs all checks concurrently, each bounded by the check timeout.
+func (h *Health) Ready(ctx context.Context) Report {
+	if h.Draining() {
+		return Report{Status: StatusDown, Checks: map[string]Result{
+			"shutdown": {Status: StatusDown, Error: ErrDraining.Error(), CheckedAt: time.Now()},
+		}}
+	}
+
+	h.mu.RLock()
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]*check, len(names))
+	for i, name := range names {
+		checks[i] = h.checks[name]
+	}
+	h.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result, fresh := c.run(ctx, h.timeout, h.ttl)
+			if fresh && result.Status != StatusUp {
+				h.logger.Warn(fmt.Sprintf("health.Ready, check %s failed after %s: %s", names[i], result.Duration, result.Error))
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks))}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+type check struct {
+	checker Checker
+
+	mu      sync.Mutex
+	last    Result
+	expires time.Time
+}
+
+// run returns the cached result while it is fresh, reporting whether the
+// check actually ran. Concurrent callers wait for the one check in flight
+// rather than starting their own.
+func (c *check) run(ctx context.Context, timeout time.Duration, ttl time.Duration) (Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Now().Before(c.expires) {
+		return c.last, false
+	}
+
+	// A probe that hangs up must not cache a cancelled check.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.checker.Check(ctx)
+
+	c.last = Result{Status: StatusUp, Duration: time.Since(start).String(), CheckedAt: start}
+	if err != nil {
+		c.last.Status = StatusDown
+		c.last.Error = err.Error()
+	}
+	c.expires = time.Now().Add(ttl)
+
+	return c.last, true
+}
@@ -0,0 +1,115 @@
+package health_test
+
+import (
+	"app/internal/pkg/config"
+	"app/internal/pkg/health"
+	"app/internal/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingLogger keeps the warnings logged.
+type recordingLogger struct {
+	warnings []string
+}
+
+func (l *recordingLogger) Debug(string, ...logger.Field)       {}
+func (l *recordingLogger) Info(string, ...logger.Field)        {}
+func (l *recordingLogger) Warn(msg string, _ ...logger.Field)  { l.warnings = append(l.warnings, msg) }
+func (l *recordingLogger) Error(msg string, _ ...logger.Field) {}
+func (l *recordingLogger) Fatal(msg string, _ ...logger.Field) {}
+
+func newHealth(t *testing.T, log logger.Interface, ttl time.Duration) *health.Health {
+	t.Helper()
+
+	h, err := health.New(&config.Health{CheckTimeout: time.Second, CacheTTL: ttl}, log)
+	if err != nil {
+		t.Fatalf("health.New: %v", err)
+	}
+	return h
+}
+
+func TestReadyHandler(t *testing.T) {
+	secret := "dial tcp db.internal:5432: password authentication failed for user \"app\""
+
+	tests := []struct {
+		name   string
+		redis  error
+		drain  bool
+		status int
+		body   string
+	}{
+		{
+			name:   "all up",
+			status: http.StatusOK,
+			body:   `{"status":"up","checks":{"postgres":{"status":"up"},"redis":{"status":"up"}}}`,
+		},
+		{
+			name:   "one down",
+			redis:  errors.New(secret),
+			status: http.StatusServiceUnavailable,
+			body:   `{"status":"down","checks":{"postgres":{"status":"up"},"redis":{"status":"down"}}}`,
+		},
+		{
+			name:   "draining",
+			drain:  true,
+			status: http.StatusServiceUnavailable,
+			body:   `{"status":"down","checks":{"shutdown":{"status":"down"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &recordingLogger{}
+			h := newHealth(t, log, 0)
+			h.Register("postgres", health.CheckerFunc(func(ctx context.Context) error { return nil }))
+			h.Register("redis", health.CheckerFunc(func(ctx context.Context) error { return tt.redis }))
+			if tt.drain {
+				h.Drain(context.Background())
+			}
+
+			rec := httptest.NewRecorder()
+			h.ReadyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if body := strings.TrimSpace(rec.Body.String()); body != tt.body {
+				t.Fatalf("body = %s, want %s", body, tt.body)
+			}
+
+			if tt.redis != nil {
+				if len(log.warnings) != 1 || !strings.Contains(log.warnings[0], secret) {
+					t.Fatalf("warnings = %v, want the error of the redis check", log.warnings)
+				}
+			}
+		})
+	}
+}
+
+func TestReadyCachesResults(t *testing.T) {
+	h := newHealth(t, &recordingLogger{}, time.Hour)
+
+	var calls atomic.Int32
+	h.Register("postgres", health.CheckerFunc(func(ctx context.Context) error {
+		return fmt.Errorf("attempt %d failed", calls.Add(1))
+	}))
+
+	for i := 0; i < 3; i++ {
+		report := h.Ready(context.Background())
+		if report.Ready() {
+			t.Fatalf("Ready() = %v, want down", report)
+		}
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("check ran %d times, want 1 while its result is cached", n)
+	}
+}
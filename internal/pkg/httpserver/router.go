@@ -0,0 +1,110 @@
+package httpserver
+
+import (
+	"app/internal/pkg/auth"
+	"app/internal/pkg/idempotency"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/middleware"
+	"app/internal/pkg/ratelimit"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+var errNoIdentityExtractor = errors.New("httpserver.Router: identity extractor is not set")
+
+// Router registers handlers on the server mux together with the policy that
+// guards them, so that access rules live next to the route they protect.
+type Router struct {
+	mux    *http.ServeMux
+	logger logger.Interface
+
+	mu        sync.RWMutex
+	extractor middleware.IdentityExtractor
+	limiter   ratelimit.Limiter
+	limits    map[string]ratelimit.Rule
+	responses idempotency.Store
+}
+
+var (
+	_ middleware.IdentityExtractor = (*Router)(nil)
+	_ middleware.RateLimits        = (*Router)(nil)
+	_ middleware.IdempotencyStores = (*Router)(nil)
+)
+
+func NewRouter(mux *http.ServeMux, logger logger.Interface) *Router {
+	return &Router{mux: mux, logger: logger}
+}
+
+// SetIdentityExtractor sets how callers are identified. Routes may be
+// registered before it is set; it is looked up on every request.
+func (rt *Router) SetIdentityExtractor(extractor middleware.IdentityExtractor) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.extractor = extractor
+}
+
+func (rt *Router) Extract(r *http.Request) (*auth.Principal, error) {
+	rt.mu.RLock()
+	extractor := rt.extractor
+	rt.mu.RUnlock()
+
+	if extractor == nil {
+		return nil, errNoIdentityExtractor
+	}
+
+	return extractor.Extract(r)
+}
+
+// SetRateLimits limits the routes registered with the patterns in limits.
+// Like the identity extractor, limits are looked up on every request.
+func (rt *Router) SetRateLimits(limiter ratelimit.Limiter, limits map[string]ratelimit.Rule) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.limiter = limiter
+	rt.limits = limits
+}
+
+func (rt *Router) RateLimit(pattern string) (ratelimit.Limiter, ratelimit.Rule, bool) {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+
+	rule, ok := rt.limits[pattern]
+	if !ok || rt.limiter == nil {
+		return nil, ratelimit.Rule{}, false
+	}
+
+	return rt.limiter, rule, true
+}
+
+// SetIdempotencyStore makes POST routes registered with an idempotent policy
+// honour the Idempotency-Key header, keeping responses in store.
+func (rt *Router) SetIdempotencyStore(store idempotency.Store) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.responses = store
+}
+
+func (rt *Router) IdempotencyStore() idempotency.Store {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+
+	return rt.responses
+}
+
+// Handle registers handler behind, from the outside in, authorization, rate
+// limiting and, when the policy asks for it, idempotency.
+func (rt *Router) Handle(pattern string, policy middleware.Policy, handler http.Handler) {
+	if policy.Idempotent {
+		handler = middleware.Idempotency(handler, rt, rt.logger)
+	}
+	limited := middleware.RateLimit(handler, pattern, rt, rt.logger)
+	rt.mux.Handle(pattern, middleware.Route(middleware.Authorize(limited, policy, rt, rt.logger)))
+}
+
+func (rt *Router) HandleFunc(pattern string, policy middleware.Policy, handler http.HandlerFunc) {
+	rt.Handle(pattern, policy, handler)
+}
@@ -2,7 +2,9 @@ package httpserver
 
 import (
 	"app/internal/pkg/config"
+	"app/internal/pkg/health"
 	"app/internal/pkg/logger"
+	"app/internal/pkg/metrics"
 	"app/internal/pkg/middleware"
 	"context"
 	"fmt"
@@ -10,41 +12,114 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 )
 
+// Worker is a background task that runs alongside the server until ctx is
+// done.
+type Worker func(ctx context.Context)
+
 type Server struct {
 	Name            string
 	Version         string
 	Mux             *http.ServeMux
+	Router          *Router
 	Server          *http.Server
 	Logger          logger.Interface
+	Health          *health.Health
 	notify          chan error
 	shutdownTimeout time.Duration
+	workers         map[string]Worker
+	stopWorkers     context.CancelFunc
+	running         sync.WaitGroup
 }
 
-func New(cfg *config.HTTP, cfgApp *config.App, logger logger.Interface) *Server {
+// New builds the server with /livez and /readyz registered. Dependencies are
+// checked by registering them on Health.
+func New(cfg *config.HTTP, cfgApp *config.App, logger logger.Interface, metrics metrics.HTTP, health *health.Health) (*Server, error) {
+	trusted, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("httpserver.New: %w", err)
+	}
+
 	mux := http.NewServeMux()
 	server := &Server{
 		Name:    cfgApp.Name,
 		Version: cfgApp.Version,
 		Logger:  logger,
+		Health:  health,
 		Mux:     mux,
+		Router:  NewRouter(mux, logger),
 		Server: &http.Server{
-			Handler:      middleware.Logging(mux, logger),
+			Handler:      middleware.ClientIP(middleware.Tracing(middleware.Metrics(middleware.Logging(mux, logger), metrics)), trusted),
 			ReadTimeout:  cfg.ReadTimeout,
 			WriteTimeout: cfg.WriteTimeout,
 			Addr:         net.JoinHostPort("", cfg.Address),
 		},
 		notify:          make(chan error, 1),
 		shutdownTimeout: cfg.ShutdownTimeout,
+		workers:         make(map[string]Worker),
+	}
+
+	server.Router.Handle("GET /livez", middleware.Public(), health.LiveHandler())
+	server.Router.Handle("GET /readyz", middleware.Public(), health.ReadyHandler())
+
+	return server, nil
+}
+
+// Go registers a worker to be started with the server and stopped when it
+// shuts down.
+func (s *Server) Go(name string, worker Worker) {
+	s.workers[name] = worker
+}
+
+// Internal serves handler on a listener of its own at address, for
+// endpoints such as metrics that must not be reachable through the API. It
+// is started and stopped with the server.
+func (s *Server) Internal(name string, address string, handler http.Handler) {
+	server := &http.Server{
+		Addr:         address,
+		Handler:      handler,
+		ReadTimeout:  s.Server.ReadTimeout,
+		WriteTimeout: s.Server.WriteTimeout,
 	}
 
-	return server
+	s.Go(name, func(ctx context.Context) {
+		failed := make(chan error, 1)
+		go func() {
+			s.Logger.Info(fmt.Sprintf("%s was started on %s", name, address))
+			failed <- server.ListenAndServe()
+		}()
+
+		select {
+		case err := <-failed:
+			s.Logger.Error(fmt.Errorf("app - Run - %s: %w", name, err).Error())
+			return
+		case <-ctx.Done():
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.shutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			s.Logger.Error(fmt.Errorf("app - Run - %s.Shutdown: %w", name, err).Error())
+		}
+	})
 }
 
 func (s *Server) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopWorkers = cancel
+	for name, worker := range s.workers {
+		s.running.Add(1)
+		go func() {
+			defer s.running.Done()
+			s.Logger.Debug(fmt.Sprintf("Worker %s was started", name))
+			worker(ctx)
+		}()
+	}
 
 	go func() {
 		s.Logger.Info(fmt.Sprintf("%s [%s] was started on port %s", s.Name, s.Version, s.Server.Addr))
@@ -76,6 +151,8 @@ func (s *Server) waitForSignals() error {
 }
 
 func (s *Server) shutdown() {
+	s.Health.Drain(context.Background())
+
 	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 	defer cancel()
 
@@ -83,5 +160,18 @@ func (s *Server) shutdown() {
 	if err != nil {
 		s.Logger.Error(fmt.Errorf("app - Run - httpServer.Shutdown: %w", err).Error())
 	}
+
+	s.stopWorkers()
+	stopped := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Logger.Error("app - Run - workers did not stop before the shutdown timeout")
+	}
 	s.Logger.Info("Application server is stopped by interrupt..")
 }
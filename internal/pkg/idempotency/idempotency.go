@@ -0,0 +1,119 @@
+package idempotency
+
+import (
+	"app/internal/pkg/cache"
+	"app/internal/pkg/config"
+	"app/internal/pkg/database"
+	"app/internal/pkg/logger"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	StoreRedis    = "redis"
+	StorePostgres = "postgres"
+)
+
+// Response is what is replayed to a retried request.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// ErrClaimLost is returned when a claim is settled after it lapsed and the
+// key may have been claimed by another request.
+var ErrClaimLost = errors.New("idempotency: claim on the key was lost")
+
+// Claim is held on a key by the request being served for it. Only the
+// holder of its token can settle it.
+type Claim struct {
+	Key         string
+	Fingerprint string
+	Token       string
+}
+
+// Record is what a key was first used for. Response is nil while the first
+// request is still being served.
+type Record struct {
+	Fingerprint string
+	Response    *Response
+}
+
+// Store keeps the responses to requests made with an Idempotency-Key. A key
+// is claimed by Begin until Complete stores the response or Release gives
+// it up; a claim that is never settled lapses after the lock timeout.
+type Store interface {
+	// Begin claims key for a request with fingerprint. It returns the claim
+	// when it succeeded and the existing record otherwise.
+	Begin(ctx context.Context, key string, fingerprint string) (*Claim, *Record, error)
+	// Complete stores the response to replay for the retention period. It
+	// returns ErrClaimLost unless claim still holds the key.
+	Complete(ctx context.Context, claim *Claim, response *Response) error
+	// Release gives up a claim so that the request can be retried. A claim
+	// that was lost is left alone.
+	Release(ctx context.Context, claim *Claim) error
+}
+
+// New returns the store named by cfg.Store. When none is named, Redis is
+// used if configured and Postgres otherwise.
+func New(cfg *config.Idempotency, db *database.Postgres, cache *cache.Redis, logger logger.Interface) (Store, error) {
+	if cfg == nil {
+		return nil, errors.New("idempotency.New: cfg is null")
+	}
+
+	store := cfg.Store
+	if store == "" {
+		store = StorePostgres
+		if cache != nil {
+			store = StoreRedis
+		}
+	}
+
+	switch store {
+	case StoreRedis:
+		return NewRedis(cache, cfg, logger)
+	case StorePostgres:
+		postgres, err := NewPostgres(db, cfg, logger)
+		if err != nil {
+			return nil, err
+		}
+		return postgres, nil
+	default:
+		return nil, fmt.Errorf("idempotency.New: unknown store %q", cfg.Store)
+	}
+}
+
+// Key scopes a client's Idempotency-Key to the caller, so that two callers
+// picking the same key do not see each other's responses.
+func Key(caller string, key string) string {
+	return digest(caller, key)
+}
+
+// Fingerprint identifies the request a key was used for.
+func Fingerprint(method string, path string, body []byte) string {
+	return digest(method, path, string(body))
+}
+
+func digest(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		// Length prefixes keep ("ab", "c") apart from ("a", "bc").
+		fmt.Fprintf(h, "%d:%s", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newClaim returns a claim on key with a random token.
+func newClaim(key string, fingerprint string) (*Claim, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	return &Claim{Key: key, Fingerprint: fingerprint, Token: hex.EncodeToString(token)}, nil
+}
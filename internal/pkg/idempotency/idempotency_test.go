@@ -0,0 +1,59 @@
+package idempotency_test
+
+import (
+	"app/internal/pkg/idempotency"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  [2]string
+		equal bool
+	}{
+		{"same caller and key", [2]string{"alice", "k1"}, [2]string{"alice", "k1"}, true},
+		{"other caller", [2]string{"alice", "k1"}, [2]string{"bob", "k1"}, false},
+		{"other key", [2]string{"alice", "k1"}, [2]string{"alice", "k2"}, false},
+		{"boundary moved", [2]string{"alice", "bk1"}, [2]string{"aliceb", "k1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := idempotency.Key(tt.a[0], tt.a[1]), idempotency.Key(tt.b[0], tt.b[1])
+			if (a == b) != tt.equal {
+				t.Fatalf("Key(%v) == Key(%v) is %v, want %v", tt.a, tt.b, a == b, tt.equal)
+			}
+			// Keys are stored in CHAR(64) columns.
+			if len(a) != 64 {
+				t.Fatalf("Key() has %d characters, want 64", len(a))
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	base := idempotency.Fingerprint("POST", "/users/1/roles", []byte(`{"role":"viewer"}`))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		equal  bool
+	}{
+		{"same request", "POST", "/users/1/roles", `{"role":"viewer"}`, true},
+		{"other method", "PUT", "/users/1/roles", `{"role":"viewer"}`, false},
+		{"other path", "POST", "/users/2/roles", `{"role":"viewer"}`, false},
+		{"other body", "POST", "/users/1/roles", `{"role":"admin"}`, false},
+		{"body moved into path", "POST", "/users/1/roles{", `"role":"viewer"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := idempotency.Fingerprint(tt.method, tt.path, []byte(tt.body))
+			if (got == base) != tt.equal {
+				t.Fatalf("Fingerprint() equal = %v, want %v", got == base, tt.equal)
+			}
+		})
+	}
+}
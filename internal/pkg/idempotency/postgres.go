@@ -0,0 +1,212 @@
+package idempotency
+
+import (
+	"app/internal/pkg/config"
+	"app/internal/pkg/database"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/reqctx"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+)
+
+// PurgeInterval is how often expired keys are deleted from Postgres.
+const PurgeInterval = time.Hour
+
+var _ Store = (*PostgresStore)(nil)
+
+type PostgresStore struct {
+	db          *database.Postgres
+	retention   time.Duration
+	lockTimeout time.Duration
+	logger      logger.Interface
+}
+
+func NewPostgres(db *database.Postgres, cfg *config.Idempotency, logger logger.Interface) (*PostgresStore, error) {
+	if db == nil {
+		return nil, errors.New("idempotency.NewPostgres: db is null")
+	}
+
+	if cfg == nil || cfg.Retention <= 0 || cfg.LockTimeout <= 0 {
+		return nil, errors.New("idempotency.NewPostgres: cfg is null or has no retention or lock timeout")
+	}
+
+	if logger == nil {
+		return nil, errors.New("idempotency.NewPostgres: logger is null")
+	}
+
+	return &PostgresStore{
+		db:          db,
+		retention:   cfg.Retention,
+		lockTimeout: cfg.LockTimeout,
+		logger:      logger,
+	}, nil
+}
+
+// Begin inserts the claim, taking over a row that has expired. When the key
+// is live the row is read instead.
+func (s *PostgresStore) Begin(ctx context.Context, key string, fingerprint string) (*Claim, *Record, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+
+	claim, err := newClaim(key, fingerprint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("idempotency.Begin: %w", err)
+	}
+
+	now := time.Now()
+	query, args, err := sq.
+		Insert("idempotency_keys").
+		Columns("key", "fingerprint", "claim", "created_at", "expires_at").
+		Values(key, fingerprint, claim.Token, now, now.Add(s.lockTimeout)).
+		Suffix(`ON CONFLICT (key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint, claim = EXCLUDED.claim, status = NULL, headers = NULL, body = NULL,
+			created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+			RETURNING key`).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, nil, fmt.Errorf("idempotency.Begin: %w", err)
+	}
+
+	var claimed string
+	err = s.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&claimed)
+	if err == nil {
+		return claim, nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		log.Error(fmt.Sprintf("txID: %s idempotency.Begin, error claiming key: %v", txID, err))
+		return nil, nil, fmt.Errorf("idempotency.Begin: %w", err)
+	}
+
+	query, args, err = sq.
+		Select("fingerprint", "status", "headers", "body").
+		From("idempotency_keys").
+		Where(sq.Eq{"key": key}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, nil, fmt.Errorf("idempotency.Begin: %w", err)
+	}
+
+	var (
+		record  Record
+		status  *int
+		headers []byte
+		body    []byte
+	)
+	err = s.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&record.Fingerprint, &status, &headers, &body)
+	if err != nil {
+		log.Error(fmt.Sprintf("txID: %s idempotency.Begin, error reading key: %v", txID, err))
+		return nil, nil, fmt.Errorf("idempotency.Begin: %w", err)
+	}
+
+	if status != nil {
+		record.Response = &Response{Status: *status, Body: body}
+		if err := json.Unmarshal(headers, &record.Response.Header); err != nil {
+			return nil, nil, fmt.Errorf("idempotency.Begin: %w", err)
+		}
+	}
+
+	return nil, &record, nil
+}
+
+// Complete stores the response on the row while it still holds the claim.
+func (s *PostgresStore) Complete(ctx context.Context, claim *Claim, response *Response) error {
+	headers, err := json.Marshal(response.Header)
+	if err != nil {
+		return fmt.Errorf("idempotency.Complete: %w", err)
+	}
+
+	if response.Header == nil {
+		headers = []byte("{}")
+	}
+
+	query, args, err := sq.
+		Update("idempotency_keys").
+		Set("claim", nil).
+		Set("status", response.Status).
+		Set("headers", headers).
+		Set("body", response.Body).
+		Set("expires_at", time.Now().Add(s.retention)).
+		Where(sq.Eq{"key": claim.Key, "claim": claim.Token}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("idempotency.Complete: %w", err)
+	}
+
+	tag, err := s.db.Conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("idempotency.Complete: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("idempotency.Complete: %w", ErrClaimLost)
+	}
+
+	return nil
+}
+
+// Release deletes the row while it still holds the claim.
+func (s *PostgresStore) Release(ctx context.Context, claim *Claim) error {
+	query, args, err := sq.
+		Delete("idempotency_keys").
+		Where(sq.Eq{"key": claim.Key, "claim": claim.Token}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("idempotency.Release: %w", err)
+	}
+
+	if _, err = s.db.Conn(ctx).Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("idempotency.Release: %w", err)
+	}
+
+	return nil
+}
+
+// Purge deletes the keys whose retention has passed.
+func (s *PostgresStore) Purge(ctx context.Context) (int64, error) {
+	query, args, err := sq.
+		Delete("idempotency_keys").
+		Where(sq.Lt{"expires_at": time.Now()}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("idempotency.Purge: %w", err)
+	}
+
+	tag, err := s.db.Conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("idempotency.Purge: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// Run purges expired keys every PurgeInterval until ctx is done.
+func (s *PostgresStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.Purge(ctx)
+			if err != nil {
+				s.logger.Error(fmt.Sprintf("idempotency.Run, error purging expired keys: %v", err))
+				continue
+			}
+			s.logger.Debug(fmt.Sprintf("idempotency.Run, purged %d expired keys", purged))
+		}
+	}
+}
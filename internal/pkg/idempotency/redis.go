@@ -0,0 +1,177 @@
+package idempotency
+
+import (
+	"app/internal/pkg/cache"
+	"app/internal/pkg/config"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/reqctx"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "idempotency:"
+
+var _ Store = (*redisStore)(nil)
+
+// settleScript replaces the claim ARGV[1] held on KEYS[1] with the record
+// ARGV[2] for ARGV[3] milliseconds, or deletes it when ARGV[2] is empty.
+// Returns 0 when the key no longer holds the claim.
+var settleScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if ARGV[2] == '' then
+	redis.call('DEL', KEYS[1])
+else
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+end
+return 1
+`)
+
+// redisRecord is how a record is stored in Redis.
+type redisRecord struct {
+	Fingerprint string      `json:"fingerprint"`
+	Token       string      `json:"token,omitempty"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+type redisStore struct {
+	client      *redis.Client
+	retention   time.Duration
+	lockTimeout time.Duration
+	logger      logger.Interface
+}
+
+func NewRedis(cache *cache.Redis, cfg *config.Idempotency, logger logger.Interface) (Store, error) {
+	if cache == nil || cache.Client == nil {
+		return nil, errors.New("idempotency.NewRedis: cache is null")
+	}
+
+	if cfg == nil || cfg.Retention <= 0 || cfg.LockTimeout <= 0 {
+		return nil, errors.New("idempotency.NewRedis: cfg is null or has no retention or lock timeout")
+	}
+
+	if logger == nil {
+		return nil, errors.New("idempotency.NewRedis: logger is null")
+	}
+
+	return &redisStore{
+		client:      cache.Client,
+		retention:   cfg.Retention,
+		lockTimeout: cfg.LockTimeout,
+		logger:      logger,
+	}, nil
+}
+
+func (s *redisStore) Begin(ctx context.Context, key string, fingerprint string) (*Claim, *Record, error) {
+	txID := reqctx.RequestID(ctx)
+	log := logger.WithContext(ctx, s.logger)
+
+	claim, err := newClaim(key, fingerprint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("idempotency.Begin: %w", err)
+	}
+
+	value, err := claimValue(claim)
+	if err != nil {
+		return nil, nil, fmt.Errorf("idempotency.Begin: %w", err)
+	}
+
+	// The record may expire between the failed claim and the read, in which
+	// case the claim is tried again.
+	for range 2 {
+		claimed, err := s.client.SetNX(ctx, keyPrefix+key, value, s.lockTimeout).Result()
+		if err != nil {
+			log.Error(fmt.Sprintf("txID: %s idempotency.Begin, error claiming key: %v", txID, err))
+			return nil, nil, fmt.Errorf("idempotency.Begin: %w", err)
+		}
+		if claimed {
+			return claim, nil, nil
+		}
+
+		existing, err := s.client.Get(ctx, keyPrefix+key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			log.Error(fmt.Sprintf("txID: %s idempotency.Begin, error reading key: %v", txID, err))
+			return nil, nil, fmt.Errorf("idempotency.Begin: %w", err)
+		}
+
+		var stored redisRecord
+		if err := json.Unmarshal(existing, &stored); err != nil {
+			return nil, nil, fmt.Errorf("idempotency.Begin: %w", err)
+		}
+
+		record := &Record{Fingerprint: stored.Fingerprint}
+		if stored.Status != 0 {
+			record.Response = &Response{Status: stored.Status, Header: stored.Header, Body: stored.Body}
+		}
+		return nil, record, nil
+	}
+
+	return nil, nil, errors.New("idempotency.Begin: key keeps expiring")
+}
+
+func (s *redisStore) Complete(ctx context.Context, claim *Claim, response *Response) error {
+	value, err := json.Marshal(redisRecord{
+		Fingerprint: claim.Fingerprint,
+		Status:      response.Status,
+		Header:      response.Header,
+		Body:        response.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("idempotency.Complete: %w", err)
+	}
+
+	if err := s.settle(ctx, claim, string(value)); err != nil {
+		return fmt.Errorf("idempotency.Complete: %w", err)
+	}
+
+	return nil
+}
+
+func (s *redisStore) Release(ctx context.Context, claim *Claim) error {
+	err := s.settle(ctx, claim, "")
+	if err != nil && !errors.Is(err, ErrClaimLost) {
+		return fmt.Errorf("idempotency.Release: %w", err)
+	}
+	return nil
+}
+
+// settle replaces the claim with value, or deletes it when value is empty,
+// if the key still holds it.
+func (s *redisStore) settle(ctx context.Context, claim *Claim, value string) error {
+	held, err := claimValue(claim)
+	if err != nil {
+		return err
+	}
+
+	settled, err := settleScript.Run(ctx, s.client, []string{keyPrefix + claim.Key},
+		held, value, s.retention.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if settled == 0 {
+		return ErrClaimLost
+	}
+
+	return nil
+}
+
+// claimValue is how a claim is stored until it is settled.
+func claimValue(claim *Claim) (string, error) {
+	value, err := json.Marshal(redisRecord{Fingerprint: claim.Fingerprint, Token: claim.Token})
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
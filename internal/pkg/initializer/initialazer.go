@@ -1,23 +1,40 @@
 package initializer
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
+	"app/internal/pkg/cache"
 	"app/internal/pkg/config"
 	"app/internal/pkg/database"
+	"app/internal/pkg/eventbus"
+	"app/internal/pkg/health"
 	"app/internal/pkg/httpserver"
+	"app/internal/pkg/idempotency"
 	"app/internal/pkg/logger"
+	"app/internal/pkg/metrics"
+	"app/internal/pkg/ratelimit"
+	"app/internal/pkg/telemetry"
 )
 
 var (
 	ErrEmptyConfig = errors.New("empty configuration file")
 )
 
+// telemetryFlushTimeout bounds how long pending spans are exported for on
+// shutdown.
+const telemetryFlushTimeout = 5 * time.Second
+
 type Initializer struct {
-	DB     *database.Postgres
-	Logger logger.Interface
-	Server *httpserver.Server
+	DB      *database.Postgres
+	Cache   *cache.Redis
+	Logger  logger.Interface
+	Server  *httpserver.Server
+	Bus     *eventbus.Bus
+	Metrics *metrics.Registry
 }
 
 func InitApplication() (*Initializer, *config.Config) {
@@ -44,12 +61,33 @@ func DefaultApplication() *Initializer {
 		fmt.Println(err)
 	}
 
-	server := httpserver.New(cfg.Http, cfg.App, log)
+	registry, err := metrics.New()
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	checks, err := health.New(cfg.Health, log)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	server, err := httpserver.New(cfg.Http, cfg.App, log, registry, checks)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	bus, err := eventbus.New(cfg.EventBus, log)
+	if err != nil {
+		fmt.Println(err)
+	}
 
 	return &Initializer{
-		DB:     nil,
-		Logger: log,
-		Server: server,
+		DB:      nil,
+		Cache:   nil,
+		Logger:  log,
+		Server:  server,
+		Bus:     bus,
+		Metrics: registry,
 	}
 }
 
@@ -63,16 +101,100 @@ func New(cfg *config.Config) (*Initializer, error) {
 		return nil, err
 	}
 
+	tracing, err := telemetry.New(cfg.Telemetry, cfg.App)
+	if err != nil {
+		return nil, err
+	}
+
 	db, err := database.NewOrGetSingletonPostgres(cfg.DB, log)
 	if err != nil {
 		return nil, err
 	}
 
-	server := httpserver.New(cfg.Http, cfg.App, log)
+	var redis *cache.Redis
+	if cfg.Cache.URL != "" {
+		redis, err = cache.NewOrGetSingletonRedis(cfg.Cache, log)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	registry, err := metrics.New()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := registry.Register(metrics.NewPoolCollector(db.Pool)); err != nil {
+		return nil, err
+	}
+
+	checks, err := health.New(cfg.Health, log)
+	if err != nil {
+		return nil, err
+	}
+	checks.Register("postgres", health.CheckerFunc(db.Ping))
+	if redis != nil {
+		checks.Register("redis", health.CheckerFunc(redis.Ping))
+	}
+
+	rules, err := ratelimit.ParseRules(cfg.RateLimit.Routes, cfg.RateLimit.Algorithm, cfg.RateLimit.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	var limiter ratelimit.Limiter
+	if redis != nil {
+		limiter, err = ratelimit.NewRedis(redis)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		log.Warn("REDIS_URL is not set, rate limits are counted per instance")
+		limiter = ratelimit.NewMemory()
+	}
+
+	server, err := httpserver.New(cfg.Http, cfg.App, log, registry, checks)
+	if err != nil {
+		return nil, err
+	}
+	server.Router.SetRateLimits(limiter, rules)
+
+	responses, err := idempotency.New(cfg.Idempotency, db, redis, log)
+	if err != nil {
+		return nil, err
+	}
+	server.Router.SetIdempotencyStore(responses)
+	if postgres, ok := responses.(*idempotency.PostgresStore); ok {
+		server.Go("idempotency purge", postgres.Run)
+	}
+	if cfg.Metrics.Address != "" && cfg.Metrics.Path != "" {
+		internal := http.NewServeMux()
+		internal.Handle("GET "+cfg.Metrics.Path, registry.Handler())
+		server.Internal("metrics server", cfg.Metrics.Address, internal)
+	}
+
+	bus, err := eventbus.New(cfg.EventBus, log)
+	if err != nil {
+		return nil, err
+	}
+	server.Go("event bus", bus.Run)
+	server.Go("telemetry", func(ctx context.Context) {
+		<-ctx.Done()
+
+		flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), telemetryFlushTimeout)
+		defer cancel()
+
+		if err := tracing.Shutdown(flushCtx); err != nil {
+			log.Error(err.Error())
+		}
+	})
 
 	return &Initializer{
-		DB:     db,
-		Logger: log,
-		Server: server,
+		DB:      db,
+		Cache:   redis,
+		Logger:  log,
+		Server:  server,
+		Bus:     bus,
+		Metrics: registry,
 	}, nil
 }
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"app/internal/pkg/reqctx"
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ Interface = (*contextLogger)(nil)
+
+// contextLogger adds the fields of a request to every line.
+type contextLogger struct {
+	next   Interface
+	fields []Field
+}
+
+// WithContext returns l with the trace_id and span_id of ctx added to every
+// line, so that the lines of a request can be found from its trace. The
+// current OpenTelemetry span is preferred; without a tracer provider the
+// trace context that the Logging middleware put into ctx is used. l is
+// returned as is when ctx carries no trace.
+func WithContext(ctx context.Context, l Interface) Interface {
+	var traceID, spanID string
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		traceID, spanID = sc.TraceID().String(), sc.SpanID().String()
+	} else if t, ok := reqctx.TraceFromContext(ctx); ok {
+		traceID, spanID = t.TraceID, t.ParentID
+	}
+
+	if traceID == "" {
+		return l
+	}
+
+	return &contextLogger{
+		next:   l,
+		fields: []Field{NewField("trace_id", traceID), NewField("span_id", spanID)},
+	}
+}
+
+func (l *contextLogger) Debug(message string, args ...Field) {
+	l.next.Debug(message, l.with(args)...)
+}
+
+func (l *contextLogger) Info(message string, args ...Field) {
+	l.next.Info(message, l.with(args)...)
+}
+
+func (l *contextLogger) Warn(message string, args ...Field) {
+	l.next.Warn(message, l.with(args)...)
+}
+
+func (l *contextLogger) Error(message string, args ...Field) {
+	l.next.Error(message, l.with(args)...)
+}
+
+func (l *contextLogger) Fatal(message string, args ...Field) {
+	l.next.Fatal(message, l.with(args)...)
+}
+
+func (l *contextLogger) with(args []Field) []Field {
+	return append(append(make([]Field, 0, len(l.fields)+len(args)), l.fields...), args...)
+}
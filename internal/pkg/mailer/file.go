@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"app/internal/pkg/config"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ Interface = (*File)(nil)
+
+// File writes every message as an .eml file into a directory instead of
+// sending it, which is handy for local runs and tests.
+type File struct {
+	dir  string
+	from string
+}
+
+func NewFile(cfg *config.Mailer) (*File, error) {
+	if cfg.Directory == "" {
+		return nil, errors.New("mailer.NewFile: directory is required")
+	}
+
+	if err := os.MkdirAll(cfg.Directory, 0o750); err != nil {
+		return nil, fmt.Errorf("mailer.NewFile: %w", err)
+	}
+
+	return &File{dir: cfg.Directory, from: cfg.From}, nil
+}
+
+func (f *File) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("mailer.File.Send: %w", err)
+	}
+
+	now := time.Now()
+	body, err := compose(f.from, msg, now)
+	if err != nil {
+		return fmt.Errorf("mailer.File.Send: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), uuid.NewString())
+	tmp := filepath.Join(f.dir, "."+name)
+
+	// Write then rename, so that a reader polling the directory never sees
+	// a partially written message.
+	if err = os.WriteFile(tmp, body, 0o640); err != nil {
+		return fmt.Errorf("mailer.File.Send: %w", err)
+	}
+
+	if err = os.Rename(tmp, filepath.Join(f.dir, name)); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("mailer.File.Send: %w", err)
+	}
+
+	return nil
+}
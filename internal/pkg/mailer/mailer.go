@@ -0,0 +1,79 @@
+package mailer
+
+import (
+	"app/internal/pkg/config"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Interface interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the mailer selected by cfg.Driver.
+func New(cfg *config.Mailer) (Interface, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("mailer.New: cfg is null")
+	}
+
+	switch cfg.Driver {
+	case DriverSMTP:
+		return NewSMTP(cfg)
+	case DriverFile:
+		return NewFile(cfg)
+	default:
+		return nil, fmt.Errorf("mailer.New: unknown driver %q", cfg.Driver)
+	}
+}
+
+// compose renders msg as an RFC 5322 message. Header values are checked for
+// line breaks so that a recipient cannot smuggle in extra headers.
+func compose(from string, msg Message, now time.Time) ([]byte, error) {
+	for _, value := range []string{from, msg.To, msg.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("header value contains a line break")
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	domain := "localhost"
+	if _, host, ok := strings.Cut(from, "@"); ok && host != "" {
+		domain = host
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+
+	return buf.Bytes(), nil
+}
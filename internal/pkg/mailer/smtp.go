@@ -0,0 +1,119 @@
+package mailer
+
+import (
+	"app/internal/pkg/config"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+var _ Interface = (*SMTP)(nil)
+
+// SMTP delivers mail through an SMTP relay, upgrading to TLS with STARTTLS
+// when the server offers it. Authentication is only attempted when a
+// username is configured.
+type SMTP struct {
+	addr     string
+	host     string
+	from     string
+	username string
+	password string
+	timeout  time.Duration
+}
+
+func NewSMTP(cfg *config.Mailer) (*SMTP, error) {
+	if cfg.SMTPHost == "" {
+		return nil, errors.New("mailer.NewSMTP: host is required")
+	}
+
+	if cfg.From == "" {
+		return nil, errors.New("mailer.NewSMTP: from address is required")
+	}
+
+	return &SMTP{
+		addr:     net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+		host:     cfg.SMTPHost,
+		from:     cfg.From,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+		timeout:  cfg.Timeout,
+	}, nil
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	body, err := compose(s.from, msg, time.Now())
+	if err != nil {
+		return fmt.Errorf("mailer.SMTP.Send: %w", err)
+	}
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("mailer.SMTP.Send: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("mailer.SMTP.Send: %w", err)
+	}
+	defer client.Close()
+
+	if err = s.deliver(client, msg.To, body); err != nil {
+		return fmt.Errorf("mailer.SMTP.Send: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SMTP) deliver(client *smtp.Client, to string, body []byte) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.from); err != nil {
+		return err
+	}
+
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err = w.Write(body); err != nil {
+		return err
+	}
+
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Interface lets usecases count what happens in the business, e.g. users
+// created, without depending on the Prometheus client.
+type Interface interface {
+	// Counter returns the counter with the given name, registering it on
+	// first use. Inc must be called with one value per label.
+	Counter(name string, help string, labels ...string) (Counter, error)
+}
+
+type Counter interface {
+	Inc(labelValues ...string)
+	Add(delta float64, labelValues ...string)
+}
+
+// HTTP observes the requests served, labelled by the route pattern that
+// matched so that the number of series stays bounded.
+type HTTP interface {
+	Started() func(method string, route string, status int)
+}
+
+// Registry holds the metrics exposed on /metrics: the HTTP RED metrics, the
+// Go runtime and process collectors and whatever else is registered.
+type Registry struct {
+	registry *prometheus.Registry
+
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+}
+
+var (
+	_ Interface = (*Registry)(nil)
+	_ HTTP      = (*Registry)(nil)
+)
+
+func New() (*Registry, error) {
+	r := &Registry{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests served, by method, route and status code.",
+		}, []string{"method", "route", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time taken to serve HTTP requests, by method, route and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "code"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests being served.",
+		}),
+	}
+
+	for _, collector := range []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		r.requests,
+		r.duration,
+		r.inFlight,
+	} {
+		if err := r.registry.Register(collector); err != nil {
+			return nil, fmt.Errorf("metrics.New: %w", err)
+		}
+	}
+
+	return r, nil
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (r *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{Registry: r.registry})
+}
+
+// Register adds a collector, such as the database pool one.
+func (r *Registry) Register(collector prometheus.Collector) error {
+	if err := r.registry.Register(collector); err != nil {
+		return fmt.Errorf("metrics.Register: %w", err)
+	}
+	return nil
+}
+
+func (r *Registry) Counter(name string, help string, labels ...string) (Counter, error) {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labels)
+	if err := r.registry.Register(vec); err != nil {
+		// Services built twice, as in the purge command, share the counter.
+		var registered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &registered) {
+			return nil, fmt.Errorf("metrics.Counter: %w", err)
+		}
+		existing, ok := registered.ExistingCollector.(*prometheus.CounterVec)
+		if !ok {
+			return nil, fmt.Errorf("metrics.Counter: %s is registered as another type", name)
+		}
+		vec = existing
+	}
+
+	return &counter{vec: vec}, nil
+}
+
+// Started counts a request in flight and returns the function that records
+// it once served.
+func (r *Registry) Started() func(method string, route string, status int) {
+	start := time.Now()
+	r.inFlight.Inc()
+
+	return func(method string, route string, status int) {
+		r.inFlight.Dec()
+
+		code := strconv.Itoa(status)
+		r.requests.WithLabelValues(method, route, code).Inc()
+		r.duration.WithLabelValues(method, route, code).Observe(time.Since(start).Seconds())
+	}
+}
+
+type counter struct {
+	vec *prometheus.CounterVec
+}
+
+func (c *counter) Inc(labelValues ...string) {
+	c.vec.WithLabelValues(labelValues...).Inc()
+}
+
+func (c *counter) Add(delta float64, labelValues ...string) {
+	c.vec.WithLabelValues(labelValues...).Add(delta)
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"app/internal/pkg/auth"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/problem"
+	"app/internal/pkg/reqctx"
+	"app/internal/pkg/token"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const bearerPrefix = "Bearer "
+
+var (
+	// ErrNoCredentials is returned by an IdentityExtractor when the request
+	// carries no credentials at all.
+	ErrNoCredentials = errors.New("missing bearer token")
+	// ErrInvalidCredentials is returned by an IdentityExtractor when the
+	// credentials are present but not acceptable.
+	ErrInvalidCredentials = errors.New("invalid or expired token")
+)
+
+// IdentityExtractor resolves the caller of a request. Implementations return
+// ErrNoCredentials or ErrInvalidCredentials for anonymous or rejected callers;
+// any other error is treated as a server failure.
+type IdentityExtractor interface {
+	Extract(r *http.Request) (*auth.Principal, error)
+}
+
+// PermissionResolver looks up the roles and permissions granted to a subject.
+type PermissionResolver interface {
+	Resolve(ctx context.Context, subject string) (roles []string, permissions []string, err error)
+}
+
+var _ IdentityExtractor = (*BearerIdentity)(nil)
+
+// BearerIdentity extracts the principal from an "Authorization: Bearer"
+// access token and, if a resolver is set, loads its roles and permissions.
+type BearerIdentity struct {
+	Tokens   token.Interface
+	Resolver PermissionResolver
+}
+
+func (b *BearerIdentity) Extract(r *http.Request) (*auth.Principal, error) {
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := b.Tokens.Verify(strings.TrimSpace(header[len(bearerPrefix):]), token.TypeAccess)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	principal := &auth.Principal{
+		Subject:   claims.Subject,
+		TokenID:   claims.ID,
+		SessionID: claims.SessionID,
+		ExpiresAt: claims.ExpiresAtTime(),
+	}
+
+	if b.Resolver != nil {
+		principal.Roles, principal.Permissions, err = b.Resolver.Resolve(r.Context(), claims.Subject)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return principal, nil
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, detail string) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	problem.Write(w, r, http.StatusUnauthorized, detail)
+}
+
+// authenticate runs the extractor and writes the failure response itself. It
+// reports whether the request may go on.
+func authenticate(
+	w http.ResponseWriter,
+	r *http.Request,
+	extractor IdentityExtractor,
+	log logger.Interface,
+) (*auth.Principal, bool) {
+	txID := reqctx.RequestID(r.Context())
+	log = logger.WithContext(r.Context(), log)
+
+	principal, err := extractor.Extract(r)
+	switch {
+	case err == nil:
+		return principal, true
+	case errors.Is(err, ErrNoCredentials):
+		unauthorized(w, r, ErrNoCredentials.Error())
+	case errors.Is(err, ErrInvalidCredentials):
+		log.Debug(fmt.Sprintf("txID: %s middleware.Authentication, rejected token: %v", txID, err))
+		unauthorized(w, r, ErrInvalidCredentials.Error())
+	default:
+		log.Error(fmt.Sprintf("txID: %s middleware.Authentication, error extracting identity: %v", txID, err))
+		problem.Write(w, r, http.StatusInternalServerError, "")
+	}
+
+	return nil, false
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"app/internal/pkg/reqctx"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const HeaderForwardedFor = "X-Forwarded-For"
+
+// ParseTrustedProxies reads the addresses and CIDR ranges of the proxies
+// whose X-Forwarded-For header may be believed.
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("middleware.ParseTrustedProxies: %q: %w", proxy, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("middleware.ParseTrustedProxies: %q: %w", proxy, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// ClientIP puts the address of the client into the request context, for
+// logs, lockouts and rate limits. It is the peer's address unless the peer
+// is a trusted proxy: then X-Forwarded-For is read from the right, and the
+// first address that is not a trusted proxy is the client. Without trusted
+// proxies the header is ignored, since any client can send it.
+func ClientIP(next http.Handler, trusted []netip.Prefix) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := peerIP(r)
+		if len(trusted) > 0 {
+			ip = forwardedIP(ip, r.Header.Values(HeaderForwardedFor), trusted)
+		}
+
+		next.ServeHTTP(w, r.WithContext(reqctx.WithClientIP(r.Context(), ip)))
+	})
+}
+
+// forwardedIP walks the hops of X-Forwarded-For back from peer for as long
+// as they are trusted proxies. A malformed hop ends the walk at the last
+// trusted one.
+func forwardedIP(peer string, headers []string, trusted []netip.Prefix) string {
+	var hops []string
+	for _, header := range headers {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	ip := peer
+	for i := len(hops) - 1; i >= 0 && isTrusted(ip, trusted); i-- {
+		hop := strings.TrimSpace(hops[i])
+		if _, err := netip.ParseAddr(hop); err != nil {
+			break
+		}
+		ip = hop
+	}
+
+	return ip
+}
+
+func isTrusted(ip string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// peerIP is the address of the peer the connection comes from.
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"app/internal/pkg/reqctx"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		trusted   bool
+		peer      string
+		forwarded []string
+		want      string
+	}{
+		{"no proxies trusted", false, "10.0.0.1:1234", []string{"203.0.113.7"}, "10.0.0.1"},
+		{"untrusted peer", true, "198.51.100.1:1234", []string{"203.0.113.7"}, "198.51.100.1"},
+		{"trusted peer", true, "10.0.0.1:1234", []string{"203.0.113.7"}, "203.0.113.7"},
+		{"chain of trusted proxies", true, "10.0.0.1:1234", []string{"203.0.113.7, 192.0.2.1", "10.1.1.1"}, "203.0.113.7"},
+		{"spoofed hop left of the client", true, "10.0.0.1:1234", []string{"1.2.3.4, 203.0.113.7"}, "203.0.113.7"},
+		{"malformed hop", true, "10.0.0.1:1234", []string{"203.0.113.7, garbage"}, "10.0.0.1"},
+		{"only trusted hops", true, "10.0.0.1:1234", []string{"10.0.0.2"}, "10.0.0.2"},
+		{"no header", true, "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"ipv4 mapped peer", true, "[::ffff:10.0.0.1]:1234", []string{"203.0.113.7"}, "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxies := trusted
+			if !tt.trusted {
+				proxies = nil
+			}
+
+			var got string
+			handler := ClientIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = reqctx.ClientIP(r.Context())
+			}), proxies)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.peer
+			for _, header := range tt.forwarded {
+				r.Header.Add(HeaderForwardedFor, header)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != tt.want {
+				t.Fatalf("client IP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		proxies []string
+		err     bool
+	}{
+		{[]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32", "::1"}, false},
+		{[]string{"proxy.internal"}, true},
+		{[]string{"10.0.0.0/33"}, true},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseTrustedProxies(tt.proxies); (err != nil) != tt.err {
+			t.Fatalf("ParseTrustedProxies(%v) error = %v, want error %v", tt.proxies, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"app/internal/pkg/auth"
+	"app/internal/pkg/idempotency"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/problem"
+	"app/internal/pkg/reqctx"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+)
+
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize bounds the request body read to fingerprint it.
+	maxIdempotentBodySize = 1 << 20
+)
+
+// IdempotencyStores looks up where responses to requests with an
+// Idempotency-Key are kept.
+type IdempotencyStores interface {
+	IdempotencyStore() idempotency.Store
+}
+
+// Idempotency makes a POST with an Idempotency-Key safe to retry. The first
+// response is stored and replayed to retries with the same key from the
+// same caller; a retry while the first request is in flight gets 409 and
+// one with another payload gets 422. Server errors are not stored, so the
+// request can be retried. Keys are scoped to the authenticated subject, or
+// to the client address for anonymous callers.
+func Idempotency(next http.Handler, stores IdempotencyStores, log logger.Interface) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientKey := r.Header.Get(HeaderIdempotencyKey)
+		store := stores.IdempotencyStore()
+		if r.Method != http.MethodPost || clientKey == "" || store == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		txID := reqctx.RequestID(r.Context())
+
+		log := logger.WithContext(r.Context(), log)
+
+		if len(clientKey) > maxIdempotencyKeyLength {
+			problem.Write(w, r, http.StatusBadRequest, fmt.Sprintf("%s is longer than %d characters", HeaderIdempotencyKey, maxIdempotencyKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				problem.Write(w, r, http.StatusRequestEntityTooLarge, "request body is too large")
+				return
+			}
+			problem.Write(w, r, http.StatusBadRequest, "request body could not be read")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := idempotency.Key(idempotencyCaller(r), clientKey)
+		fingerprint := idempotency.Fingerprint(r.Method, r.URL.RequestURI(), body)
+
+		claim, record, err := store.Begin(r.Context(), key, fingerprint)
+		if err != nil {
+			log.Error(fmt.Sprintf("txID: %s middleware.Idempotency, error claiming key: %v", txID, err))
+			problem.Write(w, r, http.StatusServiceUnavailable, "the request cannot be made idempotent right now, retry later")
+			return
+		}
+
+		switch {
+		case claim != nil:
+		case record.Fingerprint != fingerprint:
+			problem.Write(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("%s was already used for another request", HeaderIdempotencyKey))
+			return
+		case record.Response == nil:
+			problem.Write(w, r, http.StatusConflict, fmt.Sprintf("a request with this %s is still being processed", HeaderIdempotencyKey))
+			return
+		default:
+			log.Debug(fmt.Sprintf("txID: %s middleware.Idempotency, replaying the response to %s", txID, r.URL.Path))
+			replay(w, record.Response)
+			return
+		}
+
+		capture := newCaptureWriter(w)
+		settled := false
+		defer func() {
+			if settled {
+				return
+			}
+			// The handler panicked: give the key up so that a retry is served.
+			if err := store.Release(context.WithoutCancel(r.Context()), claim); err != nil {
+				log.Error(fmt.Sprintf("txID: %s middleware.Idempotency, error releasing key: %v", txID, err))
+			}
+		}()
+
+		next.ServeHTTP(capture, r)
+
+		settled = true
+		ctx := context.WithoutCancel(r.Context())
+		if capture.Status() >= http.StatusInternalServerError {
+			if err := store.Release(ctx, claim); err != nil {
+				log.Error(fmt.Sprintf("txID: %s middleware.Idempotency, error releasing key: %v", txID, err))
+			}
+			return
+		}
+
+		err = store.Complete(ctx, claim, capture.response())
+		if errors.Is(err, idempotency.ErrClaimLost) {
+			log.Warn(fmt.Sprintf("txID: %s middleware.Idempotency, key lapsed before the response was stored, it is not replayed", txID))
+		} else if err != nil {
+			log.Error(fmt.Sprintf("txID: %s middleware.Idempotency, error storing response: %v", txID, err))
+		}
+	})
+}
+
+// idempotencyCaller names who a key belongs to. Callers behind the same
+// address share their anonymous keys, which only matters if they pick the
+// same key for the same request.
+func idempotencyCaller(r *http.Request) string {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		return "sub:" + principal.Subject
+	}
+	return "ip:" + reqctx.ClientIP(r.Context())
+}
+
+func replay(w http.ResponseWriter, response *idempotency.Response) {
+	header := w.Header()
+	for name, values := range response.Header {
+		header[name] = slices.Clone(values)
+	}
+	header.Set(HeaderIdempotentReplayed, "true")
+
+	w.WriteHeader(response.Status)
+	_, _ = w.Write(response.Body)
+}
+
+// captureWriter passes the response through and keeps a copy of it. Only
+// headers set by the handler are kept: those set by middleware in front of
+// it, such as the request ID, belong to each request.
+type captureWriter struct {
+	*StatusRecorder
+	before http.Header
+	body   bytes.Buffer
+}
+
+func newCaptureWriter(w http.ResponseWriter) *captureWriter {
+	return &captureWriter{StatusRecorder: NewStatusRecorder(w), before: w.Header().Clone()}
+}
+
+func (c *captureWriter) Write(b []byte) (int, error) {
+	n, err := c.StatusRecorder.Write(b)
+	c.body.Write(b[:n])
+	return n, err
+}
+
+func (c *captureWriter) response() *idempotency.Response {
+	header := make(http.Header)
+	for name, values := range c.Header() {
+		if !slices.Equal(values, c.before[name]) {
+			header[name] = slices.Clone(values)
+		}
+	}
+
+	return &idempotency.Response{
+		Status: c.Status(),
+		Header: header,
+		Body:   c.body.Bytes(),
+	}
+}
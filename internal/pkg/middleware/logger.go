@@ -2,43 +2,69 @@ package middleware
 
 import (
 	"app/internal/pkg/logger"
-	"app/internal/pkg/types"
-	"context"
+	"app/internal/pkg/reqctx"
 	"fmt"
 	"net/http"
 	"time"
+)
 
-	"github.com/google/uuid"
+const (
+	HeaderRequestID   = "X-Request-ID"
+	HeaderTraceparent = "traceparent"
+	HeaderTracestate  = "tracestate"
 )
 
-func Logging(next http.Handler, logger logger.Interface) http.Handler {
+// Logging identifies the request, puts the request ID and the trace context
+// into its context and logs it. A valid X-Request-ID from the client is
+// kept, so that logs can be correlated across services; the ID is echoed in
+// the response either way. When Tracing has already
+// started a span, its trace context is kept; otherwise the caller's
+// traceparent is continued. Only the path is logged, since query strings
+// may carry secrets such as verification tokens.
+func Logging(next http.Handler, log logger.Interface) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		UUID := uuid.New().String()
 
-		r = r.WithContext(
-			context.WithValue(r.Context(), types.CtxKey("tx"), UUID),
-		)
+		requestID := r.Header.Get(HeaderRequestID)
+		if !reqctx.ValidRequestID(requestID) {
+			requestID = reqctx.NewRequestID()
+		}
+
+		ctx := reqctx.WithRequestID(r.Context(), requestID)
+
+		trace, ok := reqctx.TraceFromContext(ctx)
+		if !ok {
+			trace = reqctx.ContinueTrace(r.Header.Get(HeaderTraceparent), r.Header.Get(HeaderTracestate))
+			ctx = reqctx.WithTrace(ctx, trace)
+		}
+		r = r.WithContext(ctx)
+
+		w.Header().Set(HeaderRequestID, requestID)
 
-		logger.Info(
+		log.Info(
 			fmt.Sprintf(
-				"Request: [%s] -> Path: [%s] | UUID: %s",
+				"Request: [%s] -> Path: [%s] | UUID: %s | trace: %s",
 				r.Method,
-				r.RequestURI,
-				UUID,
+				r.URL.Path,
+				requestID,
+				trace.TraceID,
 			),
+			logger.NewField("trace_id", trace.TraceID),
+			logger.NewField("span_id", trace.ParentID),
 		)
 
 		next.ServeHTTP(w, r)
 
-		logger.Debug(
+		log.Debug(
 			fmt.Sprintf(
 				"Request Completed: [%s] -> Path: [%s] in [%v] | UUID: %s",
 				r.Method,
-				r.RequestURI,
+				r.URL.Path,
 				time.Since(start),
-				UUID,
+				requestID,
 			),
+			logger.NewField("trace_id", trace.TraceID),
+			logger.NewField("span_id", trace.ParentID),
 		)
 	})
 }
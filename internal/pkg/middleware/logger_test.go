@@ -0,0 +1,40 @@
+package middleware_test
+
+import (
+	"app/internal/pkg/logger"
+	"app/internal/pkg/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordingLogger keeps the messages logged at every level.
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Debug(msg string, _ ...logger.Field) { l.messages = append(l.messages, msg) }
+func (l *recordingLogger) Info(msg string, _ ...logger.Field)  { l.messages = append(l.messages, msg) }
+func (l *recordingLogger) Warn(msg string, _ ...logger.Field)  { l.messages = append(l.messages, msg) }
+func (l *recordingLogger) Error(msg string, _ ...logger.Field) { l.messages = append(l.messages, msg) }
+func (l *recordingLogger) Fatal(msg string, _ ...logger.Field) { l.messages = append(l.messages, msg) }
+
+func TestLoggingOmitsQuery(t *testing.T) {
+	log := &recordingLogger{}
+	handler := middleware.Logging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), log)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/verify?token=secret", nil))
+
+	if len(log.messages) == 0 {
+		t.Fatalf("nothing logged")
+	}
+	for _, msg := range log.messages {
+		if strings.Contains(msg, "secret") {
+			t.Fatalf("logged %q, want the query left out", msg)
+		}
+		if !strings.Contains(msg, "/users/verify") {
+			t.Fatalf("logged %q, want the path", msg)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"app/internal/pkg/metrics"
+	"net/http"
+)
+
+// Metrics records the rate, errors and duration of requests by route.
+func Metrics(next http.Handler, observer metrics.HTTP) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, route := withRoute(r)
+		recorder := NewStatusRecorder(w)
+
+		done := observer.Started()
+		defer func() {
+			done(method(r.Method), route.String(), recorder.Status())
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// method keeps arbitrary methods sent by clients out of the labels.
+func method(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return m
+	default:
+		return "OTHER"
+	}
+}
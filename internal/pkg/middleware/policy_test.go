@@ -0,0 +1,96 @@
+package middleware_test
+
+import (
+	"app/internal/pkg/auth"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...logger.Field) {}
+func (nopLogger) Info(string, ...logger.Field)  {}
+func (nopLogger) Warn(string, ...logger.Field)  {}
+func (nopLogger) Error(string, ...logger.Field) {}
+func (nopLogger) Fatal(string, ...logger.Field) {}
+
+// extractor returns principal, or err when it is set.
+type extractor struct {
+	principal *auth.Principal
+	err       error
+}
+
+func (e extractor) Extract(*http.Request) (*auth.Principal, error) {
+	return e.principal, e.err
+}
+
+func TestAuthorize(t *testing.T) {
+	const alice = "6f1c2d1e-7a4b-4c55-9a0e-2f1f0c6d9b11"
+
+	reader := &auth.Principal{Subject: "reader", Permissions: []string{"users:read"}}
+	admin := &auth.Principal{Subject: "admin", Permissions: []string{"users:read", "users:write"}}
+	owner := &auth.Principal{Subject: alice}
+
+	tests := []struct {
+		name      string
+		policy    middleware.Policy
+		extractor extractor
+		target    string
+		status    int
+	}{
+		{"public without credentials", middleware.Public(), extractor{err: middleware.ErrNoCredentials}, "/users", http.StatusOK},
+		{"authenticated", middleware.Policy{}, extractor{principal: owner}, "/users", http.StatusOK},
+		{"no credentials", middleware.Policy{}, extractor{err: middleware.ErrNoCredentials}, "/users", http.StatusUnauthorized},
+		{"invalid credentials", middleware.Policy{}, extractor{err: middleware.ErrInvalidCredentials}, "/users", http.StatusUnauthorized},
+		{"permission granted", middleware.Require("users:read"), extractor{principal: reader}, "/users", http.StatusOK},
+		{"permission missing", middleware.Require("users:write"), extractor{principal: reader}, "/users", http.StatusForbidden},
+		{"all permissions needed", middleware.Require("users:read", "users:write"), extractor{principal: reader}, "/users", http.StatusForbidden},
+		{"all permissions held", middleware.Require("users:read", "users:write"), extractor{principal: admin}, "/users", http.StatusOK},
+		{"owner by query", middleware.OwnerOr("uuid", "users:write"), extractor{principal: owner}, "/users?uuid=" + alice, http.StatusOK},
+		{"not the owner", middleware.OwnerOr("uuid", "users:write"), extractor{principal: reader}, "/users?uuid=" + alice, http.StatusForbidden},
+		{"permission instead of owner", middleware.OwnerOr("uuid", "users:write"), extractor{principal: admin}, "/users?uuid=" + alice, http.StatusOK},
+		{"owner without permissions", middleware.OwnerOr("uuid"), extractor{principal: admin}, "/users?uuid=" + alice, http.StatusForbidden},
+		{"owner missing from request", middleware.OwnerOr("uuid"), extractor{principal: owner}, "/users", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, ok := auth.PrincipalFromContext(r.Context()); !ok && !tt.policy.Public {
+					t.Error("handler called without a principal")
+				}
+			})
+
+			w := httptest.NewRecorder()
+			middleware.Authorize(next, tt.policy, tt.extractor, nopLogger{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestPolicyString(t *testing.T) {
+	tests := []struct {
+		policy middleware.Policy
+		want   string
+	}{
+		{middleware.Public(), "public"},
+		{middleware.Policy{}, "authenticated"},
+		{middleware.Require("users:read", "users:write"), "users:read+users:write"},
+		{middleware.OwnerOr("uuid", "users:write"), "owner(uuid) or users:write"},
+		{middleware.Require("roles:manage").WithIdempotency(), "roles:manage"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.policy.String(); got != tt.want {
+				t.Fatalf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"app/internal/pkg/auth"
+	"app/internal/pkg/logger"
+	"app/internal/pkg/problem"
+	"app/internal/pkg/ratelimit"
+	"app/internal/pkg/reqctx"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderRetryAfter         = "Retry-After"
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+)
+
+// RateLimits looks up the limit of a route by the pattern it was registered
+// with.
+type RateLimits interface {
+	RateLimit(pattern string) (ratelimit.Limiter, ratelimit.Rule, bool)
+}
+
+// RateLimit takes every request to the route registered as pattern from its
+// caller's quota and answers 429 once it is spent. It runs after
+// authentication so that callers can be told apart by subject. When the
+// limiter fails the request is let through: an outage of Redis should not
+// take the API down with it.
+func RateLimit(next http.Handler, pattern string, limits RateLimits, log logger.Interface) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter, rule, ok := limits.RateLimit(pattern)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		txID := reqctx.RequestID(r.Context())
+
+		log := logger.WithContext(r.Context(), log)
+		key := pattern + "|" + rateLimitKey(r, rule.Key)
+
+		result, err := limiter.Allow(r.Context(), key, rule)
+		if err != nil {
+			log.Warn(fmt.Sprintf("txID: %s middleware.RateLimit, letting %s through, limiter failed: %v", txID, pattern, err))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+		header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+		header.Set(HeaderRateLimitReset, seconds(result.Reset))
+		header.Set(HeaderRateLimitPolicy, fmt.Sprintf("%d;w=%s", rule.Limit, seconds(rule.Period)))
+
+		if !result.Allowed {
+			log.Debug(fmt.Sprintf("txID: %s middleware.RateLimit, %s exceeded %s", txID, key, pattern))
+			header.Set(HeaderRetryAfter, seconds(result.RetryAfter))
+			problem.Write(w, r, http.StatusTooManyRequests, "too many requests, retry later")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitKey tells callers apart. Only what authentication has validated
+// is trusted, since a caller could otherwise get a fresh quota by sending a
+// new value; requests without it are counted by client address. API keys
+// are counted by session rather than by access token, which a refresh
+// replaces.
+func rateLimitKey(r *http.Request, key string) string {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		switch key {
+		case ratelimit.KeySubject:
+			return "sub:" + principal.Subject
+		case ratelimit.KeyAPIKey:
+			if principal.SessionID != "" {
+				return "key:" + principal.SessionID
+			}
+		}
+	}
+
+	return "ip:" + reqctx.ClientIP(r.Context())
+}
+
+// seconds rounds up, so that a caller waiting that long is let through.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
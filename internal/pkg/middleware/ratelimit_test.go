@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"app/internal/pkg/auth"
+	"app/internal/pkg/ratelimit"
+	"app/internal/pkg/reqctx"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimitKey(t *testing.T) {
+	session := &auth.Principal{Subject: "user", TokenID: "jti-1", SessionID: "session"}
+	refreshed := &auth.Principal{Subject: "user", TokenID: "jti-2", SessionID: "session"}
+	sessionless := &auth.Principal{Subject: "user", TokenID: "jti-3"}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		key       string
+		want      string
+	}{
+		{"ip", session, ratelimit.KeyIP, "ip:203.0.113.7"},
+		{"subject", session, ratelimit.KeySubject, "sub:user"},
+		{"api key", session, ratelimit.KeyAPIKey, "key:session"},
+		{"api key after refresh", refreshed, ratelimit.KeyAPIKey, "key:session"},
+		{"api key without session", sessionless, ratelimit.KeyAPIKey, "ip:203.0.113.7"},
+		{"anonymous subject", nil, ratelimit.KeySubject, "ip:203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			ctx := reqctx.WithClientIP(r.Context(), "203.0.113.7")
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+			r = r.WithContext(ctx)
+
+			if got := rateLimitKey(r, tt.key); got != tt.want {
+				t.Fatalf("rateLimitKey(%s) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
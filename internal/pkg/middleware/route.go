@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// unmatchedRoute labels requests no route matched, such as 404s.
+const unmatchedRoute = "unmatched"
+
+type routeKey struct{}
+
+// matchedRoute is filled in by Route. The mux hands the matched pattern to
+// the handler on a copy of the request, so middleware running before it
+// learns the route through this shared value.
+type matchedRoute struct {
+	pattern string
+}
+
+func withRoute(r *http.Request) (*http.Request, *matchedRoute) {
+	if route, ok := r.Context().Value(routeKey{}).(*matchedRoute); ok {
+		return r, route
+	}
+
+	route := &matchedRoute{}
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, route)), route
+}
+
+func (m *matchedRoute) String() string {
+	if m.pattern == "" {
+		return unmatchedRoute
+	}
+	return m.pattern
+}
+
+// Route records the pattern the mux matched for the middleware in front of
+// it, and names the request span after it, which keeps span names bounded
+// unlike raw paths.
+func Route(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Pattern != "" {
+			if route, ok := r.Context().Value(routeKey{}).(*matchedRoute); ok {
+				route.pattern = r.Pattern
+			}
+
+			span := trace.SpanFromContext(r.Context())
+			span.SetName(r.Pattern)
+			span.SetAttributes(attribute.String(string(semconv.HTTPRouteKey), r.Pattern))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"app/internal/pkg/reqctx"
+	"app/internal/pkg/telemetry"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "app/internal/pkg/middleware"
+
+// Tracing starts a server span for every request, continuing the trace of
+// the caller's traceparent header. The span is named after the route once
+// the mux has matched it; see Route.
+func Tracing(next http.Handler) http.Handler {
+	tracer := telemetry.Tracer(tracerName)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		remote := trace.SpanContextFromContext(ctx)
+
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.ClientAddress(reqctx.ClientIP(r.Context())),
+				semconv.UserAgentOriginal(r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		// Without a tracer provider the span only carries the caller's
+		// context along; Logging then derives the trace context itself.
+		if sc := span.SpanContext(); sc.IsValid() && sc.SpanID() != remote.SpanID() {
+			ctx = reqctx.WithTrace(ctx, reqctx.Trace{
+				TraceID:  sc.TraceID().String(),
+				ParentID: sc.SpanID().String(),
+				Caller:   callerID(remote),
+				Flags:    byte(sc.TraceFlags()),
+				State:    sc.TraceState().String(),
+			})
+		}
+
+		recorder := NewStatusRecorder(w)
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.Status()))
+		if recorder.Status() >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.Status()))
+		}
+	})
+}
+
+func callerID(remote trace.SpanContext) string {
+	if !remote.IsValid() {
+		return ""
+	}
+	return remote.SpanID().String()
+}
@@ -0,0 +1,73 @@
+package problem
+
+import (
+	"app/internal/pkg/reqctx"
+	"encoding/json"
+	"net/http"
+)
+
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Extensions are serialised
+// as additional top level members.
+type Problem struct {
+	Type       string                 `json:"type"`
+	Title      string                 `json:"title"`
+	Status     int                    `json:"status"`
+	Detail     string                 `json:"detail,omitempty"`
+	Instance   string                 `json:"instance,omitempty"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	Extensions map[string]interface{} `json:"-"`
+}
+
+func New(r *http.Request, status int, detail string) *Problem {
+	requestID := reqctx.RequestID(r.Context())
+
+	return &Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: requestID,
+	}
+}
+
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type plain Problem
+
+	raw, err := json.Marshal((*plain)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return raw, err
+	}
+
+	members := make(map[string]interface{}, len(p.Extensions)+7)
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+	if err = json.Unmarshal(raw, &members); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(members)
+}
+
+func (p *Problem) Write(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// Write is a shorthand for New(r, status, detail).Write(w).
+func Write(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	New(r, status, detail).Write(w)
+}
@@ -0,0 +1,84 @@
+package problem_test
+
+import (
+	"app/internal/pkg/problem"
+	"app/internal/pkg/reqctx"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name       string
+		requestID  string
+		extensions map[string]interface{}
+		want       map[string]interface{}
+	}{
+		{
+			name: "without request id",
+			want: map[string]interface{}{
+				"type":     "about:blank",
+				"title":    "Not Found",
+				"status":   float64(http.StatusNotFound),
+				"detail":   "user not found",
+				"instance": "/users",
+			},
+		},
+		{
+			name:      "with request id and extensions",
+			requestID: "req-1",
+			extensions: map[string]interface{}{
+				"errors": []interface{}{"login"},
+				// Members of the problem win over extensions of the same name.
+				"status": "shadowed",
+			},
+			want: map[string]interface{}{
+				"type":       "about:blank",
+				"title":      "Not Found",
+				"status":     float64(http.StatusNotFound),
+				"detail":     "user not found",
+				"instance":   "/users",
+				"request_id": "req-1",
+				"errors":     []interface{}{"login"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/users?uuid=1", nil)
+			if tt.requestID != "" {
+				r = r.WithContext(reqctx.WithRequestID(r.Context(), tt.requestID))
+			}
+
+			p := problem.New(r, http.StatusNotFound, "user not found")
+			for k, v := range tt.extensions {
+				p.With(k, v)
+			}
+
+			w := httptest.NewRecorder()
+			p.Write(w)
+
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+			}
+			if got := w.Header().Get("Content-Type"); got != problem.ContentType {
+				t.Fatalf("Content-Type = %q, want %q", got, problem.ContentType)
+			}
+			if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Fatalf("X-Content-Type-Options = %q, want nosniff", got)
+			}
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body is not JSON: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("body = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
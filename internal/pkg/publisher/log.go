@@ -0,0 +1,41 @@
+package publisher
+
+import (
+	"app/internal/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var _ Interface = (*Log)(nil)
+
+// Log writes every message to the application log instead of sending it.
+// The payload is left out, since it may hold personal data.
+type Log struct {
+	logger logger.Interface
+}
+
+func NewLog(logger logger.Interface) (*Log, error) {
+	if logger == nil {
+		return nil, errors.New("publisher.NewLog: logger is null")
+	}
+
+	return &Log{logger: logger}, nil
+}
+
+func (l *Log) Publish(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("publisher.Log.Publish: %w", err)
+	}
+
+	l.logger.Info("event published",
+		logger.NewField("event_id", msg.ID),
+		logger.NewField("event_type", msg.Type),
+		logger.NewField("aggregate_id", msg.AggregateID),
+		logger.NewField("occurred_at", msg.OccurredAt.UTC().Format(time.RFC3339)),
+		logger.NewField("payload_bytes", len(msg.Payload)),
+	)
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+var _ Interface = (*Memory)(nil)
+
+// Memory keeps published messages in memory, for tests.
+// Fail makes the next Publish calls return an error, to exercise retries.
+type Memory struct {
+	mu       sync.Mutex
+	messages []Message
+	failures int
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Publish(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("publisher.Memory.Publish: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.failures > 0 {
+		m.failures--
+		return fmt.Errorf("publisher.Memory.Publish: induced failure")
+	}
+
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of the messages published so far.
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+// Fail makes the next n calls to Publish fail.
+func (m *Memory) Fail(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.failures = n
+}
@@ -0,0 +1,41 @@
+package publisher
+
+import (
+	"app/internal/pkg/config"
+	"app/internal/pkg/logger"
+	"context"
+	"fmt"
+	"time"
+)
+
+const DriverLog = "log"
+
+// Message is an event handed to other systems. ID is stable across
+// redeliveries, so that consumers can drop duplicates: delivery is at least
+// once.
+type Message struct {
+	ID          string
+	Type        string
+	AggregateID string
+	Payload     []byte
+	OccurredAt  time.Time
+}
+
+type Interface interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// New returns the publisher selected by cfg.Publisher. Memory is not among
+// them: it only keeps messages for tests to inspect.
+func New(cfg *config.Outbox, logger logger.Interface) (Interface, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("publisher.New: cfg is null")
+	}
+
+	switch cfg.Publisher {
+	case DriverLog:
+		return NewLog(logger)
+	default:
+		return nil, fmt.Errorf("publisher.New: unknown publisher %q", cfg.Publisher)
+	}
+}
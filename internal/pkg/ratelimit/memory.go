@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+var _ Limiter = (*Memory)(nil)
+
+type bucket struct {
+	// Token bucket.
+	tokens float64
+	last   time.Time
+
+	// Sliding window.
+	window   int64
+	current  int64
+	previous int64
+
+	expiresAt time.Time
+}
+
+// Memory keeps the quotas in process, for single instance runs without
+// Redis. They are lost on restart.
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (m *Memory) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	b := m.lookup(key+":"+rule.Algorithm, now)
+
+	if rule.Algorithm == AlgorithmSlidingWindow {
+		return m.slide(b, rule, now), nil
+	}
+	return m.take(b, rule, now), nil
+}
+
+func (m *Memory) take(b *bucket, rule Rule, now time.Time) Result {
+	if b.last.IsZero() {
+		b.tokens = float64(rule.capacity())
+	} else {
+		b.tokens = refill(rule, b.tokens, b.last, now)
+	}
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	result := tokenBucket(rule, allowed, b.tokens)
+	b.expiresAt = now.Add(result.Reset)
+	return result
+}
+
+func (m *Memory) slide(b *bucket, rule Rule, now time.Time) Result {
+	window := now.UnixNano() / int64(rule.Period)
+	switch window - b.window {
+	case 0:
+	case 1:
+		b.previous, b.current = b.current, 0
+	default:
+		b.previous, b.current = 0, 0
+	}
+	b.window = window
+
+	allowed := float64(b.previous)*windowWeight(rule, now)+float64(b.current)+1 <= float64(rule.Limit)
+	if allowed {
+		b.current++
+	}
+
+	b.expiresAt = time.Unix(0, (window+2)*int64(rule.Period))
+	return slidingWindow(rule, allowed, b.current, b.previous, now)
+}
+
+// lookup returns the bucket of key, creating it if it is missing or has
+// expired. Expired buckets of other keys are swept now and then so that
+// the map cannot grow without bound. Callers must hold m.mu.
+func (m *Memory) lookup(key string, now time.Time) *bucket {
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		for k, b := range m.buckets {
+			if !now.Before(b.expiresAt) {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	b, ok := m.buckets[key]
+	if !ok || !now.Before(b.expiresAt) {
+		b = &bucket{}
+		m.buckets[key] = b
+	}
+
+	return b
+}
@@ -0,0 +1,206 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AlgorithmTokenBucket   = "token_bucket"
+	AlgorithmSlidingWindow = "sliding_window"
+
+	KeyIP      = "ip"
+	KeySubject = "subject"
+	KeyAPIKey  = "api_key"
+)
+
+// Rule limits a route to Limit requests per Period. With the token bucket
+// up to Burst requests may come at once; Burst defaults to Limit. The
+// sliding window weighs the previous window by how much of it still
+// overlaps the last Period.
+type Rule struct {
+	Algorithm string
+	Limit     int
+	Period    time.Duration
+	Burst     int
+	// Key names what requests are counted by: the client address, the
+	// authenticated subject or the API key, i.e. the session its access
+	// tokens were issued for. Unauthenticated requests are counted by
+	// address.
+	Key string
+}
+
+func (r Rule) capacity() int {
+	if r.Algorithm == AlgorithmTokenBucket && r.Burst > 0 {
+		return r.Burst
+	}
+	return r.Limit
+}
+
+// Result is the outcome of one request against a rule.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the quota is whole again.
+	Reset time.Duration
+	// RetryAfter is how long a denied caller has to wait.
+	RetryAfter time.Duration
+}
+
+// Limiter takes one request from the quota of key.
+type Limiter interface {
+	Allow(ctx context.Context, key string, rule Rule) (Result, error)
+}
+
+// tokenBucket works out the result from the tokens left after the request.
+func tokenBucket(rule Rule, allowed bool, tokens float64) Result {
+	capacity := float64(rule.capacity())
+	perToken := float64(rule.Period) / float64(rule.Limit)
+
+	result := Result{
+		Allowed:   allowed,
+		Limit:     rule.capacity(),
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((capacity - tokens) * perToken),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * perToken)
+	}
+
+	return result
+}
+
+// refill returns the tokens of a bucket last seen at last holding tokens.
+func refill(rule Rule, tokens float64, last time.Time, now time.Time) float64 {
+	elapsed := now.Sub(last)
+	if elapsed <= 0 {
+		return tokens
+	}
+	tokens += float64(elapsed) * float64(rule.Limit) / float64(rule.Period)
+	return math.Min(tokens, float64(rule.capacity()))
+}
+
+// slidingWindow works out the result from the counts of the current and the
+// previous window, including the request if it was allowed.
+func slidingWindow(rule Rule, allowed bool, current int64, previous int64, now time.Time) Result {
+	window := rule.Period
+	elapsed := time.Duration(now.UnixNano() % int64(window))
+	estimate := float64(previous)*windowWeight(rule, now) + float64(current)
+
+	result := Result{
+		Allowed:   allowed,
+		Limit:     rule.Limit,
+		Remaining: max(0, int(math.Floor(float64(rule.Limit)-estimate))),
+		Reset:     window - elapsed,
+	}
+	if allowed {
+		return result
+	}
+
+	// Wait until the previous window weighs little enough for one more
+	// request, which may only happen in the next window.
+	limit := float64(rule.Limit - 1)
+	if float64(current) <= limit {
+		if previous > 0 {
+			needed := time.Duration(float64(window) * (1 - (limit-float64(current))/float64(previous)))
+			result.RetryAfter = max(needed-elapsed, time.Millisecond)
+		}
+		return result
+	}
+
+	result.RetryAfter = window - elapsed
+	if current > 0 {
+		result.RetryAfter += time.Duration(math.Max(0, float64(window)*(1-limit/float64(current))))
+	}
+	return result
+}
+
+// windowWeight is how much the previous window counts at now.
+func windowWeight(rule Rule, now time.Time) float64 {
+	elapsed := now.UnixNano() % int64(rule.Period)
+	return float64(int64(rule.Period)-elapsed) / float64(rule.Period)
+}
+
+// ParseRules reads per-route rules written as
+//
+//	POST /users=10/1m;POST /auth/login=5/1m,burst=10,key=ip,algorithm=sliding_window
+//
+// Routes are matched by the pattern they were registered with. Options left
+// out fall back to algorithm and key.
+func ParseRules(spec string, algorithm string, key string) (map[string]Rule, error) {
+	rules := make(map[string]Rule)
+
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		pattern, definition, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("ratelimit.ParseRules: %q has no limit", entry)
+		}
+		pattern = strings.Join(strings.Fields(pattern), " ")
+
+		rule, err := parseRule(definition, algorithm, key)
+		if err != nil {
+			return nil, fmt.Errorf("ratelimit.ParseRules: %s: %w", pattern, err)
+		}
+		rules[pattern] = rule
+	}
+
+	return rules, nil
+}
+
+func parseRule(definition string, algorithm string, key string) (Rule, error) {
+	parts := strings.Split(definition, ",")
+	rule := Rule{Algorithm: algorithm, Key: key}
+
+	limit, period, ok := strings.Cut(strings.TrimSpace(parts[0]), "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("%q is not <limit>/<period>", parts[0])
+	}
+
+	var err error
+	if rule.Limit, err = strconv.Atoi(limit); err != nil || rule.Limit <= 0 {
+		return Rule{}, fmt.Errorf("limit %q is not a positive number", limit)
+	}
+	if rule.Period, err = time.ParseDuration(period); err != nil || rule.Period <= 0 {
+		return Rule{}, fmt.Errorf("period %q is not a positive duration", period)
+	}
+
+	for _, option := range parts[1:] {
+		name, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+		switch name {
+		case "burst":
+			if rule.Burst, err = strconv.Atoi(value); err != nil || rule.Burst <= 0 {
+				return Rule{}, fmt.Errorf("burst %q is not a positive number", value)
+			}
+		case "key":
+			rule.Key = value
+		case "algorithm":
+			rule.Algorithm = value
+		default:
+			return Rule{}, fmt.Errorf("unknown option %q", name)
+		}
+	}
+
+	switch rule.Algorithm {
+	case AlgorithmTokenBucket, AlgorithmSlidingWindow:
+	default:
+		return Rule{}, fmt.Errorf("unknown algorithm %q", rule.Algorithm)
+	}
+
+	switch rule.Key {
+	case KeyIP, KeySubject, KeyAPIKey:
+	default:
+		return Rule{}, fmt.Errorf("unknown key %q", rule.Key)
+	}
+
+	return rule, nil
+}
@@ -0,0 +1,193 @@
+package ratelimit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want map[string]Rule
+		err  bool
+	}{
+		{
+			name: "defaults",
+			spec: "POST /users=10/1m",
+			want: map[string]Rule{
+				"POST /users": {Algorithm: AlgorithmTokenBucket, Limit: 10, Period: time.Minute, Key: KeyIP},
+			},
+		},
+		{
+			name: "options and spacing",
+			spec: " POST   /auth/login = 5/1m, burst=10, key=subject, algorithm=sliding_window ;; GET /users=100/1s,key=api_key",
+			want: map[string]Rule{
+				"POST /auth/login": {Algorithm: AlgorithmSlidingWindow, Limit: 5, Period: time.Minute, Burst: 10, Key: KeySubject},
+				"GET /users":       {Algorithm: AlgorithmTokenBucket, Limit: 100, Period: time.Second, Key: KeyAPIKey},
+			},
+		},
+		{name: "empty", spec: "", want: map[string]Rule{}},
+		{name: "no limit", spec: "POST /users", err: true},
+		{name: "no period", spec: "POST /users=10", err: true},
+		{name: "zero limit", spec: "POST /users=0/1m", err: true},
+		{name: "bad period", spec: "POST /users=10/soon", err: true},
+		{name: "negative burst", spec: "POST /users=10/1m,burst=-1", err: true},
+		{name: "unknown option", spec: "POST /users=10/1m,speed=2", err: true},
+		{name: "unknown algorithm", spec: "POST /users=10/1m,algorithm=leaky", err: true},
+		{name: "unknown key", spec: "POST /users=10/1m,key=header", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseRules(tt.spec, AlgorithmTokenBucket, KeyIP)
+			if (err != nil) != tt.err {
+				t.Fatalf("ParseRules() error = %v, want error %v", err, tt.err)
+			}
+			if !tt.err && !reflect.DeepEqual(rules, tt.want) {
+				t.Fatalf("ParseRules() = %+v, want %+v", rules, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	// One token per second, up to 10.
+	rule := Rule{Algorithm: AlgorithmTokenBucket, Limit: 10, Period: 10 * time.Second}
+	burst := Rule{Algorithm: AlgorithmTokenBucket, Limit: 10, Period: 10 * time.Second, Burst: 20}
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		allowed bool
+		tokens  float64
+		want    Result
+	}{
+		{"full after the request", rule, true, 9, Result{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Second}},
+		{"partial token", rule, true, 9.5, Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 500 * time.Millisecond}},
+		{"denied", rule, false, 0.25, Result{Limit: 10, Reset: 9750 * time.Millisecond, RetryAfter: 750 * time.Millisecond}},
+		{"burst is the limit", burst, true, 19, Result{Allowed: true, Limit: 20, Remaining: 19, Reset: time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenBucket(tt.rule, tt.allowed, tt.tokens); got != tt.want {
+				t.Fatalf("tokenBucket() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefill(t *testing.T) {
+	rule := Rule{Algorithm: AlgorithmTokenBucket, Limit: 10, Period: 10 * time.Second}
+	last := time.Unix(1000, 0)
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    float64
+	}{
+		{"no time passed", 0, 2},
+		{"clock went back", -time.Second, 2},
+		{"three seconds", 3 * time.Second, 5},
+		{"half a second", 500 * time.Millisecond, 2.5},
+		{"capped at capacity", time.Minute, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := refill(rule, 2, last, last.Add(tt.elapsed)); got != tt.want {
+				t.Fatalf("refill() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	rule := Rule{Algorithm: AlgorithmSlidingWindow, Limit: 10, Period: 10 * time.Second}
+	// A quarter into a window, where the previous one still weighs 0.75.
+	now := time.Unix(0, 0).Add(100*rule.Period + 2500*time.Millisecond)
+
+	tests := []struct {
+		name     string
+		limit    int
+		allowed  bool
+		current  int64
+		previous int64
+		want     Result
+	}{
+		{
+			name: "allowed", limit: 10, allowed: true, current: 3, previous: 4,
+			want: Result{Allowed: true, Limit: 10, Remaining: 4, Reset: 7500 * time.Millisecond},
+		},
+		{
+			// 8 * 0.5 + 5 leaves room for one more halfway into the window.
+			name: "denied until the previous window weighs less", limit: 10, current: 5, previous: 8,
+			want: Result{Limit: 10, Reset: 7500 * time.Millisecond, RetryAfter: 2500 * time.Millisecond},
+		},
+		{
+			// 4 * 0.5 leaves room for one more halfway into the next window.
+			name: "denied until the next window", limit: 3, current: 4, previous: 0,
+			want: Result{Limit: 3, Reset: 7500 * time.Millisecond, RetryAfter: 12500 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := rule
+			rule.Limit = tt.limit
+			if got := slidingWindow(rule, tt.allowed, tt.current, tt.previous, now); got != tt.want {
+				t.Fatalf("slidingWindow() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if got := windowWeight(rule, now); got != 0.75 {
+		t.Fatalf("windowWeight() = %v, want 0.75", got)
+	}
+}
+
+func TestMemoryAllow(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		// at are the offsets of the requests from the start of a window.
+		at      []time.Duration
+		allowed []bool
+	}{
+		{
+			name:    "token bucket refills",
+			rule:    Rule{Algorithm: AlgorithmTokenBucket, Limit: 2, Period: 2 * time.Second},
+			at:      []time.Duration{0, 0, 0, time.Second, time.Second},
+			allowed: []bool{true, true, false, true, false},
+		},
+		{
+			name:    "sliding window weighs the previous window",
+			rule:    Rule{Algorithm: AlgorithmSlidingWindow, Limit: 2, Period: 10 * time.Second},
+			at:      []time.Duration{0, time.Second, 2 * time.Second, 12 * time.Second, 15 * time.Second},
+			allowed: []bool{true, true, false, false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Unix(0, 0).Add(1000 * tt.rule.Period)
+			now := start
+			m := NewMemory()
+			m.now = func() time.Time { return now }
+
+			for i, at := range tt.at {
+				now = start.Add(at)
+				result, err := m.Allow(context.Background(), "ip:192.0.2.1", tt.rule)
+				if err != nil {
+					t.Fatalf("request %d: Allow: %v", i, err)
+				}
+				if result.Allowed != tt.allowed[i] {
+					t.Fatalf("request %d: Allow() = %+v, want allowed %v", i, result, tt.allowed[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"app/internal/pkg/cache"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "ratelimit:"
+
+var _ Limiter = (*Redis)(nil)
+
+// tokenBucketScript refills the bucket for the time since it was last seen,
+// takes a token if there is one and returns whether it did along with the
+// tokens left. The bucket refills at ARGV[2] tokens per ARGV[3]
+// milliseconds.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2]) / tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(state[1])
+local last = tonumber(state[2])
+if tokens == nil then
+	tokens = capacity
+elseif now > last then
+	tokens = math.min(capacity, tokens + (now - last) * rate)
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', math.max(now, last or now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((capacity - tokens) / rate) + 1)
+return {allowed, tostring(tokens)}
+`)
+
+// slidingWindowScript counts the request in the current window if the
+// weighted count of both windows leaves room for it, and returns whether
+// it did along with both counts.
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local weight = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+local allowed = 0
+if previous * weight + current + 1 <= limit then
+	current = redis.call('INCR', KEYS[1])
+	redis.call('PEXPIRE', KEYS[1], ttl)
+	allowed = 1
+end
+return {allowed, current, previous}
+`)
+
+// Redis keeps the quotas in Redis, shared by all instances. Each decision
+// is made by one script, so concurrent requests cannot overdraw a quota.
+type Redis struct {
+	client *redis.Client
+	now    func() time.Time
+}
+
+func NewRedis(cache *cache.Redis) (*Redis, error) {
+	if cache == nil || cache.Client == nil {
+		return nil, errors.New("ratelimit.NewRedis: cache is null")
+	}
+
+	return &Redis{client: cache.Client, now: time.Now}, nil
+}
+
+func (r *Redis) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	now := r.now()
+
+	if rule.Algorithm == AlgorithmSlidingWindow {
+		return r.slide(ctx, key, rule, now)
+	}
+	return r.take(ctx, key, rule, now)
+}
+
+func (r *Redis) take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	values, err := tokenBucketScript.Run(ctx, r.client,
+		[]string{keyPrefix + "tb:{" + key + "}"},
+		rule.capacity(), rule.Limit, rule.Period.Milliseconds(), now.UnixMilli(),
+	).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit.Allow: %w", err)
+	}
+
+	if len(values) != 2 {
+		return Result{}, fmt.Errorf("ratelimit.Allow: unexpected reply %v", values)
+	}
+
+	tokens, err := strconv.ParseFloat(fmt.Sprint(values[1]), 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit.Allow: %w", err)
+	}
+
+	return tokenBucket(rule, values[0] == int64(1), tokens), nil
+}
+
+func (r *Redis) slide(ctx context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	window := now.UnixNano() / int64(rule.Period)
+	keys := []string{
+		keyPrefix + "sw:{" + key + "}:" + strconv.FormatInt(window, 10),
+		keyPrefix + "sw:{" + key + "}:" + strconv.FormatInt(window-1, 10),
+	}
+
+	values, err := slidingWindowScript.Run(ctx, r.client, keys,
+		rule.Limit,
+		strconv.FormatFloat(windowWeight(rule, now), 'f', 6, 64),
+		(2 * rule.Period).Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit.Allow: %w", err)
+	}
+
+	if len(values) != 3 {
+		return Result{}, fmt.Errorf("ratelimit.Allow: unexpected reply %v", values)
+	}
+
+	return slidingWindow(rule, values[0] == 1, values[1], values[2], now), nil
+}
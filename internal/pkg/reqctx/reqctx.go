@@ -0,0 +1,46 @@
+// Package reqctx holds the values that identify a request in its context:
+// the request ID, the client address and the W3C trace context.
+package reqctx
+
+import (
+	"context"
+)
+
+type key int
+
+const (
+	requestIDKey key = iota
+	clientIPKey
+	traceKey
+)
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the ID of the request ctx belongs to, or "" outside a
+// request.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+// ClientIP returns the address of the peer that sent the request, or "".
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey).(string)
+	return ip
+}
+
+func WithTrace(ctx context.Context, trace Trace) context.Context {
+	return context.WithValue(ctx, traceKey, trace)
+}
+
+// TraceFromContext returns the trace context of the request.
+func TraceFromContext(ctx context.Context) (Trace, bool) {
+	trace, ok := ctx.Value(traceKey).(Trace)
+	return trace, ok
+}
@@ -0,0 +1,99 @@
+package reqctx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+	)
+
+	tests := []struct {
+		name   string
+		header string
+		ok     bool
+		flags  byte
+	}{
+		{"sampled", "00-" + traceID + "-" + parentID + "-01", true, 0x01},
+		{"not sampled", "00-" + traceID + "-" + parentID + "-00", true, 0x00},
+		{"surrounding spaces", " 00-" + traceID + "-" + parentID + "-01 ", true, 0x01},
+		{"later version with more fields", "01-" + traceID + "-" + parentID + "-01-extra", true, 0x01},
+		{"later version of version 00 length", "cc-" + traceID + "-" + parentID + "-01", true, 0x01},
+		{"empty", "", false, 0},
+		{"version ff", "ff-" + traceID + "-" + parentID + "-01", false, 0},
+		{"version 00 with more fields", "00-" + traceID + "-" + parentID + "-01-extra", false, 0},
+		{"later version without separator", "01-" + traceID + "-" + parentID + "-01extra", false, 0},
+		{"upper case", "00-" + strings.ToUpper(traceID) + "-" + parentID + "-01", false, 0},
+		{"zero trace id", "00-" + strings.Repeat("0", 32) + "-" + parentID + "-01", false, 0},
+		{"zero parent id", "00-" + traceID + "-" + strings.Repeat("0", 16) + "-01", false, 0},
+		{"wrong separator", "00_" + traceID + "-" + parentID + "-01", false, 0},
+		{"non hex flags", "00-" + traceID + "-" + parentID + "-zz", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace, ok := parseTraceparent(tt.header)
+			if ok != tt.ok {
+				t.Fatalf("parseTraceparent(%q) ok = %v, want %v", tt.header, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if trace.TraceID != traceID || trace.ParentID != parentID || trace.Flags != tt.flags {
+				t.Fatalf("parseTraceparent(%q) = %+v", tt.header, trace)
+			}
+		})
+	}
+}
+
+func TestContinueTrace(t *testing.T) {
+	const header = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"
+
+	trace := ContinueTrace(header, "vendor=value")
+	if trace.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || trace.Caller != "00f067aa0ba902b7" {
+		t.Fatalf("ContinueTrace() = %+v, want the caller's trace", trace)
+	}
+	if trace.ParentID == trace.Caller || len(trace.ParentID) != 16 {
+		t.Fatalf("ContinueTrace() parent = %q, want a new span", trace.ParentID)
+	}
+	if trace.State != "vendor=value" || trace.Sampled() {
+		t.Fatalf("ContinueTrace() = %+v, want the caller's state and flags", trace)
+	}
+	if got, want := trace.Traceparent(), "00-4bf92f3577b34da6a3ce929d0e0e4736-"+trace.ParentID+"-00"; got != want {
+		t.Fatalf("Traceparent() = %q, want %q", got, want)
+	}
+
+	started := ContinueTrace("garbage", strings.Repeat("x", maxTracestateSize+1))
+	if _, ok := parseTraceparent(started.Traceparent()); !ok || started.Caller != "" || started.State != "" || !started.Sampled() {
+		t.Fatalf("ContinueTrace() = %+v, want a new sampled trace", started)
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		name  string
+		id    string
+		valid bool
+	}{
+		{"uuid", "6f1c2d1e-7a4b-4c55-9a0e-2f1f0c6d9b11", true},
+		{"separators", "a.b_c~d:e/f+g=h@i", true},
+		{"longest", strings.Repeat("a", MaxRequestIDLength), true},
+		{"empty", "", false},
+		{"too long", strings.Repeat("a", MaxRequestIDLength+1), false},
+		{"space", "a b", false},
+		{"newline", "a\nb", false},
+		{"quote", `a"b`, false},
+		{"non ascii", "äb", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidRequestID(tt.id); got != tt.valid {
+				t.Fatalf("ValidRequestID(%q) = %v, want %v", tt.id, got, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package reqctx
+
+import (
+	"github.com/google/uuid"
+)
+
+// MaxRequestIDLength bounds the request IDs accepted from clients.
+const MaxRequestIDLength = 128
+
+// NewRequestID returns a fresh request ID.
+func NewRequestID() string {
+	return uuid.NewString()
+}
+
+// ValidRequestID reports whether id may be taken over from a client: it is
+// logged and echoed, so only short IDs of unreserved URL characters and a
+// few separators are accepted.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > MaxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == '~', c == ':', c == '/', c == '+', c == '=', c == '@':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
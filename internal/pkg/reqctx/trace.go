@@ -0,0 +1,108 @@
+package reqctx
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	traceparentLength = 55
+	maxTracestateSize = 512
+	sampledFlag       = 0x01
+)
+
+// Trace is a W3C trace context (https://www.w3.org/TR/trace-context/).
+// ParentID is the span of this service; Caller is the span that called it,
+// empty when the trace was started here.
+type Trace struct {
+	TraceID  string
+	ParentID string
+	Caller   string
+	Flags    byte
+	State    string
+}
+
+// Sampled reports whether the caller recorded the trace.
+func (t Trace) Sampled() bool {
+	return t.Flags&sampledFlag != 0
+}
+
+// Traceparent renders the header to send with requests made on behalf of
+// this one.
+func (t Trace) Traceparent() string {
+	return "00-" + t.TraceID + "-" + t.ParentID + "-" + hex.EncodeToString([]byte{t.Flags})
+}
+
+// ContinueTrace continues the trace described by the traceparent and
+// tracestate headers of an incoming request with a new span of this
+// service, or starts a new trace when traceparent is missing or invalid.
+func ContinueTrace(traceparent string, tracestate string) Trace {
+	trace, ok := parseTraceparent(traceparent)
+	if !ok {
+		return Trace{TraceID: randomHex(16), ParentID: randomHex(8), Flags: sampledFlag}
+	}
+
+	if len(tracestate) <= maxTracestateSize {
+		trace.State = strings.TrimSpace(tracestate)
+	}
+
+	trace.Caller = trace.ParentID
+	trace.ParentID = randomHex(8)
+	return trace
+}
+
+// parseTraceparent parses a version-format-version-00 header. Later versions
+// are read as version 00 when their prefix has its shape, as the
+// specification asks.
+func parseTraceparent(header string) (Trace, bool) {
+	header = strings.TrimSpace(header)
+	if len(header) < traceparentLength {
+		return Trace{}, false
+	}
+
+	version := header[0:2]
+	if !isLowerHex(version) || version == "ff" {
+		return Trace{}, false
+	}
+
+	if version == "00" && len(header) != traceparentLength {
+		return Trace{}, false
+	}
+
+	if len(header) > traceparentLength && header[traceparentLength] != '-' {
+		return Trace{}, false
+	}
+
+	if header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return Trace{}, false
+	}
+
+	traceID, parentID, flags := header[3:35], header[36:52], header[53:55]
+	if !isLowerHex(traceID) || !isLowerHex(parentID) || !isLowerHex(flags) {
+		return Trace{}, false
+	}
+
+	if strings.Trim(traceID, "0") == "" || strings.Trim(parentID, "0") == "" {
+		return Trace{}, false
+	}
+
+	f, _ := hex.DecodeString(flags)
+	return Trace{TraceID: traceID, ParentID: parentID, Flags: f[0]}, true
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
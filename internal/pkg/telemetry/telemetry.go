@@ -0,0 +1,103 @@
+package telemetry
+
+import (
+	"app/internal/pkg/config"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Provider owns the tracer provider installed as the global one. Code
+// creates spans through otel.Tracer, which is a no-op when tracing is off.
+type Provider struct {
+	shutdown func(ctx context.Context) error
+}
+
+// New installs the tracer provider selected by cfg.Exporter and the W3C
+// trace context propagator.
+func New(cfg *config.Telemetry, app *config.App) (*Provider, error) {
+	if cfg == nil || app == nil {
+		return nil, errors.New("telemetry.New: cfg is null")
+	}
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		return &Provider{shutdown: func(context.Context) error { return nil }}, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(context.Background(), opts...)
+	default:
+		return nil, fmt.Errorf("telemetry.New: unknown exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("telemetry.New: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(app.Name),
+		semconv.ServiceVersion(app.Version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("telemetry.New: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return &Provider{shutdown: provider.Shutdown}, nil
+}
+
+// Shutdown flushes the spans not exported yet.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if err := p.shutdown(ctx); err != nil {
+		return fmt.Errorf("telemetry.Shutdown: %w", err)
+	}
+	return nil
+}
+
+// Tracer returns the tracer for an instrumented package.
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,252 @@
+package token
+
+import (
+	"app/internal/pkg/config"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HS256 = "HS256"
+	EdDSA = "EdDSA"
+
+	TypeAccess      = "access"
+	TypeVerifyEmail = "verify_email"
+
+	minHMACSecretLength = 32
+
+	// examplePlaceholderSecret is the value earlier example configurations
+	// shipped with; signing with a publicly known secret is refused.
+	examplePlaceholderSecret = "change-me-to-a-random-secret-of-32-bytes-or-more"
+)
+
+var (
+	ErrInvalidToken = errors.New("token: invalid token")
+	ErrExpiredToken = errors.New("token: token is expired")
+)
+
+// Interface issues and verifies compact JWS tokens (RFC 7519). Type is a
+// private claim that keeps tokens minted for one purpose from being accepted
+// for another.
+type Interface interface {
+	Issue(subject string, typ string, ttl time.Duration, opts ...Option) (string, *Claims, error)
+	Verify(token string, typ string) (*Claims, error)
+}
+
+// Claims of a token. SessionID is the session an access token was issued
+// for; unlike ID it stays the same across refreshes.
+type Claims struct {
+	ID        string `json:"jti"`
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub"`
+	SessionID string `json:"sid,omitempty"`
+	Type      string `json:"typ"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+func (c *Claims) ExpiresAtTime() time.Time {
+	return time.Unix(c.ExpiresAt, 0).UTC()
+}
+
+// Option sets optional claims of an issued token.
+type Option func(*Claims)
+
+// WithSession ties the token to the session it was issued for.
+func WithSession(sessionID string) Option {
+	return func(c *Claims) {
+		c.SessionID = sessionID
+	}
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+}
+
+type signer interface {
+	algorithm() string
+	sign(payload []byte) []byte
+	verify(payload []byte, signature []byte) bool
+}
+
+var _ Interface = (*Manager)(nil)
+
+type Manager struct {
+	signer signer
+	issuer string
+	now    func() time.Time
+}
+
+func New(cfg *config.Auth) (*Manager, error) {
+	if cfg == nil {
+		return nil, errors.New("token.New: cfg is null")
+	}
+
+	var s signer
+	switch cfg.Algorithm {
+	case HS256:
+		if len(cfg.HMACSecret) < minHMACSecretLength {
+			return nil, fmt.Errorf("token.New: HMAC secret must be at least %d bytes", minHMACSecretLength)
+		}
+		if cfg.HMACSecret == examplePlaceholderSecret {
+			return nil, errors.New("token.New: HMAC secret is the example placeholder, generate a random one")
+		}
+		s = &hmacSigner{secret: []byte(cfg.HMACSecret)}
+	case EdDSA:
+		key, err := parseEd25519PrivateKey(cfg.Ed25519PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("token.New: %w", err)
+		}
+		s = &ed25519Signer{private: key, public: key.Public().(ed25519.PublicKey)}
+	default:
+		return nil, fmt.Errorf("token.New: unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	return &Manager{
+		signer: s,
+		issuer: cfg.Issuer,
+		now:    time.Now,
+	}, nil
+}
+
+func (m *Manager) Issue(subject string, typ string, ttl time.Duration, opts ...Option) (string, *Claims, error) {
+	now := m.now()
+	claims := &Claims{
+		ID:        uuid.NewString(),
+		Issuer:    m.issuer,
+		Subject:   subject,
+		Type:      typ,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+	for _, opt := range opts {
+		opt(claims)
+	}
+
+	h, err := json.Marshal(header{Algorithm: m.signer.algorithm(), Type: "JWT"})
+	if err != nil {
+		return "", nil, fmt.Errorf("token.Issue: %w", err)
+	}
+
+	p, err := json.Marshal(claims)
+	if err != nil {
+		return "", nil, fmt.Errorf("token.Issue: %w", err)
+	}
+
+	signingInput := encode(h) + "." + encode(p)
+	signature := m.signer.sign([]byte(signingInput))
+
+	return signingInput + "." + encode(signature), claims, nil
+}
+
+func (m *Manager) Verify(token string, typ string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var h header
+	if err := decodeJSON(parts[0], &h); err != nil || h.Algorithm != m.signer.algorithm() {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if !m.signer.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return nil, ErrInvalidToken
+	}
+
+	claims := &Claims{}
+	if err = decodeJSON(parts[1], claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Type != typ || claims.Subject == "" || (m.issuer != "" && claims.Issuer != m.issuer) {
+		return nil, ErrInvalidToken
+	}
+
+	if m.now().Unix() >= claims.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+
+	return claims, nil
+}
+
+type hmacSigner struct {
+	secret []byte
+}
+
+func (s *hmacSigner) algorithm() string {
+	return HS256
+}
+
+func (s *hmacSigner) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func (s *hmacSigner) verify(payload []byte, signature []byte) bool {
+	return hmac.Equal(s.sign(payload), signature)
+}
+
+type ed25519Signer struct {
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+func (s *ed25519Signer) algorithm() string {
+	return EdDSA
+}
+
+func (s *ed25519Signer) sign(payload []byte) []byte {
+	return ed25519.Sign(s.private, payload)
+}
+
+func (s *ed25519Signer) verify(payload []byte, signature []byte) bool {
+	return ed25519.Verify(s.public, payload, signature)
+}
+
+// parseEd25519PrivateKey accepts either a 32 byte seed or a 64 byte private
+// key, base64 encoded.
+func parseEd25519PrivateKey(value string) (ed25519.PrivateKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("ed25519 private key is not valid base64: %w", err)
+	}
+
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	default:
+		return nil, errors.New("ed25519 private key must be a 32 byte seed or a 64 byte key")
+	}
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeJSON(segment string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, v)
+}
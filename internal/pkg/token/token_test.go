@@ -0,0 +1,149 @@
+package token_test
+
+import (
+	"app/internal/pkg/config"
+	"app/internal/pkg/token"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+func newManager(t *testing.T, cfg *config.Auth) *token.Manager {
+	t.Helper()
+
+	m, err := token.New(cfg)
+	if err != nil {
+		t.Fatalf("token.New: %v", err)
+	}
+	return m
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.Auth
+		err  bool
+	}{
+		{"no config", nil, true},
+		{"unknown algorithm", &config.Auth{Algorithm: "RS256"}, true},
+		{"short secret", &config.Auth{Algorithm: token.HS256, HMACSecret: "short"}, true},
+		{"placeholder secret", &config.Auth{Algorithm: token.HS256, HMACSecret: "change-me-to-a-random-secret-of-32-bytes-or-more"}, true},
+		{"ed25519 key of the wrong size", &config.Auth{Algorithm: token.EdDSA, Ed25519PrivateKey: base64.StdEncoding.EncodeToString([]byte("short"))}, true},
+		{"hmac", &config.Auth{Algorithm: token.HS256, HMACSecret: secret}, false},
+		{"ed25519 seed", &config.Auth{Algorithm: token.EdDSA, Ed25519PrivateKey: base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize))}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := token.New(tt.cfg)
+			if (err != nil) != tt.err {
+				t.Fatalf("token.New() error = %v, want error %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	hmac := newManager(t, &config.Auth{Algorithm: token.HS256, HMACSecret: secret, Issuer: "app"})
+	other := newManager(t, &config.Auth{Algorithm: token.HS256, HMACSecret: strings.Repeat("x", 32), Issuer: "app"})
+	otherIssuer := newManager(t, &config.Auth{Algorithm: token.HS256, HMACSecret: secret, Issuer: "other"})
+	eddsa := newManager(t, &config.Auth{Algorithm: token.EdDSA, Ed25519PrivateKey: base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize))})
+
+	issue := func(m *token.Manager, subject string, typ string, ttl time.Duration) string {
+		t.Helper()
+		signed, _, err := m.Issue(subject, typ, ttl)
+		if err != nil {
+			t.Fatalf("Issue: %v", err)
+		}
+		return signed
+	}
+
+	valid := issue(hmac, "user", token.TypeAccess, time.Minute)
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name    string
+		manager *token.Manager
+		token   string
+		typ     string
+		err     error
+	}{
+		{"valid hmac", hmac, valid, token.TypeAccess, nil},
+		{"valid ed25519", eddsa, issue(eddsa, "user", token.TypeAccess, time.Minute), token.TypeAccess, nil},
+		{"expired", hmac, issue(hmac, "user", token.TypeAccess, -time.Second), token.TypeAccess, token.ErrExpiredToken},
+		{"other type", hmac, valid, token.TypeVerifyEmail, token.ErrInvalidToken},
+		{"other secret", other, valid, token.TypeAccess, token.ErrInvalidToken},
+		{"other issuer", otherIssuer, valid, token.TypeAccess, token.ErrInvalidToken},
+		{"other algorithm", eddsa, valid, token.TypeAccess, token.ErrInvalidToken},
+		{"no subject", hmac, issue(hmac, "", token.TypeAccess, time.Minute), token.TypeAccess, token.ErrInvalidToken},
+		{"tampered payload", hmac, parts[0] + "." + parts[1] + "x." + parts[2], token.TypeAccess, token.ErrInvalidToken},
+		{"two segments", hmac, parts[0] + "." + parts[1], token.TypeAccess, token.ErrInvalidToken},
+		{"empty", hmac, "", token.TypeAccess, token.ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.manager.Verify(tt.token, tt.typ)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.err)
+			}
+			if err == nil && claims.Subject != "user" {
+				t.Fatalf("Verify() subject = %q, want %q", claims.Subject, "user")
+			}
+		})
+	}
+}
+
+func TestIssueClaims(t *testing.T) {
+	m := newManager(t, &config.Auth{Algorithm: token.HS256, HMACSecret: secret, Issuer: "app"})
+
+	before := time.Now().Unix()
+	_, claims, err := m.Issue("user", token.TypeAccess, time.Hour)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	if claims.ID == "" || claims.Issuer != "app" || claims.Type != token.TypeAccess {
+		t.Fatalf("Issue() claims = %+v", claims)
+	}
+	if got := claims.ExpiresAt - claims.IssuedAt; got != int64(time.Hour/time.Second) {
+		t.Fatalf("Issue() lifetime = %ds, want %ds", got, int64(time.Hour/time.Second))
+	}
+	if claims.IssuedAt < before {
+		t.Fatalf("Issue() issued at %d, before %d", claims.IssuedAt, before)
+	}
+}
+
+func TestIssueSession(t *testing.T) {
+	m := newManager(t, &config.Auth{Algorithm: token.HS256, HMACSecret: secret, Issuer: "app"})
+
+	first, _, err := m.Issue("user", token.TypeAccess, time.Hour, token.WithSession("session"))
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	second, _, err := m.Issue("user", token.TypeAccess, time.Hour, token.WithSession("session"))
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	a, err := m.Verify(first, token.TypeAccess)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	b, err := m.Verify(second, token.TypeAccess)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	if a.SessionID != "session" || b.SessionID != "session" {
+		t.Fatalf("Verify() sessions = %q, %q, want session", a.SessionID, b.SessionID)
+	}
+	if a.ID == b.ID {
+		t.Fatalf("Verify() token IDs = %q, want one per token", a.ID)
+	}
+}
@@ -0,0 +1,76 @@
+package validation
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	CodePasswordLowercase = "password_lowercase"
+	CodePasswordUppercase = "password_uppercase"
+	CodePasswordDigit     = "password_digit"
+	CodePasswordSymbol    = "password_symbol"
+)
+
+// PasswordPolicy measures MinLength in characters and MaxLength in bytes,
+// since the latter is what the hash functions are limited by.
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireLower  bool
+	RequireUpper  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// DefaultPasswordPolicy caps passwords at 72 bytes, the most bcrypt accepts.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:    8,
+	MaxLength:    72,
+	RequireLower: true,
+	RequireUpper: true,
+	RequireDigit: true,
+}
+
+// Password reports every requirement of policy the value does not meet.
+func Password(policy PasswordPolicy) Rule {
+	return func(value string) []Violation {
+		var violations []Violation
+
+		if utf8.RuneCountInString(value) < policy.MinLength {
+			violations = append(violations, violation(CodeTooShort, "must be at least %d characters long", policy.MinLength)...)
+		}
+		if policy.MaxLength > 0 && len(value) > policy.MaxLength {
+			violations = append(violations, violation(CodeTooLong, "must be at most %d bytes long", policy.MaxLength)...)
+		}
+
+		var lower, upper, digit, symbol bool
+		for _, r := range value {
+			switch {
+			case unicode.IsLower(r):
+				lower = true
+			case unicode.IsUpper(r):
+				upper = true
+			case unicode.IsDigit(r):
+				digit = true
+			case unicode.IsPunct(r) || unicode.IsSymbol(r):
+				symbol = true
+			}
+		}
+
+		if policy.RequireLower && !lower {
+			violations = append(violations, violation(CodePasswordLowercase, "must contain a lowercase letter")...)
+		}
+		if policy.RequireUpper && !upper {
+			violations = append(violations, violation(CodePasswordUppercase, "must contain an uppercase letter")...)
+		}
+		if policy.RequireDigit && !digit {
+			violations = append(violations, violation(CodePasswordDigit, "must contain a digit")...)
+		}
+		if policy.RequireSymbol && !symbol {
+			violations = append(violations, violation(CodePasswordSymbol, "must contain a symbol")...)
+		}
+
+		return violations
+	}
+}
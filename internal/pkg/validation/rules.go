@@ -0,0 +1,80 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func violation(code string, format string, args ...interface{}) []Violation {
+	return []Violation{{Code: code, Message: fmt.Sprintf(format, args...)}}
+}
+
+func Required() Rule {
+	return func(value string) []Violation {
+		if value == "" {
+			return violation(CodeRequired, "must not be empty")
+		}
+		return nil
+	}
+}
+
+// Length bounds the number of characters (not bytes) of the value. A zero
+// max means no upper bound.
+func Length(min int, max int) Rule {
+	return func(value string) []Violation {
+		n := utf8.RuneCountInString(value)
+		if n < min {
+			return violation(CodeTooShort, "must be at least %d characters long", min)
+		}
+		if max > 0 && n > max {
+			return violation(CodeTooLong, "must be at most %d characters long", max)
+		}
+		return nil
+	}
+}
+
+// Charset accepts values made only of runes allowed reports true for;
+// description is used in the message, e.g. "letters, digits and ._-".
+func Charset(description string, allowed func(r rune) bool) Rule {
+	return func(value string) []Violation {
+		for _, r := range value {
+			if !allowed(r) {
+				return violation(CodeInvalidCharset, "may only contain %s", description)
+			}
+		}
+		return nil
+	}
+}
+
+// Login accepts ASCII letters, digits and the characters in extra.
+func Login(extra string) Rule {
+	return Charset("letters, digits and "+extra, func(r rune) bool {
+		if r > unicode.MaxASCII {
+			return false
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return true
+		}
+		for _, e := range extra {
+			if r == e {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Email accepts a bare addr-spec such as "user@example.com"; display names
+// and angle brackets are refused.
+func Email() Rule {
+	return func(value string) []Violation {
+		addr, err := mail.ParseAddress(value)
+		if err != nil || addr.Address != value || !strings.Contains(value[strings.LastIndex(value, "@")+1:], ".") {
+			return violation(CodeInvalidEmail, "must be a valid email address")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"strings"
+)
+
+const (
+	CodeRequired       = "required"
+	CodeTooShort       = "too_short"
+	CodeTooLong        = "too_long"
+	CodeInvalidCharset = "invalid_charset"
+	CodeInvalidEmail   = "invalid_email"
+)
+
+// Validatable is implemented by request models that can check themselves.
+type Validatable interface {
+	Validate() error
+}
+
+type Violation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors is the set of violations found in one value. It is returned as an
+// error so that it can travel through the usual error paths.
+type Errors []Violation
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, v := range e {
+		messages = append(messages, v.Field+": "+v.Message)
+	}
+
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Rule checks a single value and returns every violation it finds; the field
+// name is filled in by the Validator.
+type Rule func(value string) []Violation
+
+// Validator collects violations of several fields instead of stopping at the
+// first one.
+type Validator struct {
+	errors Errors
+}
+
+func New() *Validator {
+	return &Validator{}
+}
+
+// Field applies every rule to value. For an empty value only a Required
+// violation is reported, wherever Required is in rules: the other rules
+// would just repeat that the value is missing.
+func (v *Validator) Field(name string, value string, rules ...Rule) *Validator {
+	for _, rule := range rules {
+		for _, violation := range rule(value) {
+			if value == "" && violation.Code != CodeRequired {
+				continue
+			}
+
+			violation.Field = name
+			v.errors = append(v.errors, violation)
+		}
+	}
+
+	return v
+}
+
+// Add records a violation found outside of a Rule, e.g. a structural one.
+func (v *Validator) Add(field string, code string, message string) *Validator {
+	v.errors = append(v.errors, Violation{Field: field, Code: code, Message: message})
+	return v
+}
+
+// Err returns the collected violations as Errors, or nil if there are none.
+func (v *Validator) Err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+
+	return v.errors
+}
@@ -0,0 +1,141 @@
+package validation_test
+
+import (
+	"app/internal/pkg/validation"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func codes(violations []validation.Violation) []string {
+	result := make([]string, 0, len(violations))
+	for _, v := range violations {
+		result = append(result, v.Code)
+	}
+	return result
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  validation.Rule
+		value string
+		codes []string
+	}{
+		{"required empty", validation.Required(), "", []string{validation.CodeRequired}},
+		{"required set", validation.Required(), "x", []string{}},
+		{"length too short", validation.Length(3, 5), "ab", []string{validation.CodeTooShort}},
+		{"length too long", validation.Length(3, 5), "abcdef", []string{validation.CodeTooLong}},
+		{"length counts characters", validation.Length(3, 3), "äöü", []string{}},
+		{"length without max", validation.Length(1, 0), strings.Repeat("a", 1000), []string{}},
+		{"login allowed", validation.Login("._-"), "alice.b-c_1", []string{}},
+		{"login space", validation.Login("._-"), "alice b", []string{validation.CodeInvalidCharset}},
+		{"login non ascii", validation.Login("._-"), "älice", []string{validation.CodeInvalidCharset}},
+		{"email valid", validation.Email(), "alice@example.com", []string{}},
+		{"email without domain dot", validation.Email(), "alice@localhost", []string{validation.CodeInvalidEmail}},
+		{"email with display name", validation.Email(), "Alice <alice@example.com>", []string{validation.CodeInvalidEmail}},
+		{"email without at", validation.Email(), "alice.example.com", []string{validation.CodeInvalidEmail}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codes(tt.rule(tt.value)); !slices.Equal(got, tt.codes) {
+				t.Fatalf("rule(%q) = %v, want %v", tt.value, got, tt.codes)
+			}
+		})
+	}
+}
+
+func TestPassword(t *testing.T) {
+	strict := validation.DefaultPasswordPolicy
+	strict.RequireSymbol = true
+
+	tests := []struct {
+		name   string
+		policy validation.PasswordPolicy
+		value  string
+		codes  []string
+	}{
+		{"meets default", validation.DefaultPasswordPolicy, "Passw0rdOK", []string{}},
+		{"too short", validation.DefaultPasswordPolicy, "Pa0", []string{validation.CodeTooShort}},
+		{"too long in bytes", validation.DefaultPasswordPolicy, "Pa0" + strings.Repeat("ä", 35), []string{validation.CodeTooLong}},
+		{"only lowercase", validation.DefaultPasswordPolicy, "password", []string{validation.CodePasswordUppercase, validation.CodePasswordDigit}},
+		{"missing symbol", strict, "Passw0rdOK", []string{validation.CodePasswordSymbol}},
+		{"with symbol", strict, "Passw0rd!", []string{}},
+		{"everything missing", strict, "", []string{
+			validation.CodeTooShort, validation.CodePasswordLowercase, validation.CodePasswordUppercase,
+			validation.CodePasswordDigit, validation.CodePasswordSymbol,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codes(validation.Password(tt.policy)(tt.value)); !slices.Equal(got, tt.codes) {
+				t.Fatalf("Password(%q) = %v, want %v", tt.value, got, tt.codes)
+			}
+		})
+	}
+}
+
+func TestValidator(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(v *validation.Validator)
+		fields   []string
+		codes    []string
+	}{
+		{
+			name: "valid",
+			validate: func(v *validation.Validator) {
+				v.Field("login", "alice", validation.Required(), validation.Length(3, 32))
+			},
+		},
+		{
+			name: "empty value only reports required",
+			validate: func(v *validation.Validator) {
+				v.Field("login", "", validation.Length(3, 32), validation.Required())
+			},
+			fields: []string{"login"},
+			codes:  []string{validation.CodeRequired},
+		},
+		{
+			name: "violations of several fields are collected",
+			validate: func(v *validation.Validator) {
+				v.Field("login", "a", validation.Required(), validation.Length(3, 32)).
+					Field("email", "nope", validation.Email()).
+					Add("body", "invalid", "is not an object")
+			},
+			fields: []string{"login", "email", "body"},
+			codes:  []string{validation.CodeTooShort, validation.CodeInvalidEmail, "invalid"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validation.New()
+			tt.validate(v)
+
+			err := v.Err()
+			if len(tt.codes) == 0 {
+				if err != nil {
+					t.Fatalf("Err() = %v, want nil", err)
+				}
+				return
+			}
+
+			var violations validation.Errors
+			if !errors.As(err, &violations) {
+				t.Fatalf("Err() = %v, want validation.Errors", err)
+			}
+
+			fields := make([]string, 0, len(violations))
+			for _, violation := range violations {
+				fields = append(fields, violation.Field)
+			}
+			if !slices.Equal(fields, tt.fields) || !slices.Equal(codes(violations), tt.codes) {
+				t.Fatalf("Err() = %v, want fields %v with codes %v", violations, tt.fields, tt.codes)
+			}
+		})
+	}
+}